@@ -0,0 +1,7 @@
+data "docker_client_cache" "stats" {
+  depends_on = [docker_container.app]
+}
+
+output "docker_client_cache_hit_rate" {
+  value = data.docker_client_cache.stats.hits / max(1, data.docker_client_cache.stats.hits + data.docker_client_cache.stats.misses)
+}
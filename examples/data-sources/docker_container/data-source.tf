@@ -0,0 +1,10 @@
+# a container which is managed by another configuration
+data "docker_container" "db" {
+  name = "postgres"
+}
+
+resource "docker_container" "app" {
+  name  = "app"
+  image = "myapp:latest"
+  env   = ["DATABASE_HOST=${data.docker_container.db.network_data[0].ip_address}"]
+}
@@ -0,0 +1,12 @@
+# save the image on a host with registry access
+data "docker_image_save" "nginx" {
+  name = "nginx:1.25"
+  path = "${path.module}/nginx.tar"
+}
+
+# load it on an air-gapped host
+resource "docker_image" "nginx" {
+  provider   = docker.airgapped
+  name       = "nginx:1.25"
+  source_tar = data.docker_image_save.nginx.path
+}
@@ -0,0 +1,12 @@
+data "docker_registry_image_manifest" "alpine" {
+  name = "alpine:latest"
+}
+
+locals {
+  alpine_arm64 = [for m in data.docker_registry_image_manifest.alpine.manifests : m.digest if m.os == "linux" && m.architecture == "arm64"][0]
+}
+
+resource "docker_image" "alpine" {
+  name     = "alpine@${local.alpine_arm64}"
+  platform = "linux/arm64"
+}
@@ -0,0 +1,10 @@
+data "docker_registry_token" "registry" {
+  address = "registry.example.com"
+  scopes  = ["repository:team/app:pull,push"]
+}
+
+resource "docker_container" "kaniko" {
+  name  = "kaniko"
+  image = "gcr.io/kaniko-project/executor:latest"
+  env   = ["REGISTRY_TOKEN=${data.docker_registry_token.registry.token}"]
+}
@@ -0,0 +1,11 @@
+data "docker_resources_by_label" "team" {
+  label_filters = ["com.example.team=payments"]
+}
+
+output "team_volumes" {
+  value = data.docker_resources_by_label.team.volumes[*].name
+}
+
+output "stopped_team_containers" {
+  value = [for c in data.docker_resources_by_label.team.containers : c.name if c.state != "running"]
+}
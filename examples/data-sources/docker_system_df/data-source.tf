@@ -0,0 +1,5 @@
+data "docker_system_df" "host" {}
+
+output "reclaimable_image_bytes" {
+  value = data.docker_system_df.host.images[0].reclaimable
+}
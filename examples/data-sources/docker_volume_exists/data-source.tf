@@ -0,0 +1,27 @@
+data "docker_volume_exists" "legacy" {
+  name = "legacy-data"
+}
+
+resource "docker_volume" "data" {
+  name = "app-data"
+}
+
+# copy the data of the previous setup, only if its volume is still around
+resource "docker_task" "migrate" {
+  count   = data.docker_volume_exists.legacy.exists ? 1 : 0
+  image   = "alpine:latest"
+  command = ["cp", "-a", "/legacy/.", "/data/"]
+
+  mounts {
+    type      = "volume"
+    source    = "legacy-data"
+    target    = "/legacy"
+    read_only = true
+  }
+
+  mounts {
+    type   = "volume"
+    source = docker_volume.data.name
+    target = "/data"
+  }
+}
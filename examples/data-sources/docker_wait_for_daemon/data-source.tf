@@ -0,0 +1,13 @@
+data "docker_wait_for_daemon" "vm" {
+  host    = "ssh://admin@${aws_instance.docker.public_ip}:22"
+  timeout = "10m"
+}
+
+resource "docker_container" "foo" {
+  name  = "foo"
+  image = "nginx:latest"
+
+  override {
+    host = data.docker_wait_for_daemon.vm.host
+  }
+}
\ No newline at end of file
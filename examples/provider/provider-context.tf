@@ -0,0 +1,4 @@
+provider "docker" {
+  # created with `docker context create prod --docker "host=tcp://prod.example.com:2376,ca=ca.pem,cert=cert.pem,key=key.pem"`
+  docker_context = "prod"
+}
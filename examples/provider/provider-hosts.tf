@@ -0,0 +1,18 @@
+provider "docker" {
+  host = "unix:///var/run/docker.sock"
+
+  hosts {
+    name      = "prod"
+    host      = "tcp://prod.example.com:2376"
+    cert_path = pathexpand("~/.docker/prod")
+  }
+}
+
+resource "docker_container" "foo" {
+  name  = "foo"
+  image = "nginx:latest"
+
+  override {
+    host = "prod"
+  }
+}
\ No newline at end of file
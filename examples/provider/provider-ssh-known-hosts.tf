@@ -0,0 +1,5 @@
+provider "docker" {
+  host                         = "ssh://user@remote-host:22"
+  ssh_known_hosts_file         = "/ci/known_hosts"
+  ssh_strict_host_key_checking = "yes"
+}
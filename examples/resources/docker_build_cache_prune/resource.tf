@@ -0,0 +1,17 @@
+# Prune the build cache older than a day, keeping 10GB, bump the generation to prune again
+variable "prune_generation" {
+  default = "1"
+}
+
+resource "docker_build_cache_prune" "nightly" {
+  all          = true
+  keep_storage = "10GB"
+
+  filters = {
+    until = "24h"
+  }
+
+  triggers = {
+    generation = var.prune_generation
+  }
+}
@@ -0,0 +1,12 @@
+resource "docker_image" "zoo" {
+  name = "zoo"
+  build {
+    context = "."
+
+    # build on a dedicated build node and transfer the image to the Docker host of the provider
+    builder {
+      host     = "ssh://builder@build-node:22"
+      ssh_opts = ["-o", "ConnectTimeout=10"]
+    }
+  }
+}
\ No newline at end of file
@@ -0,0 +1,9 @@
+resource "docker_image" "zoo" {
+  name = "registry.example.com/zoo:1.0"
+  build {
+    context        = "."
+    platforms      = ["linux/amd64", "linux/arm64"]
+    push           = true
+    buildx_builder = "multiarch"
+  }
+}
\ No newline at end of file
@@ -0,0 +1,8 @@
+resource "docker_image" "app" {
+  name = "registry.example.com/team/app:1.0"
+
+  # fail the apply unless the image was signed with `cosign sign --key cosign.key`
+  verify_signature {
+    public_key = file("${path.module}/cosign.pub")
+  }
+}
@@ -0,0 +1,16 @@
+# Containers of the network get their own MAC and IP address on VLAN 10 of the physical network
+resource "docker_network" "lan" {
+  name   = "lan"
+  driver = "macvlan"
+
+  options = {
+    parent       = "eth0.10"
+    macvlan_mode = "bridge"
+  }
+
+  ipam_config {
+    subnet   = "192.168.10.0/24"
+    gateway  = "192.168.10.1"
+    ip_range = "192.168.10.128/25"
+  }
+}
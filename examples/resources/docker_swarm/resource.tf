@@ -0,0 +1,18 @@
+resource "docker_swarm" "cluster" {
+  advertise_addr = "10.0.0.2"
+
+  # the host is the only manager, so it can only leave the swarm with force
+  force_leave = true
+}
+
+resource "docker_secret" "db_password" {
+  name = "db-password"
+  data = base64encode("s3cr3t")
+
+  depends_on = [docker_swarm.cluster]
+}
+
+output "worker_join_token" {
+  value     = docker_swarm.cluster.worker_join_token
+  sensitive = true
+}
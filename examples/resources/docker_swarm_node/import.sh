@@ -0,0 +1,2 @@
+#!/bin/bash
+terraform import docker_swarm_node.worker "$(docker node inspect -f {{.ID}} worker-1)"
\ No newline at end of file
@@ -0,0 +1,10 @@
+# Drain a node for maintenance and label it for placement constraints
+resource "docker_swarm_node" "worker" {
+  node         = "worker-1"
+  availability = "drain"
+
+  labels {
+    label = "zone"
+    value = "eu-central-1a"
+  }
+}
@@ -0,0 +1,21 @@
+# Run the migrations of the database whenever the version of the app changes
+resource "docker_task" "migrate" {
+  image   = "registry.example.com/app:${var.app_version}"
+  command = ["migrate", "up"]
+  env     = ["DATABASE_URL=postgres://db:5432/app"]
+
+  mounts {
+    type      = "volume"
+    source    = docker_volume.migrations.name
+    target    = "/migrations"
+    read_only = true
+  }
+
+  triggers = {
+    version = var.app_version
+  }
+}
+
+output "migrate_output" {
+  value = docker_task.migrate.stdout
+}
@@ -0,0 +1,5 @@
+#!/bin/bash
+# by the mountpoint of the volume on the host
+terraform import docker_volume.foo /var/lib/docker/volumes/shared_volume/_data
+# by driver and name
+terraform import docker_volume.foo local/shared_volume
\ No newline at end of file
@@ -0,0 +1,13 @@
+# Back up the content of a volume into an archive
+resource "docker_volume_backup" "backup" {
+  volume = "postgres_data"
+  path   = "${path.module}/backups/postgres_data.tar"
+}
+
+# Restore the archive into a volume, e.g. on another Docker host
+resource "docker_volume_backup" "restore" {
+  provider  = docker.target
+  volume    = "postgres_data"
+  path      = "${path.module}/backups/postgres_data.tar"
+  direction = "restore"
+}
@@ -0,0 +1,18 @@
+# Prune the unused volumes of the project, bump the generation to prune again
+variable "prune_generation" {
+  default = "1"
+}
+
+resource "docker_volume_prune" "project" {
+  label_filters = ["com.example.project=shop"]
+
+  triggers = {
+    generation = var.prune_generation
+  }
+}
+
+# Preview which volumes would be pruned
+resource "docker_volume_prune" "preview" {
+  label_filters = ["com.example.project=shop"]
+  dry_run       = true
+}
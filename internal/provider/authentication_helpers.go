@@ -68,3 +68,19 @@ func setupHTTPHeadersForRegistryRequests(req *http.Request, fallback bool) {
 		req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.v1+prettyjws")
 	}
 }
+
+// setRegistryAuthHeader adds the credentials to a request against the registry API
+// in the form the given registry expects them.
+func setRegistryAuthHeader(req *http.Request, registry, username, password string) {
+	if username == "" {
+		return
+	}
+	if registry != "ghcr.io" && !isECRRepositoryURL(registry) && !isAzureCRRepositoryURL(registry) && registry != "gcr.io" {
+		req.SetBasicAuth(username, password)
+	} else if isECRRepositoryURL(registry) {
+		password = normalizeECRPasswordForHTTPUsage(password)
+		req.Header.Add("Authorization", "Basic "+password)
+	} else {
+		req.Header.Add("Authorization", "Bearer "+b64.StdEncoding.EncodeToString([]byte(password)))
+	}
+}
@@ -1,24 +1,36 @@
 package provider
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net"
 	"net/http"
+	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/docker/cli/cli/connhelper"
+	"github.com/docker/cli/cli/connhelper/ssh"
+	"github.com/docker/docker/api"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 
 	"context"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"hash/fnv"
 	"sort"
@@ -33,11 +45,31 @@ type Config struct {
 	Cert     string
 	Key      string
 	CertPath string
+	Insecure bool
+	// APIVersion is the pinned API version of a host of the provider, empty
+	// to use the api_version of the provider
+	APIVersion string
+	// HTTPHeaders are sent with each request, e.g. for an authenticating
+	// proxy in front of the Docker API
+	HTTPHeaders map[string]string
+	// CacheKeySuffix separates the cached client and the concurrency limit of
+	// otherwise equal configs, see cache_key_suffix
+	CacheKeySuffix string
 }
 
 func NewConfig(d *schema.ResourceData) *Config {
 	//log.Println("NewConfig")
 
+	// Check for override block and assign values
+	if v, ok := d.GetOk("override"); ok {
+		return newConfigFromOverride(v.([]interface{}))
+	}
+	return newConfigFromOverride(nil)
+}
+
+// newConfigFromOverride returns the config of an override block, e.g. of the
+// override attribute of a resource or of the builder of an image build.
+func newConfigFromOverride(override []interface{}) *Config {
 	config := Config{
 		Host:     "",
 		SSHOpts:  make([]string, 0),
@@ -47,24 +79,28 @@ func NewConfig(d *schema.ResourceData) *Config {
 		CertPath: "",
 	}
 
-	// Check for override block and assign values
-	if v, ok := d.GetOk("override"); ok {
-		if len(v.([]interface{})) > 0 {
-			o := v.([]interface{})[0].(map[string]interface{})
+	if len(override) > 0 && override[0] != nil {
+		o := override[0].(map[string]interface{})
 
-			SSHOptsI := o["ssh_opts"].([]interface{})
-			SSHOpts := make([]string, len(SSHOptsI))
-			for i, s := range SSHOptsI {
-				SSHOpts[i] = s.(string)
-			}
-			config = Config{
-				Host:     o["host"].(string),
-				SSHOpts:  SSHOpts,
-				Ca:       o["ca_material"].(string),
-				Cert:     o["cert_material"].(string),
-				Key:      o["key_material"].(string),
-				CertPath: o["cert_path"].(string),
-			}
+		SSHOptsI := o["ssh_opts"].([]interface{})
+		SSHOpts := make([]string, len(SSHOptsI))
+		for i, s := range SSHOptsI {
+			SSHOpts[i] = s.(string)
+		}
+		config = Config{
+			Host:     o["host"].(string),
+			SSHOpts:  SSHOpts,
+			Ca:       o["ca_material"].(string),
+			Cert:     o["cert_material"].(string),
+			Key:      o["key_material"].(string),
+			CertPath: o["cert_path"].(string),
+			Insecure: o["insecure"].(bool),
+		}
+		if headers, ok := o["http_headers"].(map[string]interface{}); ok && len(headers) > 0 {
+			config.HTTPHeaders = mapTypeMapValsToString(headers)
+		}
+		if suffix, ok := o["cache_key_suffix"].(string); ok {
+			config.CacheKeySuffix = suffix
 		}
 	}
 
@@ -72,11 +108,17 @@ func NewConfig(d *schema.ResourceData) *Config {
 }
 
 func (c *Config) Hash() uint64 {
-	var SSHOpts []string
+	SSHOpts := make([]string, len(c.SSHOpts))
 
 	copy(SSHOpts, c.SSHOpts)
 	sort.Strings(SSHOpts)
 
+	headers := make([]string, 0, len(c.HTTPHeaders))
+	for name, value := range c.HTTPHeaders {
+		headers = append(headers, name+"="+value)
+	}
+	sort.Strings(headers)
+
 	hash := fnv.New64()
 	_, err := hash.Write([]byte(strings.Join([]string{
 		c.Host,
@@ -84,7 +126,11 @@ func (c *Config) Hash() uint64 {
 		c.Cert,
 		c.Key,
 		c.CertPath,
-		strings.Join(SSHOpts, "|")},
+		strconv.FormatBool(c.Insecure),
+		c.APIVersion,
+		strings.Join(SSHOpts, "|"),
+		strings.Join(headers, "|"),
+		c.CacheKeySuffix},
 		"|",
 	)))
 	if err != nil {
@@ -94,10 +140,56 @@ func (c *Config) Hash() uint64 {
 	return hash.Sum64()
 }
 
+// Equal reports whether both configs connect to the same Docker host in the same way.
+// Used to verify cache hits, as different configs can have the same Hash.
+func (c *Config) Equal(other *Config) bool {
+	if other == nil {
+		return false
+	}
+	if c.Host != other.Host || c.Ca != other.Ca || c.Cert != other.Cert || c.Key != other.Key || c.CertPath != other.CertPath || c.Insecure != other.Insecure || c.APIVersion != other.APIVersion || c.CacheKeySuffix != other.CacheKeySuffix {
+		return false
+	}
+	if len(c.SSHOpts) != len(other.SSHOpts) {
+		return false
+	}
+	for i := range c.SSHOpts {
+		if c.SSHOpts[i] != other.SSHOpts[i] {
+			return false
+		}
+	}
+	if len(c.HTTPHeaders) != len(other.HTTPHeaders) {
+		return false
+	}
+	for name, value := range c.HTTPHeaders {
+		if otherValue, ok := other.HTTPHeaders[name]; !ok || otherValue != value {
+			return false
+		}
+	}
+	return true
+}
+
 // buildHTTPClientFromBytes builds the http client from bytes (content of the files)
 func buildHTTPClientFromBytes(caPEMCert, certPEMBlock, keyPEMBlock []byte) (*http.Client, error) {
+	return buildHTTPClient(caPEMCert, certPEMBlock, keyPEMBlock, tlsClientOptions{})
+}
+
+// tlsClientOptions are the optional TLS settings of buildHTTPClient.
+type tlsClientOptions struct {
+	// getClientCertificate provides the client certificate for each TLS handshake
+	// instead of the static certificate, see reload_certs
+	getClientCertificate func(*tls.CertificateRequestInfo) (*tls.Certificate, error)
+	// appendSystemCAs adds the CA to the system trust store instead of trusting
+	// only the CA, see ca_append_system
+	appendSystemCAs bool
+}
+
+// buildHTTPClient builds the http client like buildHTTPClientFromBytes, with the
+// optional TLS settings.
+func buildHTTPClient(caPEMCert, certPEMBlock, keyPEMBlock []byte, options tlsClientOptions) (*http.Client, error) {
 	tlsConfig := &tls.Config{}
-	if certPEMBlock != nil && keyPEMBlock != nil {
+	if options.getClientCertificate != nil {
+		tlsConfig.GetClientCertificate = options.getClientCertificate
+	} else if certPEMBlock != nil && keyPEMBlock != nil {
 		tlsCert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
 		if err != nil {
 			return nil, err
@@ -109,6 +201,13 @@ func buildHTTPClientFromBytes(caPEMCert, certPEMBlock, keyPEMBlock []byte) (*htt
 		tlsConfig.InsecureSkipVerify = true
 	} else {
 		caPool := x509.NewCertPool()
+		if options.appendSystemCAs {
+			systemPool, err := x509.SystemCertPool()
+			if err != nil {
+				return nil, fmt.Errorf("unable to load the system trust store: %w", err)
+			}
+			caPool = systemPool
+		}
 		if !caPool.AppendCertsFromPEM(caPEMCert) {
 			return nil, errors.New("could not add RootCA pem")
 		}
@@ -120,6 +219,39 @@ func buildHTTPClientFromBytes(caPEMCert, certPEMBlock, keyPEMBlock []byte) (*htt
 	return &http.Client{Transport: tr}, nil
 }
 
+// certPathClientCertificate returns the client certificate and key of the
+// cert_path, or a callback which reads them for each connection if reload_certs
+// is set.
+func (c *ProviderConfig) certPathClientCertificate(certPath string) ([]byte, []byte, func(*tls.CertificateRequestInfo) (*tls.Certificate, error), error) {
+	certFile := filepath.Join(certPath, "cert.pem")
+	keyFile := filepath.Join(certPath, "key.pem")
+	if c.ReloadCerts {
+		return nil, nil, reloadClientCertificate(certFile, keyFile), nil
+	}
+	cert, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return cert, key, nil, nil
+}
+
+// reloadClientCertificate returns a GetClientCertificate callback of a tls.Config
+// which reads the client certificate and key from the files for each handshake,
+// so certificates which are rotated on disk are used for the next connection.
+func reloadClientCertificate(certFile, keyFile string) func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to reload the client certificate: %w", err)
+		}
+		return &cert, nil
+	}
+}
+
 // defaultTransport returns a new http.Transport with similar default values to
 // http.DefaultTransport, but with idle connections and keepalive disabled.
 func defaultTransport() *http.Transport {
@@ -149,6 +281,10 @@ func defaultPooledTransport() *http.Transport {
 
 // NewClient returns a new Docker client.
 func (c *Config) NewClient() (*client.Client, error) {
+	if isNamedPipeHost(c.Host) {
+		return newNamedPipeClient(c.Host, client.WithAPIVersionNegotiation(), client.WithHTTPHeaders(c.HTTPHeaders))
+	}
+
 	if c.Cert != "" || c.Key != "" {
 		if c.Cert == "" || c.Key == "" {
 			return nil, fmt.Errorf("cert_material, and key_material must be specified")
@@ -169,6 +305,7 @@ func (c *Config) NewClient() (*client.Client, error) {
 			client.WithHTTPClient(httpClient),
 			client.WithHost(c.Host),
 			client.WithAPIVersionNegotiation(),
+			client.WithHTTPHeaders(c.HTTPHeaders),
 		)
 	}
 
@@ -181,6 +318,7 @@ func (c *Config) NewClient() (*client.Client, error) {
 			client.WithHost(c.Host),
 			client.WithTLSClientConfig(ca, cert, key),
 			client.WithAPIVersionNegotiation(),
+			client.WithHTTPHeaders(c.HTTPHeaders),
 		)
 	}
 
@@ -194,6 +332,7 @@ func (c *Config) NewClient() (*client.Client, error) {
 			client.WithHost(helper.Host),
 			client.WithDialContext(helper.Dialer),
 			client.WithAPIVersionNegotiation(),
+			client.WithHTTPHeaders(c.HTTPHeaders),
 		)
 	}
 
@@ -201,6 +340,7 @@ func (c *Config) NewClient() (*client.Client, error) {
 	return client.NewClientWithOpts(
 		client.WithHost(c.Host),
 		client.WithAPIVersionNegotiation(),
+		client.WithHTTPHeaders(c.HTTPHeaders),
 	)
 }
 
@@ -215,55 +355,345 @@ type ProviderConfig struct {
 	// DockerClient *client.Client
 	// Remove
 	DefaultConfig *Config
-	Hosts         map[string]*schema.ResourceData
-	AuthConfigs   *AuthConfigs
-	clientCache   sync.Map
+	// Hosts are the configs of the hosts of the provider by name, which can be used
+	// as host of an override block instead of the URL of the Docker host.
+	Hosts       map[string]*Config
+	AuthConfigs *AuthConfigs
+	clientCache sync.Map
+	cacheStats  clientCacheStats
+	// hostLimiter limits the operations per Docker host, see max_concurrent_requests
+	hostLimiter *hostLimiter
+	// reportedWarnings are the warnings of the Docker hosts which were already reported
+	reportedWarnings sync.Map
+	// DefaultVolumeDriver is the driver of the volumes which don't set one
+	DefaultVolumeDriver string
+	// DefaultLabels are added to the labels of the resources, see default_labels
+	DefaultLabels map[string]string
+	// APIVersion is the pinned API version of the clients, empty to negotiate it
+	APIVersion string
+	// SkipPing makes the clients skip the ping of the Docker host, see skip_ping
+	SkipPing bool
+	// APIRetries is how often transient errors of Docker API calls are retried,
+	// starting with APIRetryBackoff between the attempts, see withRetry
+	APIRetries      int
+	APIRetryBackoff time.Duration
+	// SSHConnectTimeout is how long to wait for the ssh connection to a Docker host
+	SSHConnectTimeout time.Duration
+	// ReloadCerts makes the clients read the client certificate of the cert_path
+	// again for each connection, see reload_certs
+	ReloadCerts bool
+	// CAAppendSystem makes the clients trust the system trust store besides the CA,
+	// see ca_append_system
+	CAAppendSystem bool
+	// SSHBinary and SSHEnv are the ssh executable and its additional environment
+	SSHBinary string
+	SSHEnv    map[string]string
+	// RegistryMirrors are the mirrors of Docker Hub, see registry_mirrors
+	RegistryMirrors     []string
+	MirrorHealthTimeout time.Duration
+	// healthyMirror is the mirror which was selected for the run, empty if none is healthy
+	healthyMirror     string
+	healthyMirrorOnce sync.Once
 }
 
-func (c *ProviderConfig) getConfig(d *schema.ResourceData) *Config {
-	config := *c.DefaultConfig
-	copy(config.SSHOpts, c.DefaultConfig.SSHOpts)
+// apiRetryMaxBackoff caps the backoff between the retries of the Docker API calls.
+const apiRetryMaxBackoff = 30 * time.Second
 
-	if d != nil {
-		resourceConfig := NewConfig(d)
-		if resourceConfig.Host != "" {
-			config.Host = resourceConfig.Host
+// withRetry calls f and retries it with an exponential backoff if it fails with a
+// transient error, see api_retries. Definitive errors, e.g. of a missing volume,
+// are returned right away.
+func (c *ProviderConfig) withRetry(ctx context.Context, operation string, f func() error) error {
+	backoff := c.APIRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || attempt > c.APIRetries || !isTransientDockerError(err) {
+			return err
 		}
-		if len(resourceConfig.SSHOpts) != 0 {
-			copy(config.SSHOpts, resourceConfig.SSHOpts)
+		tflog.Warn(ctx, "Docker API call failed with a transient error, retrying", map[string]interface{}{
+			"operation": operation,
+			"attempt":   attempt,
+			"backoff":   backoff.String(),
+			"error":     err.Error(),
+		})
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
 		}
-		if resourceConfig.Ca != "" {
-			config.Ca = resourceConfig.Ca
+		if backoff *= 2; backoff > apiRetryMaxBackoff {
+			backoff = apiRetryMaxBackoff
 		}
-		if resourceConfig.Cert != "" {
-			config.Cert = resourceConfig.Cert
+	}
+}
+
+// isTransientDockerError returns true if the error of a Docker API call is worth
+// a retry, e.g. a dropped connection or an internal error of the daemon.
+func isTransientDockerError(err error) bool {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errdefs.IsNotFound(err), errdefs.IsConflict(err), errdefs.IsInvalidParameter(err),
+		errdefs.IsUnauthorized(err), errdefs.IsForbidden(err), errdefs.IsNotImplemented(err):
+		return false
+	case errdefs.IsSystem(err), errdefs.IsUnavailable(err):
+		return true
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return true
+	}
+	// errors of the ssh connection helper only carry the message
+	return containsIgnorableErrorMessage(err.Error(), "connection reset by peer", "broken pipe", ": EOF")
+}
+
+// daemonWarnings returns the warnings in a response of the Docker host of the
+// resource, e.g. about deprecated settings, as warning diagnostics. Each warning
+// of a host is only reported once per run, so it is not repeated for every resource.
+// The warnings count as reported once they are returned, so callers must return the
+// diagnostics on every path afterwards, also together with errors.
+func (c *ProviderConfig) daemonWarnings(ctx context.Context, d *schema.ResourceData, operation string, warnings []string) diag.Diagnostics {
+	host := c.getConfig(d).Host
+	var diags diag.Diagnostics
+	for _, warning := range warnings {
+		warning = strings.TrimSpace(warning)
+		if warning == "" {
+			continue
 		}
-		if resourceConfig.Key != "" {
-			config.Key = resourceConfig.Key
+		tflog.Warn(ctx, "Docker host returned a warning", map[string]interface{}{
+			logFieldHost: host,
+			"operation":  operation,
+			"warning":    warning,
+		})
+		if _, reported := c.reportedWarnings.LoadOrStore(host+"|"+warning, true); reported {
+			continue
 		}
-		if resourceConfig.CertPath != "" {
-			config.CertPath = resourceConfig.CertPath
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("Docker host warned when %s", operation),
+			Detail:   warning,
+		})
+	}
+	return diags
+}
+
+// hostLimiter limits the number of resource operations which are run against
+// each Docker host at the same time. A limit of 0 means no limit.
+type hostLimiter struct {
+	limit      int
+	mu         sync.Mutex
+	semaphores map[string]chan struct{}
+}
+
+func newHostLimiter(limit int) *hostLimiter {
+	return &hostLimiter{
+		limit:      limit,
+		semaphores: map[string]chan struct{}{},
+	}
+}
+
+// acquire waits until an operation can be run against the host. The returned
+// func must be called once the operation is done.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	if l == nil || l.limit <= 0 {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	semaphore, ok := l.semaphores[host]
+	if !ok {
+		semaphore = make(chan struct{}, l.limit)
+		l.semaphores[host] = semaphore
+	}
+	l.mu.Unlock()
+
+	select {
+	case semaphore <- struct{}{}:
+		return func() { <-semaphore }, nil
+	default:
+	}
+
+	tflog.Debug(ctx, "Waiting for other operations on the Docker host to finish", map[string]interface{}{
+		logFieldHost: host,
+		"limit":      l.limit,
+	})
+	select {
+	case semaphore <- struct{}{}:
+		return func() { <-semaphore }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out waiting for one of the %d concurrent operations on Docker host %s to finish: %w", l.limit, host, ctx.Err())
+	}
+}
+
+// clientCacheStats counts the lookups in the clientCache, see the docker_client_cache data source.
+type clientCacheStats struct {
+	hits       atomic.Int64
+	misses     atomic.Int64
+	collisions atomic.Int64
+}
+
+// cachedClient is a client in the clientCache together with the config it was created from
+type cachedClient struct {
+	config Config
+	client *client.Client
+}
+
+// loadCachedClient returns the cached client for the config. A cached client is only
+// reused if its config is equal to the given one, so a hash collision never
+// routes to the wrong daemon.
+func (c *ProviderConfig) loadCachedClient(ctx context.Context, config *Config, configHash uint64) (*client.Client, bool) {
+	cached, found := c.clientCache.Load(configHash)
+	if !found {
+		c.cacheStats.misses.Add(1)
+		return nil, false
+	}
+
+	entry := cached.(*cachedClient)
+	if !entry.config.Equal(config) {
+		c.cacheStats.misses.Add(1)
+		c.cacheStats.collisions.Add(1)
+		tflog.Warn(ctx, "Cached client was created for a different config with the same hash, creating a new client", map[string]interface{}{
+			"hash":        configHash,
+			"cached_host": entry.config.Host,
+			logFieldHost:  config.Host,
+		})
+		return nil, false
+	}
+	c.cacheStats.hits.Add(1)
+	return entry.client, true
+}
+
+// cachedClientsPerHost returns the number of cached clients for each Docker host. If
+// suffix is not empty, only the clients with this cache_key_suffix are counted.
+func (c *ProviderConfig) cachedClientsPerHost(suffix string) map[string]int {
+	clients := map[string]int{}
+	c.clientCache.Range(func(_, value interface{}) bool {
+		config := value.(*cachedClient).config
+		if suffix == "" || config.CacheKeySuffix == suffix {
+			clients[config.Host]++
 		}
+		return true
+	})
+	return clients
+}
+
+// limiterKey returns the key of the concurrency limit of the config, which is shared
+// by the configs of the same Docker host unless they have a cache_key_suffix.
+func (c *Config) limiterKey() string {
+	if c.CacheKeySuffix == "" {
+		return c.Host
+	}
+	return c.Host + "#" + c.CacheKeySuffix
+}
+
+func (c *ProviderConfig) getConfig(d *schema.ResourceData) *Config {
+	if d == nil {
+		return c.overrideConfig(nil)
+	}
+	return c.overrideConfig(NewConfig(d))
+}
+
+// overrideConfig returns the default config of the provider with the values
+// which are set in the given config of an override block.
+func (c *ProviderConfig) overrideConfig(resourceConfig *Config) *Config {
+	config := *c.DefaultConfig
+	config.SSHOpts = make([]string, len(c.DefaultConfig.SSHOpts))
+	copy(config.SSHOpts, c.DefaultConfig.SSHOpts)
+
+	if resourceConfig != nil {
+		if hostConfig, ok := c.Hosts[resourceConfig.Host]; ok {
+			applyConfig(&config, hostConfig)
+			// the values of the override block are still applied on top of the host
+			aliasConfig := *resourceConfig
+			aliasConfig.Host = ""
+			resourceConfig = &aliasConfig
+		}
+		applyConfig(&config, resourceConfig)
 	}
 	return &config
 }
 
+// applyConfig overwrites the values of the config with the values which are set
+// in the override config.
+func applyConfig(config *Config, override *Config) {
+	if override.Host != "" {
+		config.Host = override.Host
+	}
+	if len(override.SSHOpts) != 0 {
+		config.SSHOpts = make([]string, len(override.SSHOpts))
+		copy(config.SSHOpts, override.SSHOpts)
+	}
+	if override.Ca != "" {
+		config.Ca = override.Ca
+	}
+	if override.Cert != "" {
+		config.Cert = override.Cert
+	}
+	if override.Key != "" {
+		config.Key = override.Key
+	}
+	if override.CertPath != "" {
+		config.CertPath = override.CertPath
+	}
+	if override.Insecure {
+		config.Insecure = true
+	}
+	if override.APIVersion != "" {
+		config.APIVersion = override.APIVersion
+	}
+	if override.CacheKeySuffix != "" {
+		config.CacheKeySuffix = override.CacheKeySuffix
+	}
+	if len(override.HTTPHeaders) != 0 {
+		// the headers are merged, so e.g. a host can add a header to the ones of the provider
+		headers := make(map[string]string, len(config.HTTPHeaders)+len(override.HTTPHeaders))
+		for name, value := range config.HTTPHeaders {
+			headers[name] = value
+		}
+		for name, value := range override.HTTPHeaders {
+			headers[name] = value
+		}
+		config.HTTPHeaders = headers
+	}
+}
+
 func (c *ProviderConfig) MakeClient(
 	ctx context.Context, d *schema.ResourceData) (*client.Client, error) {
+	return c.MakeClientForConfig(ctx, c.getConfig(d))
+}
+
+// MakeClientForConfig returns the client for the config, e.g. of an override
+// block which is not the override attribute of the resource. Clients are cached
+// like with MakeClient.
+func (c *ProviderConfig) MakeClientForConfig(ctx context.Context, config *Config) (*client.Client, error) {
 	var dockerClient *client.Client
 	var err error
 
-	config := c.getConfig(d)
+	if err := c.validateClientHost(config); err != nil {
+		return nil, err
+	}
+
 	configHash := config.Hash()
 
-	cached, found := c.clientCache.Load(configHash)
+	cached, found := c.loadCachedClient(ctx, config, configHash)
+
+	apiVersion, apiVersionSetting := c.APIVersion, "the api_version of the provider"
+	if config.APIVersion != "" {
+		apiVersion, apiVersionSetting = config.APIVersion, "the api_version of the host"
+	}
+	if apiVersion == "" && c.SkipPing {
+		// the negotiation pings the host and falls back to the oldest API version if it fails
+		apiVersion = api.DefaultVersion
+	}
 
 	if found {
-		log.Printf("[DEBUG] Found cached client! Hash:%d Host:%s", configHash, config.Host)
+		tflog.Debug(ctx, "Found cached client", map[string]interface{}{
+			"hash":       configHash,
+			logFieldHost: config.Host,
+		})
 
-		return cached.(*client.Client), nil
+		return cached, nil
 	}
-	if config.Cert != "" || config.Key != "" {
+	if isNamedPipeHost(config.Host) {
+		dockerClient, err = newNamedPipeClient(config.Host, withAPIVersion(apiVersion), client.WithHTTPHeaders(config.HTTPHeaders))
+	} else if config.Cert != "" || config.Key != "" {
 		if config.Cert == "" || config.Key == "" {
 			return nil, fmt.Errorf("cert_material, and key_material must be specified")
 		}
@@ -272,7 +702,12 @@ func (c *ProviderConfig) MakeClient(
 			return nil, fmt.Errorf("cert_path must not be specified")
 		}
 
-		httpClient, err := buildHTTPClientFromBytes([]byte(config.Ca), []byte(config.Cert), []byte(config.Key))
+		ca := []byte(config.Ca)
+		if config.Insecure {
+			// without a CA the certificate of the host is not verified
+			ca = nil
+		}
+		httpClient, err := buildHTTPClient(ca, []byte(config.Cert), []byte(config.Key), tlsClientOptions{appendSystemCAs: c.CAAppendSystem})
 		if err != nil {
 			return nil, err
 		}
@@ -282,7 +717,52 @@ func (c *ProviderConfig) MakeClient(
 		dockerClient, _ = client.NewClientWithOpts(
 			client.WithHTTPClient(httpClient),
 			client.WithHost(config.Host),
-			client.WithAPIVersionNegotiation(),
+			withAPIVersion(apiVersion),
+			client.WithHTTPHeaders(config.HTTPHeaders),
+		)
+	} else if config.Insecure && !strings.HasPrefix(config.Host, "ssh://") {
+		// Still present the client certificate of the cert_path, but skip the verification of the host
+		var cert, key []byte
+		options := tlsClientOptions{}
+		if config.CertPath != "" {
+			cert, key, options.getClientCertificate, err = c.certPathClientCertificate(config.CertPath)
+			if err != nil {
+				return nil, err
+			}
+		}
+		var httpClient *http.Client
+		httpClient, err = buildHTTPClient(nil, cert, key, options)
+		if err != nil {
+			return nil, err
+		}
+		dockerClient, err = client.NewClientWithOpts(
+			client.WithHTTPClient(httpClient),
+			client.WithHost(config.Host),
+			withAPIVersion(apiVersion),
+			client.WithHTTPHeaders(config.HTTPHeaders),
+		)
+	} else if config.CertPath != "" && (c.ReloadCerts || c.CAAppendSystem) {
+		// The TLS config of the cert_path is built by the provider, see reload_certs and ca_append_system
+		var ca, cert, key []byte
+		ca, err = os.ReadFile(filepath.Join(config.CertPath, "ca.pem"))
+		if err != nil {
+			return nil, err
+		}
+		options := tlsClientOptions{appendSystemCAs: c.CAAppendSystem}
+		cert, key, options.getClientCertificate, err = c.certPathClientCertificate(config.CertPath)
+		if err != nil {
+			return nil, err
+		}
+		var httpClient *http.Client
+		httpClient, err = buildHTTPClient(ca, cert, key, options)
+		if err != nil {
+			return nil, err
+		}
+		dockerClient, err = client.NewClientWithOpts(
+			client.WithHTTPClient(httpClient),
+			client.WithHost(config.Host),
+			withAPIVersion(apiVersion),
+			client.WithHTTPHeaders(config.HTTPHeaders),
 		)
 	} else if config.CertPath != "" {
 		// If there is cert information, load it and use it.
@@ -292,52 +772,345 @@ func (c *ProviderConfig) MakeClient(
 		dockerClient, err = client.NewClientWithOpts(
 			client.WithHost(config.Host),
 			client.WithTLSClientConfig(ca, cert, key),
-			client.WithAPIVersionNegotiation(),
+			withAPIVersion(apiVersion),
+			client.WithHTTPHeaders(config.HTTPHeaders),
 		)
 	} else if strings.HasPrefix(config.Host, "ssh://") {
 		// If there is no cert information, then check for ssh://
-		helper, err := connhelper.GetConnectionHelper(config.Host)
+		helper, err := newSSHConnectionHelper(config.Host, config.SSHOpts, c.SSHBinary, c.SSHEnv)
 		if err != nil {
 			return nil, err
 		}
 		if helper != nil {
+			if err := probeSSHConnection(ctx, config.Host, helper.Dialer, c.SSHConnectTimeout); err != nil {
+				return nil, err
+			}
 			dockerClient, _ = client.NewClientWithOpts(
 				client.WithHost(helper.Host),
 				client.WithDialContext(helper.Dialer),
-				client.WithAPIVersionNegotiation(),
+				withAPIVersion(apiVersion),
+				client.WithHTTPHeaders(config.HTTPHeaders),
 			)
 		}
 	} else {
 		// If there is no ssh://, then just return the direct client
 		dockerClient, err = client.NewClientWithOpts(
 			client.WithHost(config.Host),
-			client.WithAPIVersionNegotiation(),
+			withAPIVersion(apiVersion),
+			client.WithHTTPHeaders(config.HTTPHeaders),
 		)
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	c.clientCache.LoadOrStore(configHash, dockerClient)
+	c.clientCache.LoadOrStore(configHash, &cachedClient{config: *config, client: dockerClient})
 
-	_, err = dockerClient.Ping(ctx)
+	if c.SkipPing {
+		tflog.Warn(ctx, "Skipping the ping of the Docker host, errors of the connection are reported by the first request", map[string]interface{}{
+			logFieldHost: config.Host,
+			"version":    apiVersion,
+		})
+		return dockerClient, nil
+	}
+
+	ping, err := dockerClient.Ping(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error pinging Docker server: %s", err)
 	}
+	if err := checkAPIVersion(config.Host, apiVersion, apiVersionSetting, ping.APIVersion); err != nil {
+		// the client can't be used for any request, so the next resource checks it again
+		c.clientCache.Delete(configHash)
+		return nil, err
+	}
 
-	log.Printf("[DEBUG] New client with Hash:%d Host:%s", configHash, config.Host)
+	tflog.Debug(ctx, "New client", map[string]interface{}{
+		"hash":       configHash,
+		logFieldHost: config.Host,
+	})
 	return dockerClient, nil
 }
 
-// The registry address can be referenced in various places (registry auth, docker config file, image name)
-// with or without the http(s):// prefix; this function is used to standardize the inputs
-// To support insecure (http) registries, if the address explicitly states "http://" we do not change it.
-func normalizeRegistryAddress(address string) string {
+// validateClientHost returns an error if no client can be created for the host of
+// the config, e.g. for the mistyped name of one of the hosts of the provider.
+func (c *ProviderConfig) validateClientHost(config *Config) error {
+	if !strings.Contains(config.Host, "://") {
+		// all Docker hosts are URLs, so this can only be a mistyped name of a host
+		hostNames := make([]string, 0, len(c.Hosts))
+		for name := range c.Hosts {
+			hostNames = append(hostNames, name)
+		}
+		sort.Strings(hostNames)
+		return fmt.Errorf("unknown host %q, it must be a URL like tcp://docker.example.com:2376 or one of the hosts of the provider: %s", config.Host, strings.Join(hostNames, ", "))
+	}
+	return validateHostScheme(config.Host)
+}
+
+// newSSHConnectionHelper returns the connection helper for an ssh:// host. Like the
+// one of the docker cli, it runs 'docker system dial-stdio' on the host through ssh,
+// but with the ssh executable and environment of ssh_binary and ssh_env.
+func newSSHConnectionHelper(host string, sshOpts []string, binary string, env map[string]string) (*connhelper.ConnectionHelper, error) {
+	if binary == "" && len(env) == 0 {
+		return connhelper.GetConnectionHelperWithSSHOpts(host, sshOpts)
+	}
+	sp, err := ssh.ParseURL(host)
+	if err != nil {
+		return nil, fmt.Errorf("ssh host connection is not valid: %w", err)
+	}
+	if binary == "" {
+		binary = "ssh"
+	}
+	args := append(append([]string{}, sshOpts...), sp.Args("docker", "system", "dial-stdio")...)
+	command, args := sshCommand(binary, env, args)
+	return connhelper.GetCommandConnectionHelper(command, args...)
+}
+
+// sshCommand returns the command which runs ssh with the given environment. The
+// connection helper always runs its command with the environment of the provider,
+// so the additional variables are set through env.
+func sshCommand(binary string, env map[string]string, args []string) (string, []string) {
+	if len(env) == 0 {
+		return binary, args
+	}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	envArgs := make([]string, 0, len(env)+1+len(args))
+	for _, k := range keys {
+		envArgs = append(envArgs, k+"="+env[k])
+	}
+	return "env", append(append(envArgs, binary), args...)
+}
+
+// sshPingRequest is sent through the ssh connection to check that the Docker
+// daemon on the other side responds.
+const sshPingRequest = "HEAD /_ping HTTP/1.1\r\nHost: docker\r\n\r\n"
+
+// probeSSHConnection checks that the Docker host can be reached through ssh before
+// the client is created. Otherwise failures of ssh, e.g. a rejected key, only show
+// up as a failed ping with the whole ssh command in the error.
+func probeSSHConnection(ctx context.Context, host string, dialer func(ctx context.Context, network, addr string) (net.Conn, error), timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	conn, err := dialer(ctx, "tcp", "")
+	if err != nil {
+		return sshConnectionError(host, err)
+	}
+	defer conn.Close()
+
+	// the ssh command is only started by the dial, its errors show up when reading
+	result := make(chan error, 1)
+	go func() {
+		if _, err := conn.Write([]byte(sshPingRequest)); err != nil {
+			result <- err
+			return
+		}
+		_, err := conn.Read(make([]byte, 1))
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		if err != nil {
+			return sshConnectionError(host, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("cannot establish SSH connection to host %s within %s, see ssh_connect_timeout", host, timeout)
+	}
+}
+
+// sshConnectionError returns the error of an ssh connection with the last line
+// ssh wrote to stderr, e.g. 'Permission denied (publickey).', as reason.
+func sshConnectionError(host string, err error) error {
+	reason := err.Error()
+	if _, stderr, found := strings.Cut(reason, "stderr="); found {
+		lines := strings.Split(strings.TrimSpace(stderr), "\n")
+		if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+			reason = last
+		}
+	}
+	return fmt.Errorf("cannot establish SSH connection to host %s: %s", host, reason)
+}
+
+// withAPIVersion pins the API version of the client to the api_version of the
+// provider, or negotiates it with the Docker host if it is not set.
+func withAPIVersion(version string) client.Opt {
+	if version == "" {
+		return client.WithAPIVersionNegotiation()
+	}
+	return client.WithVersion(version)
+}
+
+// checkAPIVersion returns an error if the pinned API version of the client is newer
+// than the maximum API version of the Docker host, as all requests would fail with
+// 'client version is too new' then. Negotiated versions are never too new. The
+// setting names the api_version the client version comes from.
+func checkAPIVersion(host, clientVersion, setting, daemonVersion string) error {
+	if clientVersion == "" || daemonVersion == "" || !versions.GreaterThan(clientVersion, daemonVersion) {
+		return nil
+	}
+	return fmt.Errorf("the Docker API version %s is too new for the Docker host %s, which supports API versions up to %s. Set %s to %s or lower, or remove it to negotiate the API version with the Docker host", clientVersion, host, daemonVersion, setting, daemonVersion)
+}
+
+// supportedHostSchemes are the schemes of the Docker hosts the client can connect to.
+var supportedHostSchemes = []string{"tcp", "unix", "ssh", "npipe", "http", "https"}
+
+// validateHostScheme returns an error if the client can't connect to the Docker
+// host because of its scheme, e.g. for a typo like tpc://, which otherwise only
+// fails with an obscure error of the first request.
+func validateHostScheme(host string) error {
+	scheme, _, _ := strings.Cut(host, "://")
+	for _, supported := range supportedHostSchemes {
+		if scheme == supported {
+			return nil
+		}
+	}
+	schemes := make([]string, len(supportedHostSchemes))
+	for i, supported := range supportedHostSchemes {
+		schemes[i] = supported + "://"
+	}
+	return fmt.Errorf("the Docker host %q has the unsupported scheme %s://, it must start with one of %s", host, scheme, strings.Join(schemes, ", "))
+}
+
+// isNamedPipeHost returns true if the host is a Windows named pipe, e.g. npipe:////./pipe/docker_engine
+func isNamedPipeHost(host string) bool {
+	return strings.HasPrefix(host, "npipe://")
+}
+
+// newNamedPipeClient returns a client for a Windows named pipe host with the given
+// options, e.g. the API version. TLS and ssh settings don't apply to named pipes,
+// the dialer for the pipe is set up by the docker client itself.
+func newNamedPipeClient(host string, opts ...client.Opt) (*client.Client, error) {
+	if runtime.GOOS != "windows" {
+		return nil, fmt.Errorf("named pipe host '%s' is only supported on Windows", host)
+	}
+	return client.NewClientWithOpts(append([]client.Opt{client.WithHost(host)}, opts...)...)
+}
+
+// appendSSHHostKeyOpts appends the ssh flags for the given host key verification
+// settings to sshOpts. Settings which contradict each other or options already
+// given in sshOpts are rejected.
+func appendSSHHostKeyOpts(sshOpts []string, knownHostsFile, strictHostKeyChecking string) ([]string, error) {
+	if knownHostsFile != "" && strictHostKeyChecking == "no" {
+		return nil, fmt.Errorf("ssh_known_hosts_file cannot be used when ssh_strict_host_key_checking is 'no', as host keys are not verified then")
+	}
+
+	for _, opt := range sshOpts {
+		// options are given either as "-o", "Key=value" or as "-oKey=value"
+		opt = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(opt, "-o")))
+		if knownHostsFile != "" && strings.HasPrefix(opt, "userknownhostsfile") {
+			return nil, fmt.Errorf("ssh_known_hosts_file cannot be used when ssh_opts already sets UserKnownHostsFile")
+		}
+		if strictHostKeyChecking != "" && strings.HasPrefix(opt, "stricthostkeychecking") {
+			return nil, fmt.Errorf("ssh_strict_host_key_checking cannot be used when ssh_opts already sets StrictHostKeyChecking")
+		}
+	}
+
+	if knownHostsFile != "" {
+		sshOpts = append(sshOpts, "-o", "UserKnownHostsFile="+knownHostsFile)
+	}
+	if strictHostKeyChecking != "" {
+		sshOpts = append(sshOpts, "-o", "StrictHostKeyChecking="+strictHostKeyChecking)
+	}
+	return sshOpts, nil
+}
+
+// dockerContextMeta is the meta.json of a context in the contexts store of the docker
+// CLI, e.g. ~/.docker/contexts/meta/<sha256 of the name>/meta.json.
+type dockerContextMeta struct {
+	Name      string `json:"Name"`
+	Endpoints map[string]struct {
+		Host          string `json:"Host"`
+		SkipTLSVerify bool   `json:"SkipTLSVerify"`
+	} `json:"Endpoints"`
+}
+
+// dockerConfigDir returns the config directory of the docker CLI, which holds the
+// contexts store. Like the docker CLI it is DOCKER_CONFIG or ~/.docker, and as the
+// provider also accepts DOCKER_CONFIG as the path of the config.json, the directory
+// of the file is used then.
+func dockerConfigDir() (string, error) {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+			return filepath.Dir(dir), nil
+		}
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".docker"), nil
+}
+
+// applyDockerContext sets the host and TLS settings of the config to the docker
+// endpoint of the named context of the contexts store in configDir, like
+// 'docker --context <name>' does. The TLS files of a context are stored as ca.pem,
+// cert.pem and key.pem, so they are used as the cert_path.
+func applyDockerContext(config *Config, configDir, name string) error {
+	contextID := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))
+	metaFile := filepath.Join(configDir, "contexts", "meta", contextID, "meta.json")
+	content, err := os.ReadFile(metaFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("the context %q does not exist in %s", name, filepath.Join(configDir, "contexts"))
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read the context %q: %w", name, err)
+	}
+
+	meta := dockerContextMeta{}
+	if err := json.Unmarshal(content, &meta); err != nil {
+		return fmt.Errorf("unable to parse %s: %w", metaFile, err)
+	}
+	endpoint, ok := meta.Endpoints["docker"]
+	if !ok || endpoint.Host == "" {
+		return fmt.Errorf("the context %q has no docker endpoint", name)
+	}
+
+	config.Host = endpoint.Host
+	config.Insecure = endpoint.SkipTLSVerify
+	config.CertPath = ""
+	tlsDir := filepath.Join(configDir, "contexts", "tls", contextID, "docker")
+	if info, err := os.Stat(tlsDir); err == nil && info.IsDir() {
+		config.CertPath = tlsDir
+	}
+	return nil
+}
+
+// NormalizeRegistryAddress standardizes a registry address, which can be referenced in
+// various places (registry auth, docker config file, image name) with or without the
+// http(s):// prefix. The address gets the https:// prefix, its host is lowercased with
+// the port kept and trailing slashes are stripped. To support insecure (http)
+// registries, an explicit "http://" is kept.
+func NormalizeRegistryAddress(address string) string {
+	address = strings.TrimSpace(address)
+	scheme := "https://"
+	// DevSkim: ignore DS137138
 	if strings.HasPrefix(address, "http://") {
-		return address
+		// DevSkim: ignore DS137138
+		scheme = "http://"
 	}
-	if !strings.HasPrefix(address, "https://") && !strings.HasPrefix(address, "http://") {
-		return "https://" + address
+	// DevSkim: ignore DS137138
+	address = strings.TrimPrefix(strings.TrimPrefix(address, "http://"), "https://")
+
+	host, path, _ := strings.Cut(address, "/")
+	normalized := scheme + strings.ToLower(host)
+	if path = strings.TrimRight(path, "/"); path != "" {
+		normalized += "/" + path
 	}
-	return address
+	return normalized
+}
+
+// registryAuthKey returns the key of the auth config of a registry in the AuthConfigs,
+// which is the normalized host with port, e.g. registry.example.com:5000.
+func registryAuthKey(address string) string {
+	return convertToHostname(NormalizeRegistryAddress(address))
 }
@@ -1,14 +1,43 @@
 package provider
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func TestNormalizeRegistryAddress(t *testing.T) {
 	t.Run("Should return same address if http:// is used", func(t *testing.T) {
 		address := "http://registry.com"
 		expected := "http://registry.com"
-		actual := normalizeRegistryAddress(address)
+		actual := NormalizeRegistryAddress(address)
 		if actual != expected {
 			t.Fatalf("Expected %s, got %s", expected, actual)
 		}
@@ -16,7 +45,7 @@ func TestNormalizeRegistryAddress(t *testing.T) {
 	t.Run("Should return https address if no protocol is specified", func(t *testing.T) {
 		address := "registry.com"
 		expected := "https://registry.com"
-		actual := normalizeRegistryAddress(address)
+		actual := NormalizeRegistryAddress(address)
 		if actual != expected {
 			t.Fatalf("Expected %s, got %s", expected, actual)
 		}
@@ -24,9 +53,886 @@ func TestNormalizeRegistryAddress(t *testing.T) {
 	t.Run("Should return https address if https protocol is specified", func(t *testing.T) {
 		address := "https://registry.com"
 		expected := "https://registry.com"
-		actual := normalizeRegistryAddress(address)
+		actual := NormalizeRegistryAddress(address)
 		if actual != expected {
 			t.Fatalf("Expected %s, got %s", expected, actual)
 		}
 	})
 }
+
+func TestNormalizeRegistryAddressTable(t *testing.T) {
+	for _, tc := range []struct {
+		address    string
+		normalized string
+		authKey    string
+	}{
+		{address: "registry.com", normalized: "https://registry.com", authKey: "registry.com"},
+		{address: "registry:5000", normalized: "https://registry:5000", authKey: "registry:5000"},
+		{address: "http://registry:5000/", normalized: "http://registry:5000", authKey: "registry:5000"},
+		{address: "https://Registry.Example.com:5000//", normalized: "https://registry.example.com:5000", authKey: "registry.example.com:5000"},
+		{address: " registry.com ", normalized: "https://registry.com", authKey: "registry.com"},
+		{address: "https://index.docker.io/v1/", normalized: "https://index.docker.io/v1", authKey: "index.docker.io"},
+		{address: "127.0.0.1:15000", normalized: "https://127.0.0.1:15000", authKey: "127.0.0.1:15000"},
+	} {
+		if normalized := NormalizeRegistryAddress(tc.address); normalized != tc.normalized {
+			t.Errorf("Expected %q to be normalized to %q, got %q", tc.address, tc.normalized, normalized)
+		}
+		if authKey := registryAuthKey(tc.address); authKey != tc.authKey {
+			t.Errorf("Expected the auth key of %q to be %q, got %q", tc.address, tc.authKey, authKey)
+		}
+	}
+}
+
+func TestAuthConfigsGetWithUnnormalizedRegistry(t *testing.T) {
+	registryAuthSchema := New("dev")().Schema["registry_auth"].Elem.(*schema.Resource)
+	authList := schema.NewSet(schema.HashResource(registryAuthSchema), []interface{}{map[string]interface{}{
+		"address":             "https://Registry.Example.com:5000/",
+		"username":            "user",
+		"password":            "secret",
+		"config_file":         "",
+		"config_file_content": "",
+		"auth_disabled":       false,
+	}})
+	authConfigs, err := providerSetToRegistryAuth(authList)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	for _, registry := range []string{"registry.example.com:5000", "Registry.Example.com:5000", "https://registry.example.com:5000/"} {
+		if authConfig, ok := authConfigs.Get(registry); !ok || authConfig.Username != "user" {
+			t.Errorf("Expected the auth config to be found for %q, got %#v", registry, authConfig)
+		}
+	}
+	if _, ok := authConfigs.Get("registry.example.com"); ok {
+		t.Error("Expected no auth config for the registry without port")
+	}
+}
+
+func TestAppendSSHHostKeyOpts(t *testing.T) {
+	t.Run("Should append the flags of the host key settings", func(t *testing.T) {
+		expected := []string{"-i", "key", "-o", "UserKnownHostsFile=/ci/known_hosts", "-o", "StrictHostKeyChecking=yes"}
+		actual, err := appendSSHHostKeyOpts([]string{"-i", "key"}, "/ci/known_hosts", "yes")
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("Expected %v, got %v", expected, actual)
+		}
+	})
+	t.Run("Should keep ssh_opts if no host key settings are given", func(t *testing.T) {
+		expected := []string{"-o", "StrictHostKeyChecking=no"}
+		actual, err := appendSSHHostKeyOpts([]string{"-o", "StrictHostKeyChecking=no"}, "", "")
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("Expected %v, got %v", expected, actual)
+		}
+	})
+	t.Run("Should fail if a known_hosts file is used without strict checking", func(t *testing.T) {
+		if _, err := appendSSHHostKeyOpts(nil, "/ci/known_hosts", "no"); err == nil {
+			t.Fatal("Expected an error")
+		}
+	})
+	t.Run("Should fail if ssh_opts already sets the option", func(t *testing.T) {
+		if _, err := appendSSHHostKeyOpts([]string{"-o", "StrictHostKeyChecking=no"}, "", "accept-new"); err == nil {
+			t.Fatal("Expected an error")
+		}
+		if _, err := appendSSHHostKeyOpts([]string{"-oUserKnownHostsFile=/dev/null"}, "/ci/known_hosts", ""); err == nil {
+			t.Fatal("Expected an error")
+		}
+	})
+}
+
+func TestConfigEqual(t *testing.T) {
+	config := &Config{Host: "ssh://user@remote-host:22", SSHOpts: []string{"-o", "Port=22"}, CertPath: "/certs"}
+
+	if !config.Equal(&Config{Host: "ssh://user@remote-host:22", SSHOpts: []string{"-o", "Port=22"}, CertPath: "/certs"}) {
+		t.Fatal("Expected configs with the same values to be equal")
+	}
+	for _, other := range []*Config{
+		nil,
+		{Host: "ssh://user@other-host:22", SSHOpts: []string{"-o", "Port=22"}, CertPath: "/certs"},
+		{Host: "ssh://user@remote-host:22", SSHOpts: []string{"-o", "Port=2222"}, CertPath: "/certs"},
+		{Host: "ssh://user@remote-host:22", CertPath: "/certs"},
+		{Host: "ssh://user@remote-host:22", SSHOpts: []string{"-o", "Port=22"}},
+		{Host: "ssh://user@remote-host:22", SSHOpts: []string{"-o", "Port=22"}, CertPath: "/certs", Insecure: true},
+		{Host: "ssh://user@remote-host:22", SSHOpts: []string{"-o", "Port=22"}, CertPath: "/certs", APIVersion: "1.40"},
+		{Host: "ssh://user@remote-host:22", SSHOpts: []string{"-o", "Port=22"}, CertPath: "/certs", HTTPHeaders: map[string]string{"Proxy-Authorization": "Basic dGVhbTpzZWNyZXQ="}},
+		{Host: "ssh://user@remote-host:22", SSHOpts: []string{"-o", "Port=22"}, CertPath: "/certs", CacheKeySuffix: "tenant-a"},
+	} {
+		if config.Equal(other) {
+			t.Fatalf("Expected %v not to be equal to %v", config, other)
+		}
+	}
+}
+
+func TestLoadCachedClientWithHashCollision(t *testing.T) {
+	providerConfig := &ProviderConfig{}
+	config := &Config{Host: "tcp://host-a:2376"}
+	other := &Config{Host: "tcp://host-b:2376"}
+	dockerClient, err := config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate a collision by caching the client of another config under the hash of config
+	providerConfig.clientCache.Store(config.Hash(), &cachedClient{config: *other, client: dockerClient})
+	if _, found := providerConfig.loadCachedClient(context.Background(), config, config.Hash()); found {
+		t.Fatal("Expected the client of another config not to be reused")
+	}
+
+	providerConfig.clientCache.Store(config.Hash(), &cachedClient{config: *config, client: dockerClient})
+	cached, found := providerConfig.loadCachedClient(context.Background(), config, config.Hash())
+	if !found || cached != dockerClient {
+		t.Fatal("Expected the cached client of the config to be reused")
+	}
+
+	if _, found := providerConfig.loadCachedClient(context.Background(), other, other.Hash()); found {
+		t.Fatal("Expected no cached client for the other config")
+	}
+
+	stats := &providerConfig.cacheStats
+	if stats.hits.Load() != 1 || stats.misses.Load() != 2 || stats.collisions.Load() != 1 {
+		t.Fatalf("Expected 1 hit, 2 misses and 1 collision, got %d hits, %d misses and %d collisions", stats.hits.Load(), stats.misses.Load(), stats.collisions.Load())
+	}
+	if clients := providerConfig.cachedClientsPerHost(""); !reflect.DeepEqual(clients, map[string]int{"tcp://host-a:2376": 1}) {
+		t.Fatalf("Unexpected cached clients per host %v", clients)
+	}
+}
+
+func TestMakeClientWithCacheKeySuffix(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		fmt.Fprint(w, "OK")
+	}))
+	defer server.Close()
+
+	host := "tcp://" + strings.TrimPrefix(server.URL, "http://")
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: host},
+		Hosts: map[string]*Config{
+			"tenant-a": {Host: host, CacheKeySuffix: "tenant-a"},
+		},
+	}
+
+	shared := providerConfig.overrideConfig(&Config{})
+	tenantA := providerConfig.overrideConfig(&Config{Host: "tenant-a"})
+	tenantB := providerConfig.overrideConfig(&Config{CacheKeySuffix: "tenant-b"})
+	if shared.Hash() == tenantA.Hash() || tenantA.Hash() == tenantB.Hash() {
+		t.Fatal("Expected configs with different cache key suffixes to have different hashes")
+	}
+	if shared.limiterKey() != host || tenantA.limiterKey() != host+"#tenant-a" {
+		t.Fatalf("Expected the tenants to have their own concurrency limit, got %s and %s", shared.limiterKey(), tenantA.limiterKey())
+	}
+
+	clients := map[string]interface{}{}
+	for _, config := range []*Config{shared, tenantA, tenantB, providerConfig.overrideConfig(&Config{CacheKeySuffix: "tenant-b"})} {
+		dockerClient, err := providerConfig.MakeClientForConfig(context.Background(), config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clients[fmt.Sprintf("%p", dockerClient)] = true
+	}
+	if len(clients) != 3 {
+		t.Fatalf("Expected one client per cache key suffix, got %d clients", len(clients))
+	}
+	if perHost := providerConfig.cachedClientsPerHost(""); perHost[host] != 3 {
+		t.Fatalf("Expected 3 cached clients for the host, got %v", perHost)
+	}
+	if perHost := providerConfig.cachedClientsPerHost("tenant-b"); !reflect.DeepEqual(perHost, map[string]int{host: 1}) {
+		t.Fatalf("Expected only the client of tenant-b to be counted, got %v", perHost)
+	}
+}
+
+func TestConfigHashWithInsecure(t *testing.T) {
+	config := &Config{Host: "tcp://lab-host:2376"}
+	insecure := &Config{Host: "tcp://lab-host:2376", Insecure: true}
+	if config.Hash() == insecure.Hash() {
+		t.Fatal("Expected the hash of an insecure config to differ from the hash of a secure config")
+	}
+}
+
+func TestMakeClientWithHTTPHeaders(t *testing.T) {
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		w.Header().Set("API-Version", "1.41")
+		fmt.Fprint(w, "OK")
+	}))
+	defer server.Close()
+
+	host := "tcp://" + strings.TrimPrefix(server.URL, "http://")
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: host, HTTPHeaders: map[string]string{"Proxy-Authorization": "Basic dGVhbTpzZWNyZXQ="}},
+		Hosts: map[string]*Config{
+			"gateway": {Host: host, HTTPHeaders: map[string]string{"X-Api-Key": "gateway-key"}},
+		},
+	}
+
+	config := providerConfig.overrideConfig(&Config{Host: "gateway"})
+	if !reflect.DeepEqual(config.HTTPHeaders, map[string]string{"Proxy-Authorization": "Basic dGVhbTpzZWNyZXQ=", "X-Api-Key": "gateway-key"}) {
+		t.Fatalf("Expected the headers of the host to be merged with the ones of the provider, got %v", config.HTTPHeaders)
+	}
+	if len(providerConfig.DefaultConfig.HTTPHeaders) != 1 {
+		t.Fatalf("Expected the headers of the provider not to be changed, got %v", providerConfig.DefaultConfig.HTTPHeaders)
+	}
+	if config.Hash() == providerConfig.DefaultConfig.Hash() {
+		t.Fatal("Expected configs with different headers to have different hashes")
+	}
+
+	if _, err := providerConfig.MakeClientForConfig(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := providerConfig.MakeClientForConfig(context.Background(), providerConfig.DefaultConfig); err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) < 2 || headers[0].Get("X-Api-Key") != "gateway-key" || headers[0].Get("Proxy-Authorization") == "" {
+		t.Fatalf("Expected the headers of the host to be sent, got %v", headers)
+	}
+	if last := headers[len(headers)-1]; last.Get("X-Api-Key") != "" || last.Get("Proxy-Authorization") != "Basic dGVhbTpzZWNyZXQ=" {
+		t.Fatalf("Expected only the headers of the provider to be sent by its client, got %v", last)
+	}
+}
+
+func TestMakeClientWithSkipPing(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/_ping") {
+			// the API gateway blocks the ping
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, "[]")
+	}))
+	defer server.Close()
+
+	config := &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")}
+	if _, err := (&ProviderConfig{DefaultConfig: config}).MakeClientForConfig(context.Background(), config); err == nil {
+		t.Fatal("Expected the blocked ping to fail")
+	}
+
+	paths = nil
+	providerConfig := &ProviderConfig{DefaultConfig: config, SkipPing: true}
+	client, err := providerConfig.MakeClientForConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Expected the ping to be skipped, got %s", err)
+	}
+	if client.ClientVersion() != "1.41" {
+		t.Fatalf("Expected the default API version instead of a negotiated one, got %s", client.ClientVersion())
+	}
+	if _, err := client.NetworkList(context.Background(), types.NetworkListOptions{}); err != nil {
+		t.Fatalf("Expected the requests to work, got %s", err)
+	}
+	if !reflect.DeepEqual(paths, []string{"/v1.41/networks"}) {
+		t.Fatalf("Expected only the request to be sent, got %v", paths)
+	}
+}
+
+func TestGetConfigWithInsecureOverride(t *testing.T) {
+	providerConfig := &ProviderConfig{DefaultConfig: &Config{Host: "tcp://prod-host:2376"}}
+	config := providerConfig.getConfig(nil)
+	if config.Insecure {
+		t.Fatal("Expected the default config not to be insecure")
+	}
+
+	d := schema.TestResourceDataRaw(t, map[string]*schema.Schema{"override": overrideSchema}, map[string]interface{}{
+		"override": []interface{}{map[string]interface{}{"host": "tcp://lab-host:2376", "insecure": true}},
+	})
+	config = providerConfig.getConfig(d)
+	if !config.Insecure || config.Host != "tcp://lab-host:2376" {
+		t.Fatalf("Expected the override to select the insecure lab host, got %+v", config)
+	}
+	if !providerConfig.DefaultConfig.Equal(&Config{Host: "tcp://prod-host:2376"}) {
+		t.Fatal("Expected the override not to change the default config")
+	}
+}
+
+func TestOverrideConfigForBuilder(t *testing.T) {
+	providerConfig := &ProviderConfig{DefaultConfig: &Config{Host: "tcp://deploy-host:2376", CertPath: "/certs"}}
+
+	builder := []interface{}{map[string]interface{}{
+		"host":          "ssh://builder@build-node",
+		"ssh_opts":      []interface{}{"-o", "ConnectTimeout=10"},
+		"ca_material":   "",
+		"cert_material": "",
+		"key_material":  "",
+		"cert_path":     "",
+		"insecure":      false,
+	}}
+	config := providerConfig.overrideConfig(newConfigFromOverride(builder))
+	if config.Host != "ssh://builder@build-node" || config.CertPath != "/certs" {
+		t.Fatalf("Expected the builder host on top of the provider config, got %+v", config)
+	}
+	if !reflect.DeepEqual(config.SSHOpts, []string{"-o", "ConnectTimeout=10"}) {
+		t.Fatalf("Expected the ssh_opts of the builder, got %v", config.SSHOpts)
+	}
+
+	if config := providerConfig.overrideConfig(newConfigFromOverride(nil)); !config.Equal(providerConfig.DefaultConfig) {
+		t.Fatalf("Expected the provider config without builder, got %+v", config)
+	}
+}
+
+func TestOverrideConfigWithHosts(t *testing.T) {
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "unix:///var/run/docker.sock", SSHOpts: []string{"-o", "ConnectTimeout=10"}},
+		Hosts: map[string]*Config{
+			"prod": {Host: "tcp://prod.example.com:2376", CertPath: "/certs/prod"},
+		},
+	}
+
+	config := providerConfig.overrideConfig(&Config{Host: "prod", Insecure: true})
+	expected := &Config{Host: "tcp://prod.example.com:2376", SSHOpts: []string{"-o", "ConnectTimeout=10"}, CertPath: "/certs/prod", Insecure: true}
+	if !reflect.DeepEqual(config, expected) {
+		t.Fatalf("Expected the host with the override on top, got %+v", config)
+	}
+
+	_, err := providerConfig.MakeClientForConfig(context.Background(), providerConfig.overrideConfig(&Config{Host: "staging"}))
+	if err == nil || !strings.Contains(err.Error(), `unknown host "staging"`) || !strings.Contains(err.Error(), "prod") {
+		t.Fatalf("Expected an error for the unknown host, got %v", err)
+	}
+}
+
+func TestValidateHostScheme(t *testing.T) {
+	for _, host := range []string{"tcp://127.0.0.1:2376", "unix:///var/run/docker.sock", "ssh://user@remote", "npipe:////./pipe/docker_engine", "https://docker.example.com"} {
+		if err := validateHostScheme(host); err != nil {
+			t.Fatalf("Expected %s to be supported, got %s", host, err)
+		}
+	}
+	err := validateHostScheme("tpc://127.0.0.1:2376")
+	if err == nil || !strings.Contains(err.Error(), "unsupported scheme tpc://") || !strings.Contains(err.Error(), "tcp://, unix://, ssh://") {
+		t.Fatalf("Expected an error with the supported schemes, got %v", err)
+	}
+
+	providerConfig := &ProviderConfig{}
+	if _, err := providerConfig.MakeClientForConfig(context.Background(), &Config{Host: "tpc://127.0.0.1:2376"}); err == nil || !strings.Contains(err.Error(), "unsupported scheme") {
+		t.Fatalf("Expected the client for the unsupported scheme to be rejected, got %v", err)
+	}
+}
+
+func TestNewClientWithNamedPipeHost(t *testing.T) {
+	host := "npipe:////./pipe/docker_engine"
+	if !isNamedPipeHost(host) {
+		t.Fatalf("Expected %s to be a named pipe host", host)
+	}
+	if isNamedPipeHost("unix:///var/run/docker.sock") {
+		t.Fatal("Expected a unix socket not to be a named pipe host")
+	}
+
+	// TLS settings are ignored for named pipes
+	config := &Config{Host: host, CertPath: "/does/not/exist", HTTPHeaders: map[string]string{"X-Team": "platform"}}
+	dockerClient, err := config.NewClient()
+	if runtime.GOOS != "windows" {
+		if err == nil {
+			t.Fatal("Expected named pipe hosts to be rejected on other platforms than Windows")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if dockerClient.DaemonHost() != host {
+		t.Fatalf("Expected daemon host %s, got %s", host, dockerClient.DaemonHost())
+	}
+	if dockerClient.CustomHTTPHeaders()["X-Team"] != "platform" {
+		t.Fatalf("Expected the http_headers to be sent, got %v", dockerClient.CustomHTTPHeaders())
+	}
+
+	pinned, err := newNamedPipeClient(host, withAPIVersion("1.40"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if pinned.ClientVersion() != "1.40" {
+		t.Fatalf("Expected the API version 1.40, got %s", pinned.ClientVersion())
+	}
+}
+
+func TestHostLimiter(t *testing.T) {
+	limiter := newHostLimiter(2)
+	releaseFirst, err := limiter.acquire(context.Background(), "tcp://a:2376")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if _, err := limiter.acquire(context.Background(), "tcp://a:2376"); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx, "tcp://a:2376"); err == nil {
+		t.Fatal("Expected the third operation on the host to wait until the timeout")
+	}
+	if _, err := limiter.acquire(ctx, "tcp://b:2376"); err != nil {
+		t.Fatalf("Expected the operations on another host not to be limited, got %s", err)
+	}
+
+	releaseFirst()
+	if _, err := limiter.acquire(context.Background(), "tcp://a:2376"); err != nil {
+		t.Fatalf("Expected a free slot after the release, got %s", err)
+	}
+
+	var unlimited *hostLimiter
+	for i := 0; i < 3; i++ {
+		if _, err := unlimited.acquire(ctx, "tcp://a:2376"); err != nil {
+			t.Fatalf("Expected no limit without a limiter, got %s", err)
+		}
+	}
+}
+
+func TestWithoutHostSlot(t *testing.T) {
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://a:2376"},
+		hostLimiter:   newHostLimiter(1),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	otherOperation := withHostLimit(func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+		return nil
+	})
+	// the waiting operation holds the only slot of the host, except while it waits
+	waitingOperation := withHostLimit(func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		err := withoutHostSlot(ctx, func() error {
+			if diags := otherOperation(ctx, nil, meta); diags.HasError() {
+				return fmt.Errorf("%v", diags)
+			}
+			return nil
+		})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		if diags := otherOperation(waitCtx, nil, meta); !diags.HasError() {
+			return diag.Errorf("expected the slot to be acquired again after the wait")
+		}
+		return nil
+	})
+	if diags := waitingOperation(ctx, nil, providerConfig); diags.HasError() {
+		t.Fatalf("Expected the other operation to run during the wait, got %v", diags)
+	}
+	if diags := otherOperation(ctx, nil, providerConfig); diags.HasError() {
+		t.Fatalf("Expected the slot to be released after the operation, got %v", diags)
+	}
+}
+
+func TestDaemonWarningsAreReportedOnce(t *testing.T) {
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://prod:2376"},
+		Hosts:         map[string]*Config{"staging": {Host: "tcp://staging:2376"}},
+	}
+	d := schema.TestResourceDataRaw(t, map[string]*schema.Schema{"override": overrideSchema}, map[string]interface{}{})
+	warning := "The deprecated option is removed in a future version"
+
+	diags := providerConfig.daemonWarnings(context.Background(), d, "creating network foo", []string{warning, ""})
+	if len(diags) != 1 || diags[0].Severity != diag.Warning || diags[0].Detail != warning {
+		t.Fatalf("Expected one warning diagnostic, got %#v", diags)
+	}
+	if diags := providerConfig.daemonWarnings(context.Background(), d, "creating network bar", []string{warning}); len(diags) != 0 {
+		t.Fatalf("Expected the warning to be reported only once, got %#v", diags)
+	}
+
+	staging := schema.TestResourceDataRaw(t, map[string]*schema.Schema{"override": overrideSchema}, map[string]interface{}{
+		"override": []interface{}{map[string]interface{}{"host": "staging"}},
+	})
+	if diags := providerConfig.daemonWarnings(context.Background(), staging, "creating network foo", []string{warning}); len(diags) != 1 {
+		t.Fatalf("Expected the warning of another host to be reported, got %#v", diags)
+	}
+}
+
+func TestProbeSSHConnection(t *testing.T) {
+	ctx := context.Background()
+	host := "ssh://admin@docker.example.com"
+
+	respondingDialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			request := make([]byte, len(sshPingRequest))
+			if _, err := io.ReadFull(server, request); err == nil {
+				server.Write([]byte("HTTP/1.1 200 OK\r\n\r\n"))
+			}
+		}()
+		return client, nil
+	}
+	if err := probeSSHConnection(ctx, host, respondingDialer, time.Second); err != nil {
+		t.Fatalf("Expected the connection to be established, got %s", err)
+	}
+
+	rejectingDialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		server.Close()
+		return &failingConn{Conn: client, err: errors.New("command [ssh -- admin@docker.example.com docker system dial-stdio] has exited with exit status 255, please make sure the URL is valid, and Docker 18.09 or later is installed on the remote host: stderr=Warning: Permanently added 'docker.example.com' to the list of known hosts.\r\nadmin@docker.example.com: Permission denied (publickey).\n")}, nil
+	}
+	err := probeSSHConnection(ctx, host, rejectingDialer, time.Second)
+	expected := "cannot establish SSH connection to host ssh://admin@docker.example.com: admin@docker.example.com: Permission denied (publickey)."
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected error %q, got %v", expected, err)
+	}
+
+	hangingDialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go io.Copy(io.Discard, server)
+		return client, nil
+	}
+	if err := probeSSHConnection(ctx, host, hangingDialer, 50*time.Millisecond); err == nil || !strings.Contains(err.Error(), "within 50ms") {
+		t.Fatalf("Expected a timeout error, got %v", err)
+	}
+}
+
+// failingConn is a connection whose reads fail like the ones of a failed ssh command.
+type failingConn struct {
+	net.Conn
+	err error
+}
+
+func (c *failingConn) Read(b []byte) (int, error) {
+	return 0, c.err
+}
+
+func (c *failingConn) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func TestSSHCommand(t *testing.T) {
+	command, args := sshCommand("/opt/bin/ssh", nil, []string{"-l", "admin"})
+	if command != "/opt/bin/ssh" || !reflect.DeepEqual(args, []string{"-l", "admin"}) {
+		t.Fatalf("Expected ssh to be run directly, got %s %v", command, args)
+	}
+
+	command, args = sshCommand("ssh", map[string]string{"SSH_AUTH_SOCK": "/run/agent.sock", "HOME": "/home/ci"}, []string{"-l", "admin"})
+	expected := []string{"HOME=/home/ci", "SSH_AUTH_SOCK=/run/agent.sock", "ssh", "-l", "admin"}
+	if command != "env" || !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Expected ssh to be run with env %v, got %s %v", expected, command, args)
+	}
+}
+
+func TestNewSSHConnectionHelperWithBinaryAndEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ssh executable is a shell script")
+	}
+	// an ssh executable which writes the agent socket and its arguments
+	binary := filepath.Join(t.TempDir(), "fake-ssh")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\necho \"$SSH_AUTH_SOCK $*\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	helper, err := newSSHConnectionHelper("ssh://admin@docker.example.com", []string{"-o", "BatchMode=yes"}, binary, map[string]string{"SSH_AUTH_SOCK": "/run/agent.sock"})
+	if err != nil {
+		t.Fatalf("Expected the connection helper to be created, got %s", err)
+	}
+	conn, err := helper.Dialer(context.Background(), "tcp", "")
+	if err != nil {
+		t.Fatalf("Expected the fake ssh executable to be started, got %s", err)
+	}
+	defer conn.Close()
+
+	output, _ := io.ReadAll(conn)
+	expected := "/run/agent.sock -o BatchMode=yes -l admin -- docker.example.com docker system dial-stdio\n"
+	if string(output) != expected {
+		t.Fatalf("Expected output %q, got %q", expected, string(output))
+	}
+}
+
+// flakyTransport fails the first requests with the given errors, a nil error
+// stands for an internal server error of the daemon.
+type flakyTransport struct {
+	failures []error
+	status   int
+	requests int
+}
+
+func (t *flakyTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.requests++
+	response := func(status int, body string) *http.Response {
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}
+	}
+	if len(t.failures) > 0 {
+		err := t.failures[0]
+		t.failures = t.failures[1:]
+		if err != nil {
+			return nil, err
+		}
+		return response(http.StatusInternalServerError, `{"message": "internal error"}`), nil
+	}
+	if t.status != 0 {
+		return response(t.status, `{"message": "get tf-test: no such volume"}`), nil
+	}
+	return response(http.StatusOK, `{"Name": "tf-test", "Driver": "local"}`), nil
+}
+
+func TestWithRetry(t *testing.T) {
+	ctx := context.Background()
+	inspect := func(providerConfig *ProviderConfig, transport *flakyTransport) error {
+		dockerClient, err := client.NewClientWithOpts(
+			client.WithHost("tcp://docker.example.com:2376"),
+			client.WithHTTPClient(&http.Client{Transport: transport}),
+			client.WithVersion("1.41"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return providerConfig.withRetry(ctx, "inspect volume", func() error {
+			_, err := dockerClient.VolumeInspect(ctx, "tf-test")
+			return err
+		})
+	}
+	providerConfig := &ProviderConfig{APIRetries: 2, APIRetryBackoff: time.Millisecond}
+
+	transport := &flakyTransport{failures: []error{io.EOF, nil}}
+	if err := inspect(providerConfig, transport); err != nil || transport.requests != 3 {
+		t.Fatalf("Expected the transient errors to be retried, got %v after %d requests", err, transport.requests)
+	}
+
+	transport = &flakyTransport{failures: []error{syscall.ECONNRESET, io.EOF, nil}}
+	if err := inspect(providerConfig, transport); err == nil || transport.requests != 3 {
+		t.Fatalf("Expected to give up after 2 retries, got %v after %d requests", err, transport.requests)
+	}
+
+	transport = &flakyTransport{status: http.StatusNotFound}
+	if err := inspect(providerConfig, transport); !errdefs.IsNotFound(err) || transport.requests != 1 {
+		t.Fatalf("Expected a missing volume not to be retried, got %v after %d requests", err, transport.requests)
+	}
+
+	transport = &flakyTransport{failures: []error{io.EOF}}
+	if err := inspect(&ProviderConfig{}, transport); err == nil || transport.requests != 1 {
+		t.Fatalf("Expected no retries by default, got %v after %d requests", err, transport.requests)
+	}
+}
+
+func TestMakeClientWithTooNewAPIVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.40")
+		fmt.Fprint(w, "OK")
+	}))
+	defer server.Close()
+	config := &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")}
+
+	providerConfig := &ProviderConfig{APIVersion: "1.41"}
+	_, err := providerConfig.MakeClientForConfig(context.Background(), config)
+	if err == nil || !strings.Contains(err.Error(), "supports API versions up to 1.40. Set the api_version of the provider to 1.40 or lower") {
+		t.Fatalf("Expected an error with the maximum API version of the Docker host, got %v", err)
+	}
+	if _, err := providerConfig.MakeClientForConfig(context.Background(), config); err == nil {
+		t.Fatal("Expected the client with the too new API version not to be cached")
+	}
+
+	for _, apiVersion := range []string{"", "1.40", "1.39"} {
+		providerConfig := &ProviderConfig{APIVersion: apiVersion}
+		if _, err := providerConfig.MakeClientForConfig(context.Background(), config); err != nil {
+			t.Fatalf("Expected the API version %q to be accepted, got %s", apiVersion, err)
+		}
+	}
+}
+
+func TestMakeClientWithHostAPIVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.40")
+		fmt.Fprint(w, "OK")
+	}))
+	defer server.Close()
+	host := "tcp://" + strings.TrimPrefix(server.URL, "http://")
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "unix:///var/run/docker.sock"},
+		Hosts: map[string]*Config{
+			"old": {Host: host, APIVersion: "1.40"},
+			"new": {Host: host, APIVersion: "1.41"},
+		},
+		APIVersion: "1.41",
+	}
+	config := providerConfig.overrideConfig(&Config{Host: "old"})
+	if config.APIVersion != "1.40" {
+		t.Fatalf("Expected the API version of the host, got %+v", config)
+	}
+	dockerClient, err := providerConfig.MakeClientForConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Expected the API version of the host to be used, got %s", err)
+	}
+	if dockerClient.ClientVersion() != "1.40" {
+		t.Fatalf("Expected the client to use the API version 1.40, got %s", dockerClient.ClientVersion())
+	}
+
+	_, err = providerConfig.MakeClientForConfig(context.Background(), providerConfig.overrideConfig(&Config{Host: "new"}))
+	if err == nil || !strings.Contains(err.Error(), "Set the api_version of the host to 1.40 or lower") {
+		t.Fatalf("Expected an error for the API version of the host, got %v", err)
+	}
+}
+
+func TestMakeClientWithReloadCerts(t *testing.T) {
+	var clientNames []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientNames = append(clientNames, r.TLS.PeerCertificates[0].Subject.CommonName)
+		w.Header().Set("API-Version", "1.41")
+		fmt.Fprint(w, "OK")
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	certPath := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(filepath.Join(certPath, "ca.pem"), ca, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	writeClientCertificate(t, certPath, "first")
+
+	providerConfig := &ProviderConfig{ReloadCerts: true}
+	config := &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "https://"), CertPath: certPath}
+	dockerClient, err := providerConfig.MakeClientForConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Expected the client to connect with the client certificate, got %s", err)
+	}
+
+	// the certificate is rotated while the client is cached
+	writeClientCertificate(t, certPath, "second")
+	if _, err := dockerClient.Ping(context.Background()); err != nil {
+		t.Fatalf("Expected the client to connect with the rotated certificate, got %s", err)
+	}
+	if !reflect.DeepEqual(clientNames, []string{"first", "second"}) {
+		t.Fatalf("Expected the rotated certificate to be used for the next connection, got %v", clientNames)
+	}
+}
+
+// writeClientCertificate writes a self-signed client certificate with the common
+// name and its key to the cert.pem and key.pem of the cert path.
+func writeClientCertificate(t *testing.T, certPath string, commonName string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(certPath, "cert.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(certPath, "key.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildHTTPClientWithSystemCAs(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	onlyCA := x509.NewCertPool()
+	onlyCA.AppendCertsFromPEM(ca)
+	withSystemCAs, err := x509.SystemCertPool()
+	if err != nil {
+		t.Skipf("No system trust store: %s", err)
+	}
+	withSystemCAs.AppendCertsFromPEM(ca)
+
+	httpClient, err := buildHTTPClient(ca, nil, nil, tlsClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rootCAs := httpClient.Transport.(*http.Transport).TLSClientConfig.RootCAs; !rootCAs.Equal(onlyCA) {
+		t.Fatal("Expected only the CA to be trusted by default")
+	}
+
+	httpClient, err = buildHTTPClient(ca, nil, nil, tlsClientOptions{appendSystemCAs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rootCAs := httpClient.Transport.(*http.Transport).TLSClientConfig.RootCAs; !rootCAs.Equal(withSystemCAs) {
+		t.Fatal("Expected the CA to be added to the system trust store")
+	}
+	if _, err := httpClient.Get(server.URL); err != nil {
+		t.Fatalf("Expected the server with the CA to be trusted, got %s", err)
+	}
+}
+
+func TestApplyDockerContext(t *testing.T) {
+	configDir := t.TempDir()
+	writeContext := func(name, meta string, withTLS bool) string {
+		contextID := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))
+		metaDir := filepath.Join(configDir, "contexts", "meta", contextID)
+		if err := os.MkdirAll(metaDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(metaDir, "meta.json"), []byte(meta), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		tlsDir := filepath.Join(configDir, "contexts", "tls", contextID, "docker")
+		if withTLS {
+			if err := os.MkdirAll(tlsDir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return tlsDir
+	}
+	tlsDir := writeContext("prod", `{"Name":"prod","Metadata":{},"Endpoints":{"docker":{"Host":"tcp://prod.example.com:2376","SkipTLSVerify":false}}}`, true)
+	writeContext("remote", `{"Name":"remote","Metadata":{},"Endpoints":{"docker":{"Host":"ssh://user@remote.example.com","SkipTLSVerify":true}}}`, false)
+	writeContext("kubernetes", `{"Name":"kubernetes","Metadata":{},"Endpoints":{"kubernetes":{"Host":"https://k8s.example.com"}}}`, false)
+	writeContext("broken", `{`, false)
+
+	t.Run("Should use the endpoint and TLS files of the context", func(t *testing.T) {
+		config := &Config{Host: "unix:///var/run/docker.sock", CertPath: "/etc/docker/certs"}
+		if err := applyDockerContext(config, configDir, "prod"); err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		if config.Host != "tcp://prod.example.com:2376" || config.CertPath != tlsDir || config.Insecure {
+			t.Fatalf("Unexpected config %+v", config)
+		}
+	})
+
+	t.Run("Should not use a cert path without TLS files", func(t *testing.T) {
+		config := &Config{Host: "unix:///var/run/docker.sock", CertPath: "/etc/docker/certs"}
+		if err := applyDockerContext(config, configDir, "remote"); err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		if config.Host != "ssh://user@remote.example.com" || config.CertPath != "" || !config.Insecure {
+			t.Fatalf("Unexpected config %+v", config)
+		}
+	})
+
+	for _, name := range []string{"missing", "kubernetes", "broken"} {
+		t.Run("Should fail for the context "+name, func(t *testing.T) {
+			if err := applyDockerContext(&Config{}, configDir, name); err == nil {
+				t.Fatal("Expected an error")
+			}
+		})
+	}
+}
+
+func TestDockerConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configFile, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, value := range []string{dir, configFile} {
+		t.Setenv("DOCKER_CONFIG", value)
+		got, err := dockerConfigDir()
+		if err != nil {
+			t.Fatalf("Expected no error, got %s", err)
+		}
+		if got != dir {
+			t.Fatalf("Expected %s for DOCKER_CONFIG %s, got %s", dir, value, got)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceDockerClientCache() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reads the statistics of the cache of Docker clients of the provider, to diagnose connection issues with many hosts or `override` blocks. The provider reuses one client per distinct connection config. The counters cover the current terraform run up to the point the data source is read, so make it depend on the resources of interest.",
+
+		ReadContext: dataSourceDockerClientCacheRead,
+
+		Schema: map[string]*schema.Schema{
+			"cache_key_suffix": {
+				Type:        schema.TypeString,
+				Description: "If set, only the clients of configs with this `cache_key_suffix` are counted in `clients` and `clients_per_host`. The `hits`, `misses` and `hash_collisions` always cover all clients.",
+				Optional:    true,
+			},
+			"hits": {
+				Type:        schema.TypeInt,
+				Description: "The number of times a cached client was reused.",
+				Computed:    true,
+			},
+			"misses": {
+				Type:        schema.TypeInt,
+				Description: "The number of times a new client had to be created.",
+				Computed:    true,
+			},
+			"hash_collisions": {
+				Type:        schema.TypeInt,
+				Description: "The number of misses because a cached client was created for a different config with the same hash.",
+				Computed:    true,
+			},
+			"clients": {
+				Type:        schema.TypeInt,
+				Description: "The number of cached clients.",
+				Computed:    true,
+			},
+			"clients_per_host": {
+				Type:        schema.TypeMap,
+				Description: "The number of cached clients for each Docker host.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+			},
+		},
+	}
+}
+
+func dataSourceDockerClientCacheRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*ProviderConfig)
+
+	suffix := d.Get("cache_key_suffix").(string)
+	clientsPerHost := providerConfig.cachedClientsPerHost(suffix)
+	clients := 0
+	for _, count := range clientsPerHost {
+		clients += count
+	}
+
+	if suffix != "" {
+		d.SetId("client-cache-" + suffix)
+	} else {
+		d.SetId("client-cache")
+	}
+	d.Set("hits", providerConfig.cacheStats.hits.Load())
+	d.Set("misses", providerConfig.cacheStats.misses.Load())
+	d.Set("hash_collisions", providerConfig.cacheStats.collisions.Load())
+	d.Set("clients", clients)
+	d.Set("clients_per_host", clientsPerHost)
+
+	return nil
+}
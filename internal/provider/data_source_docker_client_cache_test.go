@@ -0,0 +1,25 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDockerClientCacheDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: loadTestConfiguration(t, DATA_SOURCE, "docker_client_cache", "testAccDockerClientCacheDataSource"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.docker_client_cache.test", "clients", "1"),
+					resource.TestCheckResourceAttr("data.docker_client_cache.test", "clients_per_host.%", "1"),
+					resource.TestCheckResourceAttr("data.docker_client_cache.test", "hash_collisions", "0"),
+					testValueHigherEqualThan("data.docker_client_cache.test", "misses", 1),
+				),
+			},
+		},
+	})
+}
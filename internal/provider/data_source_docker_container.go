@@ -0,0 +1,253 @@
+package provider
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceDockerContainer() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reads the details of a running or stopped Docker container by its name or ID, e.g. of a container which is managed outside of Terraform or by another configuration.",
+
+		ReadContext: dataSourceDockerContainerRead,
+
+		Schema: map[string]*schema.Schema{
+			"override": overrideSchema,
+
+			"name": {
+				Type:        schema.TypeString,
+				Description: "The name or ID of the Docker container.",
+				Required:    true,
+			},
+
+			"container_name": {
+				Type:        schema.TypeString,
+				Description: "The name of the container, without the leading `/`.",
+				Computed:    true,
+			},
+
+			"image": {
+				Type:        schema.TypeString,
+				Description: "The name of the image the container was created from, as it was given on create.",
+				Computed:    true,
+			},
+
+			"image_id": {
+				Type:        schema.TypeString,
+				Description: "The ID of the image of the container.",
+				Computed:    true,
+			},
+
+			"state": {
+				Type:        schema.TypeString,
+				Description: "The state of the container, e.g. `running` or `exited`.",
+				Computed:    true,
+			},
+
+			"running": {
+				Type:        schema.TypeBool,
+				Description: "If `true`, the container is running.",
+				Computed:    true,
+			},
+
+			"exit_code": {
+				Type:        schema.TypeInt,
+				Description: "The exit code of the container if it is not running.",
+				Computed:    true,
+			},
+
+			"hostname": {
+				Type:        schema.TypeString,
+				Description: "The hostname of the container.",
+				Computed:    true,
+			},
+
+			"labels": {
+				Type:        schema.TypeMap,
+				Description: "The labels of the container.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+
+			"network_data": {
+				Type:        schema.TypeList,
+				Description: "The networks the container is connected to, sorted by name.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"network_name": {
+							Type:        schema.TypeString,
+							Description: "The name of the network.",
+							Computed:    true,
+						},
+						"ip_address": {
+							Type:        schema.TypeString,
+							Description: "The IP address of the container in the network.",
+							Computed:    true,
+						},
+						"ip_prefix_length": {
+							Type:        schema.TypeInt,
+							Description: "The IP prefix length of the container in the network.",
+							Computed:    true,
+						},
+						"gateway": {
+							Type:        schema.TypeString,
+							Description: "The gateway of the network.",
+							Computed:    true,
+						},
+						"global_ipv6_address": {
+							Type:        schema.TypeString,
+							Description: "The IPv6 address of the container in the network.",
+							Computed:    true,
+						},
+						"global_ipv6_prefix_length": {
+							Type:        schema.TypeInt,
+							Description: "The IPv6 prefix length of the container in the network.",
+							Computed:    true,
+						},
+						"ipv6_gateway": {
+							Type:        schema.TypeString,
+							Description: "The IPv6 gateway of the network.",
+							Computed:    true,
+						},
+						"mac_address": {
+							Type:        schema.TypeString,
+							Description: "The MAC address of the container in the network.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+
+			"ports": {
+				Type:        schema.TypeList,
+				Description: "The ports the container publishes on the Docker host. The ports of a stopped container are not published.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"internal": {
+							Type:        schema.TypeInt,
+							Description: "The port within the container.",
+							Computed:    true,
+						},
+						"external": {
+							Type:        schema.TypeInt,
+							Description: "The port on the Docker host.",
+							Computed:    true,
+						},
+						"ip": {
+							Type:        schema.TypeString,
+							Description: "The IP address the port is published on.",
+							Computed:    true,
+						},
+						"protocol": {
+							Type:        schema.TypeString,
+							Description: "The protocol of the port, e.g. `tcp`.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+
+			"mounts": {
+				Type:        schema.TypeList,
+				Description: "The mounts of the container, sorted by target.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:        schema.TypeString,
+							Description: "The type of the mount, e.g. `bind`, `volume` or `tmpfs`.",
+							Computed:    true,
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Description: "The name of the volume, if the mount is a volume.",
+							Computed:    true,
+						},
+						"source": {
+							Type:        schema.TypeString,
+							Description: "The path of the mount on the Docker host.",
+							Computed:    true,
+						},
+						"target": {
+							Type:        schema.TypeString,
+							Description: "The path of the mount in the container.",
+							Computed:    true,
+						},
+						"read_only": {
+							Type:        schema.TypeBool,
+							Description: "If `true`, the mount is read-only.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceDockerContainerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, errC := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if errC != nil {
+		return diag.FromErr(errC)
+	}
+
+	name := d.Get("name").(string)
+	container, err := client.ContainerInspect(ctx, name)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return diag.Errorf("There is no container with the name or ID '%s' on the Docker host", name)
+		}
+		return diag.Errorf("Unable to inspect container '%s': %s", name, err)
+	}
+
+	d.SetId(container.ID)
+	d.Set("container_name", strings.TrimPrefix(container.Name, "/"))
+	d.Set("image_id", container.Image)
+	if container.Config != nil {
+		d.Set("image", container.Config.Image)
+		d.Set("hostname", container.Config.Hostname)
+		d.Set("labels", container.Config.Labels)
+	}
+	if container.State != nil {
+		d.Set("state", container.State.Status)
+		d.Set("running", container.State.Running)
+		d.Set("exit_code", container.State.ExitCode)
+	}
+	networks := flattenContainerNetworks(container.NetworkSettings)
+	sort.Slice(networks, func(i, j int) bool {
+		return networks[i].(map[string]interface{})["network_name"].(string) < networks[j].(map[string]interface{})["network_name"].(string)
+	})
+	d.Set("network_data", networks)
+	if container.NetworkSettings != nil {
+		d.Set("ports", flattenContainerPorts(container.NetworkSettings.Ports))
+	}
+	d.Set("mounts", flattenContainerMountPoints(container.Mounts))
+
+	return nil
+}
+
+// flattenContainerMountPoints returns the mounts of a container sorted by their
+// path in the container.
+func flattenContainerMountPoints(mountPoints []types.MountPoint) []interface{} {
+	sort.Slice(mountPoints, func(i, j int) bool { return mountPoints[i].Destination < mountPoints[j].Destination })
+
+	mounts := make([]interface{}, 0, len(mountPoints))
+	for _, m := range mountPoints {
+		mounts = append(mounts, map[string]interface{}{
+			"type":      string(m.Type),
+			"name":      m.Name,
+			"source":    m.Source,
+			"target":    m.Destination,
+			"read_only": !m.RW,
+		})
+	}
+	return mounts
+}
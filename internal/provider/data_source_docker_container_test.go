@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAccDockerContainerDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: loadTestConfiguration(t, DATA_SOURCE, "docker_container", "testAccDockerContainerDataSource"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.docker_container.foo", "id", "docker_container.foo", "id"),
+					resource.TestCheckResourceAttr("data.docker_container.foo", "container_name", "tf-test-container-data-source"),
+					resource.TestCheckResourceAttr("data.docker_container.foo", "running", "true"),
+					resource.TestCheckResourceAttr("data.docker_container.foo", "ports.0.internal", "80"),
+					resource.TestCheckResourceAttrPair("data.docker_container.foo", "ports.0.external", "docker_container.foo", "ports.0.external"),
+					resource.TestCheckResourceAttrSet("data.docker_container.foo", "network_data.0.ip_address"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDockerContainerDataSource_missing(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				data "docker_container" "foo" {
+					name = "tf-test-missing-container"
+				}
+				`,
+				ExpectError: regexp.MustCompile(`There is no container with the name or ID 'tf-test-missing-container'`),
+			},
+		},
+	})
+}
+
+func TestDataSourceDockerContainerRead(t *testing.T) {
+	containerID := "9a550c0f0163d39d77222d3efd58701b625d47676c25c686c95b5b92d1cba6fd"
+	// a Docker host which only knows the stopped container 'db'
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/containers/db/json"):
+			fmt.Fprintf(w, `{
+				"Id": %q,
+				"Name": "/db",
+				"Image": "sha256:abc",
+				"State": {"Status": "exited", "Running": false, "ExitCode": 3},
+				"Config": {"Image": "postgres:16", "Hostname": "db", "Labels": {"team": "storage"}},
+				"HostConfig": {},
+				"NetworkSettings": {"Networks": {
+					"frontend": {"IPAddress": "10.0.2.3", "IPPrefixLen": 24},
+					"backend": {"IPAddress": "10.0.1.3", "IPPrefixLen": 24}
+				}},
+				"Mounts": [
+					{"Type": "volume", "Name": "data", "Source": "/var/lib/docker/volumes/data/_data", "Destination": "/var/lib/postgresql/data", "RW": true},
+					{"Type": "bind", "Source": "/etc/db", "Destination": "/etc/postgresql", "RW": false}
+				]
+			}`, containerID)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "No such container"}`)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+
+	d := schema.TestResourceDataRaw(t, dataSourceDockerContainer().Schema, map[string]interface{}{"name": "db"})
+	if diags := dataSourceDockerContainerRead(context.Background(), d, providerConfig); diags.HasError() {
+		t.Fatalf("Expected the container to be read, got %#v", diags)
+	}
+	if d.Id() != containerID || d.Get("image") != "postgres:16" || d.Get("state") != "exited" || d.Get("exit_code") != 3 {
+		t.Fatalf("Expected the details of the container, got %#v", d.State())
+	}
+	if d.Get("network_data.0.network_name") != "backend" || d.Get("network_data.1.ip_address") != "10.0.2.3" {
+		t.Fatalf("Expected the networks sorted by name, got %#v", d.Get("network_data"))
+	}
+	if d.Get("mounts.0.target") != "/etc/postgresql" || d.Get("mounts.0.read_only") != true || d.Get("mounts.1.name") != "data" {
+		t.Fatalf("Expected the mounts sorted by target, got %#v", d.Get("mounts"))
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceDockerContainer().Schema, map[string]interface{}{"name": "missing"})
+	diags := dataSourceDockerContainerRead(context.Background(), d, providerConfig)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "There is no container") {
+		t.Fatalf("Expected an error for the missing container, got %#v", diags)
+	}
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceDockerImageSave() *schema.Resource {
+	return &schema.Resource{
+		Description: "`docker_image_save` writes an image which is present on the Docker Host into a tar archive on the machine running terraform, like `docker save` does. The archive can be loaded on another Docker Host with the `source_tar` attribute of the `docker_image` resource, without a registry in between.",
+
+		ReadContext: dataSourceDockerImageSaveRead,
+
+		Schema: map[string]*schema.Schema{
+			"override": overrideSchema,
+			"name": {
+				Type:        schema.TypeString,
+				Description: "The name of the Docker image, including any tags or SHA256 repo digests.",
+				Required:    true,
+			},
+			"path": {
+				Type:        schema.TypeString,
+				Description: "The path of the tar archive on the machine running terraform. An existing file is overwritten.",
+				Required:    true,
+			},
+			"image_id": {
+				Type:        schema.TypeString,
+				Description: "The ID of the saved image.",
+				Computed:    true,
+			},
+			"size": {
+				Type:        schema.TypeInt,
+				Description: "The size of the tar archive in bytes.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceDockerImageSaveRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, errC := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if errC != nil {
+		return diag.FromErr(errC)
+	}
+
+	var data Data
+	if err := fetchLocalImages(ctx, &data, client); err != nil {
+		return diag.Errorf("Error reading docker image list: %s", err)
+	}
+
+	imageName := d.Get("name").(string)
+
+	foundImage, err := searchLocalImages(ctx, client, data, imageName)
+	if err != nil {
+		return diag.Errorf("dataSourceDockerImageSaveRead: error looking up local image %q: %s", imageName, err)
+	}
+	if foundImage == nil {
+		return diag.Errorf("did not find docker image '%s'", imageName)
+	}
+
+	archivePath := d.Get("path").(string)
+	size, err := saveImageToArchive(ctx, client, imageName, archivePath)
+	if err != nil {
+		return diag.Errorf("Unable to save docker image '%s' to '%s': %s", imageName, archivePath, err)
+	}
+	log.Printf("[INFO] Saved docker image '%s' with %d bytes to '%s'", imageName, size, archivePath)
+
+	d.SetId(foundImage.ID)
+	d.Set("image_id", foundImage.ID)
+	d.Set("size", size)
+
+	return nil
+}
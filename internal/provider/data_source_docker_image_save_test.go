@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccDockerImageSaveDataSource_loadWithSourceTar(t *testing.T) {
+	ctx := context.Background()
+	imageName := "busybox:1.34.0"
+	archivePath := filepath.Join(t.TempDir(), "busybox.tar")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			pullImageForTest(t, imageName)
+		},
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(loadTestConfiguration(t, DATA_SOURCE, "docker_image_save", "testAccDockerImageSaveDataSource"), archivePath),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.docker_image_save.foo", "path", archivePath),
+					resource.TestMatchResourceAttr("data.docker_image_save.foo", "image_id", contentDigestRegexp),
+					testValueHigherEqualThan("data.docker_image_save.foo", "size", 1),
+					resource.TestCheckResourceAttrPair("docker_image.foo", "image_id", "data.docker_image_save.foo", "image_id"),
+				),
+			},
+		},
+		CheckDestroy: func(state *terraform.State) error {
+			return removeImageForTest(ctx, state, imageName)
+		},
+	})
+}
+
+func TestSaveImageToArchive(t *testing.T) {
+	// a Docker host whose archive of the image 'broken' is cut off
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/images/get"):
+			if r.URL.Query().Get("names") == "broken" {
+				w.Header().Set("Content-Length", "100")
+			}
+			fmt.Fprint(w, "archive")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+	client, err := providerConfig.MakeClient(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "image.tar")
+	if _, err := saveImageToArchive(context.Background(), client, "broken", archivePath); err == nil {
+		t.Fatal("Expected the cut off archive to fail the save")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("Expected no truncated archive to be left, got %v", entries)
+	}
+
+	size, err := saveImageToArchive(context.Background(), client, "busybox:latest", archivePath)
+	if err != nil {
+		t.Fatalf("Expected the save to succeed, got %s", err)
+	}
+	if content, _ := os.ReadFile(archivePath); string(content) != "archive" || size != 7 {
+		t.Fatalf("Expected the archive to be written, got %q with size %d", content, size)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("Expected only the archive to be left, got %v", entries)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -53,6 +55,66 @@ func dataSourceDockerPlugin() *schema.Resource {
 				Computed:    true,
 				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
+			"args": {
+				Type:        schema.TypeList,
+				Description: "The arguments of the plugin",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"mounts": {
+				Type:        schema.TypeList,
+				Description: "The mounts of the plugin",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Description: "The name of the mount",
+							Computed:    true,
+						},
+						"type": {
+							Type:        schema.TypeString,
+							Description: "The type of the mount, e.g. `bind`",
+							Computed:    true,
+						},
+						"source": {
+							Type:        schema.TypeString,
+							Description: "The source of the mount on the Docker host",
+							Computed:    true,
+						},
+						"destination": {
+							Type:        schema.TypeString,
+							Description: "The destination of the mount in the plugin",
+							Computed:    true,
+						},
+						"options": {
+							Type:        schema.TypeList,
+							Description: "The options of the mount, e.g. `rbind`",
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"devices": {
+				Type:        schema.TypeList,
+				Description: "The devices of the Docker host the plugin has access to",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Description: "The name of the device",
+							Computed:    true,
+						},
+						"path": {
+							Type:        schema.TypeString,
+							Description: "The path of the device on the Docker host",
+							Computed:    true,
+						},
+					},
+				},
+			},
 		},
 	}
 }
@@ -85,9 +147,48 @@ func dataSourceDockerPluginRead(ctx context.Context, d *schema.ResourceData, met
 	}
 
 	plugin, _, err3 := client.PluginInspectWithRaw(ctx, key)
-	if err != nil {
-		return diag.Errorf("inspect a Docker plugin "+key+": %w", err3)
+	if err3 != nil {
+		if errdefs.IsNotFound(err3) {
+			return diag.Errorf("Docker plugin %s is not installed", key)
+		}
+		return diag.Errorf("inspect a Docker plugin %s: %s", key, err3)
 	}
 	setDockerPlugin(d, plugin)
+	d.Set("args", plugin.Settings.Args)
+	d.Set("mounts", flattenPluginMounts(plugin.Settings.Mounts))
+	d.Set("devices", flattenPluginDevices(plugin.Settings.Devices))
 	return nil
 }
+
+func flattenPluginMounts(in []types.PluginMount) []interface{} {
+	mounts := make([]interface{}, len(in))
+	for i, mount := range in {
+		source := ""
+		if mount.Source != nil {
+			source = *mount.Source
+		}
+		mounts[i] = map[string]interface{}{
+			"name":        mount.Name,
+			"type":        mount.Type,
+			"source":      source,
+			"destination": mount.Destination,
+			"options":     mount.Options,
+		}
+	}
+	return mounts
+}
+
+func flattenPluginDevices(in []types.PluginDevice) []interface{} {
+	devices := make([]interface{}, len(in))
+	for i, device := range in {
+		path := ""
+		if device.Path != nil {
+			path = *device.Path
+		}
+		devices[i] = map[string]interface{}{
+			"name": device.Name,
+			"path": path,
+		}
+	}
+	return devices
+}
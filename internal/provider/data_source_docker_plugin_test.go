@@ -2,6 +2,7 @@ package provider
 
 import (
 	"os/exec"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -26,8 +27,22 @@ func TestAccDockerPluginDataSource_basic(t *testing.T) {
 				Config: loadTestConfiguration(t, DATA_SOURCE, "docker_plugin", "testAccDockerPluginDataSourceBasic"),
 				Check: resource.ComposeTestCheckFunc(
 					resource.TestCheckResourceAttr("data.docker_plugin.test", "plugin_reference", "docker.io/tiborvass/sample-volume-plugin:latest"),
+					resource.TestCheckResourceAttr("data.docker_plugin.test", "enabled", "true"),
 				),
 			},
 		},
 	})
 }
+
+func TestAccDockerPluginDataSource_notInstalled(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      loadTestConfiguration(t, DATA_SOURCE, "docker_plugin", "testAccDockerPluginDataSourceNotInstalled"),
+				ExpectError: regexp.MustCompile(`Docker plugin .* is not installed`),
+			},
+		},
+	})
+}
@@ -3,7 +3,6 @@ package provider
 import (
 	"context"
 	"crypto/sha256"
-	b64 "encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -46,9 +45,23 @@ func dataSourceDockerRegistryImage() *schema.Resource {
 }
 
 func dataSourceDockerRegistryImageRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	pullOpts := parseImageOptions(d.Get("name").(string))
+	digest, err := getRegistryImageDigest(d.Get("name").(string), meta.(*ProviderConfig), d.Get("insecure_skip_verify").(bool))
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	authConfig, err := getAuthConfigForRegistry(pullOpts.Registry, meta.(*ProviderConfig))
+	d.SetId(digest)
+	d.Set("sha256_digest", digest)
+
+	return nil
+}
+
+// getRegistryImageDigest returns the digest of the manifest, or the manifest list, of an
+// image in its registry.
+func getRegistryImageDigest(name string, providerConfig *ProviderConfig, insecureSkipVerify bool) (string, error) {
+	pullOpts := parseImageOptions(name)
+
+	authConfig, err := getAuthConfigForRegistry(pullOpts.Registry, providerConfig)
 	if err != nil {
 		// The user did not provide a credential for this registry.
 		// But there are many registries where you can pull without a credential.
@@ -58,19 +71,14 @@ func dataSourceDockerRegistryImageRead(ctx context.Context, d *schema.ResourceDa
 		authConfig.ServerAddress = "https://" + pullOpts.Registry
 	}
 
-	insecureSkipVerify := d.Get("insecure_skip_verify").(bool)
 	digest, err := getImageDigest(pullOpts.Registry, authConfig.ServerAddress, pullOpts.Repository, pullOpts.Tag, authConfig.Username, authConfig.Password, insecureSkipVerify, false)
 	if err != nil {
 		digest, err = getImageDigest(pullOpts.Registry, authConfig.ServerAddress, pullOpts.Repository, pullOpts.Tag, authConfig.Username, authConfig.Password, insecureSkipVerify, true)
 		if err != nil {
-			return diag.Errorf("Got error when attempting to fetch image version %s:%s from registry: %s", pullOpts.Repository, pullOpts.Tag, err)
+			return "", fmt.Errorf("Got error when attempting to fetch image version %s:%s from registry: %s", pullOpts.Repository, pullOpts.Tag, err)
 		}
 	}
-
-	d.SetId(digest)
-	d.Set("sha256_digest", digest)
-
-	return nil
+	return digest, nil
 }
 
 func getImageDigest(registry string, registryWithProtocol string, image, tag, username, password string, insecureSkipVerify, fallback bool) (string, error) {
@@ -81,18 +89,7 @@ func getImageDigest(registry string, registryWithProtocol string, image, tag, us
 		return "", fmt.Errorf("Error creating registry request: %s", err)
 	}
 
-	if username != "" {
-		if registry != "ghcr.io" && !isECRRepositoryURL(registry) && !isAzureCRRepositoryURL(registry) && registry != "gcr.io" {
-			req.SetBasicAuth(username, password)
-		} else {
-			if isECRRepositoryURL(registry) {
-				password = normalizeECRPasswordForHTTPUsage(password)
-				req.Header.Add("Authorization", "Basic "+password)
-			} else {
-				req.Header.Add("Authorization", "Bearer "+b64.StdEncoding.EncodeToString([]byte(password)))
-			}
-		}
-	}
+	setRegistryAuthHeader(req, registry, username, password)
 
 	setupHTTPHeadersForRegistryRequests(req, fallback)
 
@@ -149,6 +146,9 @@ func getImageDigest(registry string, registryWithProtocol string, image, tag, us
 type TokenResponse struct {
 	Token       string
 	AccessToken string `json:"access_token"`
+	// ExpiresIn is the lifetime of the token in seconds, if the registry returns it
+	ExpiresIn int    `json:"expires_in"`
+	IssuedAt  string `json:"issued_at"`
 }
 
 // Parses key/value pairs from a WWW-Authenticate header
@@ -184,12 +184,25 @@ func getDigestFromResponse(response *http.Response) (string, error) {
 
 func getAuthToken(authHeader string, username string, password string, client *http.Client) (string, error) {
 	auth := parseAuthHeader(authHeader)
+	token, err := requestAuthToken(auth["realm"], auth["service"], []string{auth["scope"]}, username, password, client)
+	if err != nil {
+		return "", err
+	}
+	return token.Token, nil
+}
+
+// requestAuthToken requests a bearer token for the scopes from the token service
+// of a registry. The token of the response is set to its access token if the
+// service only returns the latter.
+func requestAuthToken(realm, service string, scopes []string, username, password string, client *http.Client) (*TokenResponse, error) {
 	params := url.Values{}
-	params.Set("service", auth["service"])
-	params.Set("scope", auth["scope"])
-	tokenRequest, err := http.NewRequest("GET", auth["realm"]+"?"+params.Encode(), nil)
+	params.Set("service", service)
+	for _, scope := range scopes {
+		params.Add("scope", scope)
+	}
+	tokenRequest, err := http.NewRequest("GET", realm+"?"+params.Encode(), nil)
 	if err != nil {
-		return "", fmt.Errorf("Error creating registry request: %s", err)
+		return nil, fmt.Errorf("Error creating registry request: %s", err)
 	}
 
 	if username != "" {
@@ -198,33 +211,32 @@ func getAuthToken(authHeader string, username string, password string, client *h
 
 	tokenResponse, err := client.Do(tokenRequest)
 	if err != nil {
-		return "", fmt.Errorf("Error during registry request: %s", err)
+		return nil, fmt.Errorf("Error during registry request: %s", err)
 	}
+	defer tokenResponse.Body.Close()
 
 	if tokenResponse.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Got bad response from registry: " + tokenResponse.Status)
+		return nil, fmt.Errorf("Got bad response from registry: " + tokenResponse.Status)
 	}
 
 	body, err := io.ReadAll(tokenResponse.Body)
 	if err != nil {
-		return "", fmt.Errorf("Error reading response body: %s", err)
+		return nil, fmt.Errorf("Error reading response body: %s", err)
 	}
 
 	token := &TokenResponse{}
 	err = json.Unmarshal(body, token)
 	if err != nil {
-		return "", fmt.Errorf("Error parsing OAuth token response: %s", err)
+		return nil, fmt.Errorf("Error parsing OAuth token response: %s", err)
 	}
 
-	if token.Token != "" {
-		return token.Token, nil
+	if token.Token == "" {
+		token.Token = token.AccessToken
 	}
-
-	if token.AccessToken != "" {
-		return token.AccessToken, nil
+	if token.Token == "" {
+		return nil, fmt.Errorf("Error unsupported OAuth response")
 	}
-
-	return "", fmt.Errorf("Error unsupported OAuth response")
+	return token, nil
 }
 
 func doDigestRequest(req *http.Request, client *http.Client) (*http.Response, error) {
@@ -0,0 +1,221 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	dockerManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+	ociImageIndexMediaType      = "application/vnd.oci.image.index.v1+json"
+)
+
+func dataSourceDockerRegistryImageManifest() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reads the manifest list of a multi-platform image from a Docker Registry and returns the digest of the image for each platform.",
+
+		ReadContext: dataSourceDockerRegistryImageManifestRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Description: "The name of the Docker image, including any tags. e.g. `alpine:latest`",
+				Required:    true,
+			},
+
+			"insecure_skip_verify": {
+				Type:        schema.TypeBool,
+				Description: "If `true`, the verification of TLS certificates of the server/registry is disabled. Defaults to `false`",
+				Optional:    true,
+				Default:     false,
+			},
+
+			"sha256_digest": {
+				Type:        schema.TypeString,
+				Description: "The content digest of the manifest list, as stored in the registry.",
+				Computed:    true,
+			},
+
+			"media_type": {
+				Type:        schema.TypeString,
+				Description: "The media type of the manifest list, either a Docker manifest list or an OCI image index.",
+				Computed:    true,
+			},
+
+			"manifests": {
+				Type:        schema.TypeList,
+				Description: "The images of the manifest list, one for each platform.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"digest": {
+							Type:        schema.TypeString,
+							Description: "The content digest of the image for this platform.",
+							Computed:    true,
+						},
+						"os": {
+							Type:        schema.TypeString,
+							Description: "The operating system of the platform, e.g. `linux`.",
+							Computed:    true,
+						},
+						"architecture": {
+							Type:        schema.TypeString,
+							Description: "The CPU architecture of the platform, e.g. `amd64`.",
+							Computed:    true,
+						},
+						"variant": {
+							Type:        schema.TypeString,
+							Description: "The variant of the CPU architecture, e.g. `v8` for `arm64`.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// registryManifestList is the common subset of a Docker manifest list and an OCI image index
+type registryManifestList struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	MediaType     string `json:"mediaType"`
+	Manifests     []struct {
+		MediaType string `json:"mediaType"`
+		Digest    string `json:"digest"`
+		Platform  struct {
+			Architecture string `json:"architecture"`
+			OS           string `json:"os"`
+			Variant      string `json:"variant"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+func dataSourceDockerRegistryImageManifestRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	pullOpts := parseImageOptions(d.Get("name").(string))
+
+	authConfig, err := getAuthConfigForRegistry(pullOpts.Registry, meta.(*ProviderConfig))
+	if err != nil {
+		// The user did not provide a credential for this registry.
+		// But there are many registries where you can pull without a credential.
+		// We are setting default values for the authConfig here.
+		authConfig.Username = ""
+		authConfig.Password = ""
+		authConfig.ServerAddress = "https://" + pullOpts.Registry
+	}
+
+	insecureSkipVerify := d.Get("insecure_skip_verify").(bool)
+	resp, err := getImageManifest(pullOpts.Registry, authConfig.ServerAddress, pullOpts.Repository, pullOpts.Tag, authConfig.Username, authConfig.Password, insecureSkipVerify)
+	if err != nil {
+		return diag.Errorf("Got error when attempting to fetch manifest of image %s:%s from registry: %s", pullOpts.Repository, pullOpts.Tag, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return diag.Errorf("Error reading registry response body: %s", err)
+	}
+
+	manifestList, err := parseManifestList(resp.Header.Get("Content-Type"), body)
+	if err != nil {
+		return diag.Errorf("Image %s:%s: %s", pullOpts.Repository, pullOpts.Tag, err)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+	}
+
+	d.SetId(digest)
+	d.Set("sha256_digest", digest)
+	d.Set("media_type", manifestList.MediaType)
+	d.Set("manifests", flattenManifestList(manifestList))
+
+	return nil
+}
+
+func getImageManifest(registry string, registryWithProtocol string, image, tag, username, password string, insecureSkipVerify bool) (*http.Response, error) {
+	client := buildHttpClientForRegistry(registryWithProtocol, insecureSkipVerify)
+
+	req, err := http.NewRequest("GET", registryWithProtocol+"/v2/"+image+"/manifests/"+tag, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating registry request: %s", err)
+	}
+
+	setupHTTPHeadersForRegistryRequests(req, false)
+	return doRegistryRequest(req, registry, username, password, client)
+}
+
+// doRegistryRequest sends a request to the registry API with the credentials, and
+// repeats it with a bearer token if the registry asks for one.
+func doRegistryRequest(req *http.Request, registry, username, password string, client *http.Client) (*http.Response, error) {
+	setRegistryAuthHeader(req, registry, username, password)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error during registry request: %s", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+
+	// Either OAuth is required or the basic auth creds were invalid
+	case http.StatusUnauthorized:
+		resp.Body.Close()
+		if !strings.HasPrefix(resp.Header.Get("www-authenticate"), "Bearer") {
+			return nil, fmt.Errorf("Bad credentials: " + resp.Status)
+		}
+
+		token, err := getAuthToken(resp.Header.Get("www-authenticate"), username, password, client)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+		return doDigestRequest(req, client)
+
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("Got bad response from registry: " + resp.Status)
+	}
+}
+
+// parseManifestList parses the body of a manifest response. The media type is taken from
+// the Content-Type header if the body does not contain it, which is optional for OCI indexes.
+func parseManifestList(contentType string, body []byte) (*registryManifestList, error) {
+	manifestList := &registryManifestList{}
+	if err := json.Unmarshal(body, manifestList); err != nil {
+		return nil, fmt.Errorf("Error parsing manifest: %s", err)
+	}
+
+	if manifestList.MediaType == "" {
+		manifestList.MediaType = strings.TrimSpace(strings.Split(contentType, ";")[0])
+	}
+
+	if manifestList.MediaType != dockerManifestListMediaType && manifestList.MediaType != ociImageIndexMediaType {
+		return nil, fmt.Errorf("is a single-platform image with manifest of type '%s' and has no manifest list", manifestList.MediaType)
+	}
+
+	return manifestList, nil
+}
+
+func flattenManifestList(manifestList *registryManifestList) []interface{} {
+	out := make([]interface{}, 0, len(manifestList.Manifests))
+	for _, manifest := range manifestList.Manifests {
+		out = append(out, map[string]interface{}{
+			"digest":       manifest.Digest,
+			"os":           manifest.Platform.OS,
+			"architecture": manifest.Platform.Architecture,
+			"variant":      manifest.Platform.Variant,
+		})
+	}
+	return out
+}
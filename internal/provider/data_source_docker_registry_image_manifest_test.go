@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDockerRegistryImageManifest_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: loadTestConfiguration(t, DATA_SOURCE, "docker_registry_image_manifest", "testAccDockerRegistryImageManifestDataSourceConfig"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr("data.docker_registry_image_manifest.foo", "sha256_digest", registryDigestRegexp),
+					resource.TestMatchResourceAttr("data.docker_registry_image_manifest.foo", "media_type", regexp.MustCompile(`manifest\.list|image\.index`)),
+					resource.TestMatchResourceAttr("data.docker_registry_image_manifest.foo", "manifests.0.digest", registryDigestRegexp),
+					resource.TestCheckTypeSetElemNestedAttrs("data.docker_registry_image_manifest.foo", "manifests.*", map[string]string{
+						"os":           "linux",
+						"architecture": "amd64",
+					}),
+				),
+			},
+		},
+	})
+}
+
+func TestParseManifestList(t *testing.T) {
+	dockerManifestList := `{
+		"schemaVersion": 2,
+		"mediaType": "application/vnd.docker.distribution.manifest.list.v2+json",
+		"manifests": [
+			{"digest": "sha256:aaaa", "platform": {"architecture": "amd64", "os": "linux"}},
+			{"digest": "sha256:bbbb", "platform": {"architecture": "arm", "os": "linux", "variant": "v7"}}
+		]
+	}`
+	manifestList, err := parseManifestList("application/vnd.docker.distribution.manifest.list.v2+json", []byte(dockerManifestList))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	manifests := flattenManifestList(manifestList)
+	if len(manifests) != 2 {
+		t.Fatalf("expected 2 manifests, got %d", len(manifests))
+	}
+	if variant := manifests[1].(map[string]interface{})["variant"]; variant != "v7" {
+		t.Errorf("expected variant v7, got %v", variant)
+	}
+
+	// the media type is optional in an OCI index
+	ociIndex := `{"schemaVersion": 2, "manifests": [{"digest": "sha256:cccc", "platform": {"architecture": "arm64", "os": "linux"}}]}`
+	manifestList, err = parseManifestList("application/vnd.oci.image.index.v1+json; charset=utf-8", []byte(ociIndex))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if manifestList.MediaType != ociImageIndexMediaType {
+		t.Errorf("expected media type %s, got %s", ociImageIndexMediaType, manifestList.MediaType)
+	}
+
+	singleManifest := `{"schemaVersion": 2, "mediaType": "application/vnd.docker.distribution.manifest.v2+json", "layers": []}`
+	if _, err := parseManifestList("application/vnd.docker.distribution.manifest.v2+json", []byte(singleManifest)); err == nil {
+		t.Fatalf("expected an error for a single-platform image")
+	}
+}
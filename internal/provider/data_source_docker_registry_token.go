@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceDockerRegistryToken() *schema.Resource {
+	return &schema.Resource{
+		Description: "Logs in to a Docker registry and returns its tokens, e.g. to hand an authenticated session to kaniko or buildah running in the same plan. The tokens are read again on each plan.",
+
+		ReadContext: dataSourceDockerRegistryTokenRead,
+
+		Schema: map[string]*schema.Schema{
+			"override": overrideSchema,
+
+			"address": {
+				Type:        schema.TypeString,
+				Description: "The address of the registry, e.g. `registry.example.com:5000`. Insecure registries which are configured in the Docker daemon are addressed with `http://`.",
+				Required:    true,
+			},
+
+			"username": {
+				Type:         schema.TypeString,
+				Description:  "The username for the registry. Defaults to the username of the `registry_auth` of the provider for the registry.",
+				Optional:     true,
+				RequiredWith: []string{"password"},
+			},
+
+			"password": {
+				Type:         schema.TypeString,
+				Description:  "The password for the registry. Defaults to the password of the `registry_auth` of the provider for the registry.",
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"username"},
+			},
+
+			"scopes": {
+				Type:        schema.TypeList,
+				Description: "The scopes of the bearer token, e.g. `repository:team/app:pull,push`.",
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+
+			"insecure_skip_verify": {
+				Type:        schema.TypeBool,
+				Description: "If `true`, the verification of TLS certificates of the registry is disabled when the bearer token is requested. Defaults to `false`",
+				Optional:    true,
+				Default:     false,
+			},
+
+			"server_address": {
+				Type:        schema.TypeString,
+				Description: "The normalized address of the registry.",
+				Computed:    true,
+			},
+
+			"status": {
+				Type:        schema.TypeString,
+				Description: "The status of the login returned by the Docker daemon, e.g. `Login Succeeded`.",
+				Computed:    true,
+			},
+
+			"identity_token": {
+				Type:        schema.TypeString,
+				Description: "The identity token of the login, which can be used instead of the password. Empty if the registry does not issue identity tokens.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+
+			"token": {
+				Type:        schema.TypeString,
+				Description: "The bearer token for the `scopes` from the token service of the registry. Empty if the registry does not use token authentication.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+
+			"expires_at": {
+				Type:        schema.TypeString,
+				Description: "The time the bearer token expires in RFC3339 format. Empty if the registry does not return the lifetime of the token.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceDockerRegistryTokenRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*ProviderConfig)
+	client, errC := providerConfig.MakeClient(ctx, d)
+	if errC != nil {
+		return diag.FromErr(errC)
+	}
+
+	// the address keeps an explicit http:// so the daemon tries an insecure registry
+	serverAddress := NormalizeRegistryAddress(d.Get("address").(string))
+	registry := convertToHostname(serverAddress)
+	authConfig := types.AuthConfig{
+		ServerAddress: serverAddress,
+		Username:      d.Get("username").(string),
+		Password:      d.Get("password").(string),
+	}
+	if authConfig.Username == "" {
+		configured, ok := providerConfig.AuthConfigs.Get(registry)
+		if !ok || configured.Username == "" {
+			return diag.Errorf("There are no credentials for the registry %s, set the username and password or a registry_auth of the provider", registry)
+		}
+		authConfig.Username = configured.Username
+		authConfig.Password = configured.Password
+	}
+
+	login, err := client.RegistryLogin(ctx, authConfig)
+	if err != nil {
+		return diag.Errorf("Unable to log in to registry %s: %s", registry, err)
+	}
+
+	scopes := stringListToStringSlice(d.Get("scopes").([]interface{}))
+	httpClient := buildHttpClientForRegistry(serverAddress, d.Get("insecure_skip_verify").(bool))
+	token, err := requestRegistryBearerToken(serverAddress, scopes, authConfig.Username, authConfig.Password, httpClient)
+	if err != nil {
+		return diag.Errorf("Unable to request a token from registry %s: %s", registry, err)
+	}
+
+	d.SetId(serverAddress)
+	d.Set("server_address", serverAddress)
+	d.Set("status", login.Status)
+	d.Set("identity_token", login.IdentityToken)
+	d.Set("token", "")
+	d.Set("expires_at", "")
+	if token != nil {
+		d.Set("token", token.Token)
+		d.Set("expires_at", registryTokenExpiry(token, time.Now()))
+	}
+
+	return nil
+}
+
+// requestRegistryBearerToken requests a bearer token from the token service the
+// registry names in its challenge. It returns nil if the registry does not
+// challenge for a bearer token, e.g. as it uses basic authentication.
+func requestRegistryBearerToken(serverAddress string, scopes []string, username, password string, client *http.Client) (*TokenResponse, error) {
+	response, err := client.Get(serverAddress + "/v2/")
+	if err != nil {
+		return nil, fmt.Errorf("Error during registry request: %s", err)
+	}
+	response.Body.Close()
+
+	challenge := response.Header.Get("www-authenticate")
+	if response.StatusCode != http.StatusUnauthorized || !strings.HasPrefix(challenge, "Bearer") {
+		return nil, nil
+	}
+	auth := parseAuthHeader(challenge)
+	return requestAuthToken(auth["realm"], auth["service"], scopes, username, password, client)
+}
+
+// registryTokenExpiry returns the time the token expires in RFC3339, based on the
+// time it was issued, or the given time if the registry does not return it.
+func registryTokenExpiry(token *TokenResponse, now time.Time) string {
+	if token.ExpiresIn <= 0 {
+		return ""
+	}
+	issuedAt, err := time.Parse(time.RFC3339, token.IssuedAt)
+	if err != nil {
+		issuedAt = now
+	}
+	return issuedAt.Add(time.Duration(token.ExpiresIn) * time.Second).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAccDockerRegistryTokenDataSource_basicAuth(t *testing.T) {
+	registry := "127.0.0.1:15000"
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(loadTestConfiguration(t, DATA_SOURCE, "docker_registry_token", "testAccDockerRegistryTokenDataSource"), registry, registry),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.docker_registry_token.foobar", "server_address", "https://"+registry),
+					resource.TestCheckResourceAttr("data.docker_registry_token.foobar", "status", "Login Succeeded"),
+					// the registry uses basic authentication
+					resource.TestCheckResourceAttr("data.docker_registry_token.foobar", "token", ""),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSourceDockerRegistryTokenRead(t *testing.T) {
+	var tokenQuery string
+	// a Docker host which is also a registry with a token service
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/auth"):
+			fmt.Fprint(w, `{"Status": "Login Succeeded", "IdentityToken": "identity"}`)
+		case r.URL.Path == "/v2/":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="registry"`, r.Host))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/token":
+			if username, password, _ := r.BasicAuth(); username != "ci" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			tokenQuery = r.URL.RawQuery
+			fmt.Fprint(w, `{"token": "bearer", "expires_in": 300, "issued_at": "2024-05-01T10:00:00Z"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	address := strings.TrimPrefix(server.URL, "http://")
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + address},
+		AuthConfigs:   &AuthConfigs{},
+	}
+
+	d := schema.TestResourceDataRaw(t, dataSourceDockerRegistryToken().Schema, map[string]interface{}{
+		"address":  "http://" + address,
+		"username": "ci",
+		"password": "secret",
+		"scopes":   []interface{}{"repository:team/app:pull,push"},
+	})
+	if diags := dataSourceDockerRegistryTokenRead(context.Background(), d, providerConfig); diags.HasError() {
+		t.Fatalf("Expected the tokens to be read, got %#v", diags)
+	}
+	if d.Get("identity_token") != "identity" || d.Get("token") != "bearer" || d.Get("expires_at") != "2024-05-01T10:05:00Z" {
+		t.Fatalf("Expected the tokens of the registry, got %#v", d.State())
+	}
+	if tokenQuery != "scope=repository%3Ateam%2Fapp%3Apull%2Cpush&service=registry" {
+		t.Fatalf("Expected the token to be requested for the scopes, got %s", tokenQuery)
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceDockerRegistryToken().Schema, map[string]interface{}{"address": address})
+	diags := dataSourceDockerRegistryTokenRead(context.Background(), d, providerConfig)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "There are no credentials for the registry") {
+		t.Fatalf("Expected an error without credentials, got %#v", diags)
+	}
+}
+
+func TestRegistryTokenExpiry(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		token    TokenResponse
+		expected string
+	}{
+		{TokenResponse{ExpiresIn: 60, IssuedAt: "2024-05-01T10:00:00+02:00"}, "2024-05-01T08:01:00Z"},
+		{TokenResponse{ExpiresIn: 60}, "2024-05-01T12:01:00Z"},
+		{TokenResponse{}, ""},
+	} {
+		if expiry := registryTokenExpiry(&tc.token, now); expiry != tc.expected {
+			t.Fatalf("Expected the expiry %q for %+v, got %q", tc.expected, tc.token, expiry)
+		}
+	}
+}
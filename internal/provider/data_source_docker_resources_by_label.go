@@ -0,0 +1,232 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceDockerResourcesByLabel() *schema.Resource {
+	return &schema.Resource{
+		Description: "Lists the volumes, networks and containers of the Docker host with the given labels at once, e.g. everything owned by a team for cleanup or reporting.",
+
+		ReadContext: dataSourceDockerResourcesByLabelRead,
+
+		Schema: map[string]*schema.Schema{
+			"override": overrideSchema,
+
+			"label_filters": {
+				Type:        schema.TypeList,
+				Description: "Only list the resources with all of these labels, either in the form `key` to match any value or `key=value`.",
+				Required:    true,
+				MinItems:    1,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+
+			"volumes": {
+				Type:        schema.TypeList,
+				Description: "The matching volumes, sorted by name.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Description: "The name of the volume.",
+							Computed:    true,
+						},
+						"driver": {
+							Type:        schema.TypeString,
+							Description: "The driver of the volume.",
+							Computed:    true,
+						},
+						"labels": {
+							Type:        schema.TypeMap,
+							Description: "The labels of the volume.",
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+
+			"networks": {
+				Type:        schema.TypeList,
+				Description: "The matching networks, sorted by name.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeString,
+							Description: "The ID of the network.",
+							Computed:    true,
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Description: "The name of the network.",
+							Computed:    true,
+						},
+						"driver": {
+							Type:        schema.TypeString,
+							Description: "The driver of the network.",
+							Computed:    true,
+						},
+						"labels": {
+							Type:        schema.TypeMap,
+							Description: "The labels of the network.",
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+
+			"containers": {
+				Type:        schema.TypeList,
+				Description: "The matching containers, including the stopped ones, sorted by name.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeString,
+							Description: "The ID of the container.",
+							Computed:    true,
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Description: "The name of the container.",
+							Computed:    true,
+						},
+						"image": {
+							Type:        schema.TypeString,
+							Description: "The image of the container.",
+							Computed:    true,
+						},
+						"state": {
+							Type:        schema.TypeString,
+							Description: "The state of the container, e.g. `running` or `exited`.",
+							Computed:    true,
+						},
+						"labels": {
+							Type:        schema.TypeMap,
+							Description: "The labels of the container.",
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceDockerResourcesByLabelRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, errC := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if errC != nil {
+		return diag.FromErr(errC)
+	}
+
+	labelFilters := stringListToStringSlice(d.Get("label_filters").([]interface{}))
+	args := buildFilters(map[string][]string{"label": labelFilters})
+
+	volumes, err := listVolumesByLabel(ctx, client, args)
+	if err != nil {
+		return diag.Errorf("Unable to list volumes: %s", err)
+	}
+	networks, err := listNetworksByLabel(ctx, client, args)
+	if err != nil {
+		return diag.Errorf("Unable to list networks: %s", err)
+	}
+	containers, err := listContainersByLabel(ctx, client, args)
+	if err != nil {
+		return diag.Errorf("Unable to list containers: %s", err)
+	}
+
+	d.SetId(resourcesByLabelID(args.Get("label")))
+	d.Set("volumes", volumes)
+	d.Set("networks", networks)
+	d.Set("containers", containers)
+
+	return nil
+}
+
+// resourcesByLabelID returns the ID of the data source for the given label filters,
+// which is the same regardless of their order.
+func resourcesByLabelID(labelFilters []string) string {
+	sorted := append([]string{}, labelFilters...)
+	sort.Strings(sorted)
+
+	hash := fnv.New64()
+	hash.Write([]byte(strings.Join(sorted, "|")))
+	return fmt.Sprintf("resources-by-label-%x", hash.Sum64())
+}
+
+func listVolumesByLabel(ctx context.Context, client *client.Client, args filters.Args) ([]interface{}, error) {
+	list, err := client.VolumeList(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list.Volumes, func(i, j int) bool { return list.Volumes[i].Name < list.Volumes[j].Name })
+
+	volumes := make([]interface{}, 0, len(list.Volumes))
+	for _, v := range list.Volumes {
+		volumes = append(volumes, map[string]interface{}{
+			"name":   v.Name,
+			"driver": v.Driver,
+			"labels": v.Labels,
+		})
+	}
+	return volumes, nil
+}
+
+func listNetworksByLabel(ctx context.Context, client *client.Client, args filters.Args) ([]interface{}, error) {
+	list, err := client.NetworkList(ctx, types.NetworkListOptions{Filters: args})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	networks := make([]interface{}, 0, len(list))
+	for _, n := range list {
+		networks = append(networks, map[string]interface{}{
+			"id":     n.ID,
+			"name":   n.Name,
+			"driver": n.Driver,
+			"labels": n.Labels,
+		})
+	}
+	return networks, nil
+}
+
+func listContainersByLabel(ctx context.Context, client *client.Client, args filters.Args) ([]interface{}, error) {
+	list, err := client.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: args})
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]interface{}, 0, len(list))
+	for _, c := range list {
+		name := ""
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		containers = append(containers, map[string]interface{}{
+			"id":     c.ID,
+			"name":   name,
+			"image":  c.Image,
+			"state":  c.State,
+			"labels": c.Labels,
+		})
+	}
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].(map[string]interface{})["name"].(string) < containers[j].(map[string]interface{})["name"].(string)
+	})
+	return containers, nil
+}
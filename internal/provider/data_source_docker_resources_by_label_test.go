@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAccDockerResourcesByLabelDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: loadTestConfiguration(t, DATA_SOURCE, "docker_resources_by_label", "testAccDockerResourcesByLabelDataSource"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.docker_resources_by_label.test", "volumes.#", "1"),
+					resource.TestCheckResourceAttr("data.docker_resources_by_label.test", "volumes.0.name", "tftest-resources-by-label"),
+					resource.TestCheckResourceAttr("data.docker_resources_by_label.test", "networks.#", "1"),
+					resource.TestCheckResourceAttr("data.docker_resources_by_label.test", "networks.0.name", "tftest-resources-by-label"),
+					resource.TestCheckResourceAttr("data.docker_resources_by_label.test", "containers.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSourceDockerResourcesByLabelRead(t *testing.T) {
+	labelFilters := map[string][]string{}
+	// a Docker host which records the label filters of the list requests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/_ping") {
+			fmt.Fprint(w, "OK")
+			return
+		}
+		args, err := filters.FromJSON(r.URL.Query().Get("filters"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		labelFilters[r.URL.Path[strings.LastIndex(r.URL.Path, "/"):]] = args.Get("label")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/volumes"):
+			fmt.Fprint(w, `{"Volumes": [{"Name": "payments-db", "Driver": "local", "Labels": {"team": "payments"}}, {"Name": "payments-cache", "Driver": "local", "Labels": {"team": "payments"}}]}`)
+		case strings.HasSuffix(r.URL.Path, "/networks"):
+			fmt.Fprint(w, `[{"Id": "net-1", "Name": "payments", "Driver": "bridge", "Labels": {"team": "payments"}}]`)
+		case strings.HasSuffix(r.URL.Path, "/containers/json"):
+			fmt.Fprint(w, `[{"Id": "c-2", "Names": ["/payments-worker"], "Image": "worker:1", "State": "exited"}, {"Id": "c-1", "Names": ["/payments-api"], "Image": "api:1", "State": "running"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+	d := schema.TestResourceDataRaw(t, dataSourceDockerResourcesByLabel().Schema, map[string]interface{}{
+		"label_filters": []interface{}{"team = payments"},
+	})
+	if diags := dataSourceDockerResourcesByLabelRead(context.Background(), d, providerConfig); diags.HasError() {
+		t.Fatalf("Expected the resources to be listed, got %#v", diags)
+	}
+
+	for path, labels := range labelFilters {
+		if len(labels) != 1 || labels[0] != "team=payments" {
+			t.Errorf("Expected the label filter 'team=payments' for %s, got %v", path, labels)
+		}
+	}
+	if len(labelFilters) != 3 {
+		t.Fatalf("Expected the volumes, networks and containers to be listed, got %v", labelFilters)
+	}
+	if d.Get("volumes.0.name") != "payments-cache" || d.Get("volumes.1.name") != "payments-db" {
+		t.Fatalf("Expected the volumes sorted by name, got %v", d.Get("volumes"))
+	}
+	if d.Get("networks.0.id") != "net-1" || d.Get("networks.0.labels.team") != "payments" {
+		t.Fatalf("Expected the network, got %v", d.Get("networks"))
+	}
+	if d.Get("containers.0.name") != "payments-api" || d.Get("containers.1.state") != "exited" {
+		t.Fatalf("Expected the containers sorted by name, got %v", d.Get("containers"))
+	}
+}
@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// diskUsageSchema is the schema of the disk usage of one kind of object, like
+// the rows of `docker system df`.
+func diskUsageSchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: description,
+		Computed:    true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"total_count": {
+					Type:        schema.TypeInt,
+					Description: "The number of objects.",
+					Computed:    true,
+				},
+				"active": {
+					Type:        schema.TypeInt,
+					Description: "The number of objects which are in use.",
+					Computed:    true,
+				},
+				"size": {
+					Type:        schema.TypeInt,
+					Description: "The disk space the objects use in bytes.",
+					Computed:    true,
+				},
+				"reclaimable": {
+					Type:        schema.TypeInt,
+					Description: "The disk space in bytes which a prune would free, as the objects are not in use.",
+					Computed:    true,
+				},
+			},
+		},
+	}
+}
+
+func dataSourceDockerSystemDF() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reads the disk usage of the Docker host like `docker system df`, e.g. to track the disk consumption of each host in outputs or for capacity planning. The usage is read again on each plan.",
+
+		ReadContext: dataSourceDockerSystemDFRead,
+
+		Schema: map[string]*schema.Schema{
+			"override": overrideSchema,
+
+			"images":      diskUsageSchema("The disk usage of the images. The size is the size of all layers, which are shared between images."),
+			"containers":  diskUsageSchema("The disk usage of the writable layers of the containers. The objects in use are the running containers."),
+			"volumes":     diskUsageSchema("The disk usage of the volumes of the `local` driver. The objects in use are the volumes of containers."),
+			"build_cache": diskUsageSchema("The disk usage of the build cache. Shared cache records are not counted in the size."),
+		},
+	}
+}
+
+func dataSourceDockerSystemDFRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*ProviderConfig)
+	config := providerConfig.getConfig(d)
+	client, errC := providerConfig.MakeClientForConfig(ctx, config)
+	if errC != nil {
+		return diag.FromErr(errC)
+	}
+
+	usage, err := client.DiskUsage(ctx)
+	if err != nil {
+		return diag.Errorf("Unable to read the disk usage of the Docker host: %s", err)
+	}
+
+	d.SetId(fmt.Sprintf("system-df-%s", config.Host))
+	d.Set("images", []interface{}{imagesDiskUsage(usage)})
+	d.Set("containers", []interface{}{containersDiskUsage(usage.Containers)})
+	d.Set("volumes", []interface{}{volumesDiskUsage(usage.Volumes)})
+	d.Set("build_cache", []interface{}{buildCacheDiskUsage(usage.BuildCache)})
+
+	return nil
+}
+
+func newDiskUsage(totalCount, active int, size, reclaimable int64) map[string]interface{} {
+	return map[string]interface{}{
+		"total_count": totalCount,
+		"active":      active,
+		"size":        int(size),
+		"reclaimable": int(reclaimable),
+	}
+}
+
+// imagesDiskUsage computes the disk usage of the images like the docker CLI: the
+// layers of images which are not used by containers are reclaimable.
+func imagesDiskUsage(usage types.DiskUsage) map[string]interface{} {
+	active := 0
+	var used int64
+	for _, image := range usage.Images {
+		if image.Containers <= 0 {
+			continue
+		}
+		active++
+		// the sizes are -1 if the daemon did not compute them
+		if image.Size != -1 && image.SharedSize != -1 {
+			used += image.Size - image.SharedSize
+		}
+	}
+	return newDiskUsage(len(usage.Images), active, usage.LayersSize, usage.LayersSize-used)
+}
+
+func containersDiskUsage(containers []*types.Container) map[string]interface{} {
+	active := 0
+	var size, reclaimable int64
+	for _, container := range containers {
+		size += container.SizeRw
+		if container.State == "running" || container.State == "paused" || container.State == "restarting" {
+			active++
+			continue
+		}
+		reclaimable += container.SizeRw
+	}
+	return newDiskUsage(len(containers), active, size, reclaimable)
+}
+
+func volumesDiskUsage(volumes []*types.Volume) map[string]interface{} {
+	active := 0
+	var size, reclaimable int64
+	for _, volume := range volumes {
+		// the usage is only known for volumes of the local driver
+		if volume.UsageData == nil || volume.UsageData.Size == -1 {
+			continue
+		}
+		size += volume.UsageData.Size
+		if volume.UsageData.RefCount > 0 {
+			active++
+			continue
+		}
+		reclaimable += volume.UsageData.Size
+	}
+	return newDiskUsage(len(volumes), active, size, reclaimable)
+}
+
+func buildCacheDiskUsage(records []*types.BuildCache) map[string]interface{} {
+	active := 0
+	var size, reclaimable int64
+	for _, record := range records {
+		if record.InUse {
+			active++
+		}
+		if record.Shared {
+			continue
+		}
+		size += record.Size
+		if !record.InUse {
+			reclaimable += record.Size
+		}
+	}
+	return newDiskUsage(len(records), active, size, reclaimable)
+}
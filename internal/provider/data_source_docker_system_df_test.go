@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAccDockerSystemDFDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `data "docker_system_df" "host" {}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.docker_system_df.host", "images.0.size"),
+					resource.TestCheckResourceAttrSet("data.docker_system_df.host", "containers.0.total_count"),
+					resource.TestCheckResourceAttrSet("data.docker_system_df.host", "volumes.0.reclaimable"),
+					resource.TestCheckResourceAttrSet("data.docker_system_df.host", "build_cache.0.active"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSourceDockerSystemDFRead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/system/df"):
+			fmt.Fprint(w, `{
+				"LayersSize": 1000,
+				"Images": [
+					{"Id": "sha256:a", "Containers": 1, "Size": 600, "SharedSize": 100},
+					{"Id": "sha256:b", "Containers": 0, "Size": 500, "SharedSize": 100}
+				],
+				"Containers": [
+					{"Id": "web", "State": "running", "SizeRw": 10},
+					{"Id": "job", "State": "exited", "SizeRw": 20}
+				],
+				"Volumes": [
+					{"Name": "data", "UsageData": {"Size": 300, "RefCount": 1}},
+					{"Name": "old", "UsageData": {"Size": 200, "RefCount": 0}},
+					{"Name": "remote", "UsageData": {"Size": -1, "RefCount": -1}}
+				],
+				"BuildCache": [
+					{"ID": "a", "InUse": true, "Size": 40},
+					{"ID": "b", "Size": 50},
+					{"ID": "c", "Shared": true, "Size": 60}
+				]
+			}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+
+	d := schema.TestResourceDataRaw(t, dataSourceDockerSystemDF().Schema, map[string]interface{}{})
+	if diags := dataSourceDockerSystemDFRead(context.Background(), d, providerConfig); diags.HasError() {
+		t.Fatalf("Expected the disk usage to be read, got %#v", diags)
+	}
+
+	expected := map[string][4]int{
+		"images":      {2, 1, 1000, 500},
+		"containers":  {2, 1, 30, 20},
+		"volumes":     {3, 1, 500, 200},
+		"build_cache": {3, 1, 90, 50},
+	}
+	for kind, values := range expected {
+		actual := [4]int{
+			d.Get(kind + ".0.total_count").(int),
+			d.Get(kind + ".0.active").(int),
+			d.Get(kind + ".0.size").(int),
+			d.Get(kind + ".0.reclaimable").(int),
+		}
+		if actual != values {
+			t.Errorf("Expected the disk usage %v of the %s, got %v", values, kind, actual)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceDockerVolumeExists() *schema.Resource {
+	return &schema.Resource{
+		Description: "Checks if a Docker volume exists. Unlike a lookup which fails for missing volumes, `exists` is `false` if there is no volume with the `name`, so other resources can depend on it, e.g. with `count = data.docker_volume_exists.legacy.exists ? 1 : 0`. Do not use it to create a `docker_volume` of the same name conditionally, as the volume then exists on the next run and gets destroyed again.",
+
+		ReadContext: dataSourceDockerVolumeExistsRead,
+
+		Schema: map[string]*schema.Schema{
+			"override": overrideSchema,
+
+			"name": {
+				Type:        schema.TypeString,
+				Description: "The name of the Docker volume.",
+				Required:    true,
+			},
+
+			"exists": {
+				Type:        schema.TypeBool,
+				Description: "If `true`, the volume exists. The other attributes are only set if it exists.",
+				Computed:    true,
+			},
+
+			"driver": {
+				Type:        schema.TypeString,
+				Description: "The driver of the volume.",
+				Computed:    true,
+			},
+
+			"driver_opts": {
+				Type:        schema.TypeMap,
+				Description: "The options of the driver of the volume.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+
+			"labels": {
+				Type:        schema.TypeMap,
+				Description: "The labels of the volume.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+
+			"mountpoint": {
+				Type:        schema.TypeString,
+				Description: "The mountpoint of the volume on the Docker host.",
+				Computed:    true,
+			},
+
+			"scope": {
+				Type:        schema.TypeString,
+				Description: "The scope of the volume, either `local` or `global`.",
+				Computed:    true,
+			},
+
+			"created_at": {
+				Type:        schema.TypeString,
+				Description: "The time the volume was created, in RFC 3339 format.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceDockerVolumeExistsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, errC := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if errC != nil {
+		return diag.FromErr(errC)
+	}
+
+	name := d.Get("name").(string)
+	volume, err := client.VolumeInspect(ctx, name)
+	if err != nil && !errdefs.IsNotFound(err) {
+		return diag.Errorf("Unable to inspect volume '%s': %s", name, err)
+	}
+
+	exists := err == nil
+	if !exists {
+		tflog.Debug(ctx, "Docker volume does not exist", map[string]interface{}{"volume": name})
+	}
+
+	d.SetId(name)
+	d.Set("exists", exists)
+	d.Set("driver", volume.Driver)
+	d.Set("driver_opts", volume.Options)
+	d.Set("labels", volume.Labels)
+	d.Set("mountpoint", volume.Mountpoint)
+	d.Set("scope", volume.Scope)
+	d.Set("created_at", formatVolumeCreatedAt(ctx, volume.CreatedAt))
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAccDockerVolumeExistsDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: loadTestConfiguration(t, DATA_SOURCE, "docker_volume_exists", "testAccDockerVolumeExistsDataSource"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.docker_volume_exists.existing", "exists", "true"),
+					resource.TestCheckResourceAttr("data.docker_volume_exists.existing", "driver", "local"),
+					resource.TestCheckResourceAttrSet("data.docker_volume_exists.existing", "mountpoint"),
+					resource.TestCheckResourceAttr("data.docker_volume_exists.missing", "exists", "false"),
+					resource.TestCheckResourceAttr("data.docker_volume_exists.missing", "driver", ""),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSourceDockerVolumeExistsRead(t *testing.T) {
+	// a Docker host which only knows the volume 'data'
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/volumes/data"):
+			fmt.Fprint(w, `{"Name": "data", "Driver": "local", "Mountpoint": "/var/lib/docker/volumes/data/_data", "Labels": {"team": "storage"}, "Scope": "local"}`)
+		case strings.HasSuffix(r.URL.Path, "/volumes/broken"):
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"message": "volume plugin is not responding"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "no such volume"}`)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+	read := func(name string) *schema.ResourceData {
+		d := schema.TestResourceDataRaw(t, dataSourceDockerVolumeExists().Schema, map[string]interface{}{"name": name})
+		if diags := dataSourceDockerVolumeExistsRead(context.Background(), d, providerConfig); diags.HasError() {
+			t.Fatalf("Expected volume %s to be read, got %#v", name, diags)
+		}
+		return d
+	}
+
+	existing := read("data")
+	if !existing.Get("exists").(bool) || existing.Get("driver") != "local" || existing.Get("labels.team") != "storage" {
+		t.Fatalf("Expected the details of the existing volume, got %#v", existing.State())
+	}
+	missing := read("missing")
+	if missing.Get("exists").(bool) || missing.Id() != "missing" || missing.Get("mountpoint") != "" {
+		t.Fatalf("Expected the volume not to exist, got %#v", missing.State())
+	}
+
+	d := schema.TestResourceDataRaw(t, dataSourceDockerVolumeExists().Schema, map[string]interface{}{"name": "broken"})
+	if diags := dataSourceDockerVolumeExistsRead(context.Background(), d, providerConfig); !diags.HasError() {
+		t.Fatal("Expected other errors than a missing volume to fail the read")
+	}
+}
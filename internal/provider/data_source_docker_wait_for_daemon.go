@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceDockerWaitForDaemon() *schema.Resource {
+	return &schema.Resource{
+		Description: "Waits until the Docker daemon of a host responds, e.g. of a virtual machine which is created in the same apply. Resources which depend on the data source are only created once the daemon is up. The connection is configured like for the other resources, so TLS and SSH work the same way.",
+
+		ReadContext: dataSourceDockerWaitForDaemonRead,
+
+		Schema: map[string]*schema.Schema{
+			"override": overrideSchema,
+
+			"host": {
+				Type:        schema.TypeString,
+				Description: "The Docker host to wait for, either the address of the daemon or the name of one of the `hosts` of the provider. Defaults to the host of `override` or of the provider.",
+				Optional:    true,
+			},
+
+			"timeout": {
+				Type:             schema.TypeString,
+				Description:      "How long to wait for the daemon, e.g. `5m`. Defaults to `5m`.",
+				Optional:         true,
+				Default:          "5m",
+				ValidateDiagFunc: validateDurationGeq0(),
+			},
+
+			"interval": {
+				Type:             schema.TypeString,
+				Description:      "How long to wait between the attempts to reach the daemon, e.g. `5s`. Defaults to `5s`.",
+				Optional:         true,
+				Default:          "5s",
+				ValidateDiagFunc: validateDurationGeq0(),
+			},
+
+			"fail_on_timeout": {
+				Type:        schema.TypeBool,
+				Description: "If `true`, reading the data source fails if the daemon is not reachable within the `timeout`. If `false`, `ready` is `false` instead. Defaults to `true`.",
+				Optional:    true,
+				Default:     true,
+			},
+
+			"ready": {
+				Type:        schema.TypeBool,
+				Description: "If `true`, the daemon responded within the `timeout`.",
+				Computed:    true,
+			},
+
+			"elapsed_seconds": {
+				Type:        schema.TypeInt,
+				Description: "The number of seconds it took until the daemon responded.",
+				Computed:    true,
+			},
+
+			"api_version": {
+				Type:        schema.TypeString,
+				Description: "The API version of the daemon.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceDockerWaitForDaemonRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*ProviderConfig)
+	config := NewConfig(d)
+	if host, ok := d.GetOk("host"); ok {
+		config.Host = host.(string)
+	}
+	config = providerConfig.overrideConfig(config)
+
+	// errors of the config can't be fixed by waiting
+	if err := providerConfig.validateClientHost(config); err != nil {
+		return diag.Errorf("failed to create Docker client: %v", err)
+	}
+
+	// the values are validated by the schema
+	timeout, _ := time.ParseDuration(d.Get("timeout").(string))
+	interval, _ := time.ParseDuration(d.Get("interval").(string))
+
+	start := time.Now()
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ready := false
+	apiVersion := ""
+	for {
+		ping, err := pingDaemon(waitCtx, providerConfig, config)
+		if err == nil {
+			ready = true
+			apiVersion = ping.APIVersion
+			break
+		}
+		tflog.Debug(ctx, "Docker daemon is not reachable yet", map[string]interface{}{
+			logFieldHost: config.Host,
+			"error":      err.Error(),
+		})
+
+		select {
+		case <-waitCtx.Done():
+		case <-time.After(interval):
+			continue
+		}
+		break
+	}
+	elapsed := time.Since(start)
+
+	if !ready && d.Get("fail_on_timeout").(bool) {
+		return diag.Errorf("Docker daemon %s was not reachable within %s", config.Host, timeout)
+	}
+	tflog.Info(ctx, "Waited for Docker daemon", map[string]interface{}{
+		logFieldHost: config.Host,
+		"ready":      ready,
+		"elapsed":    elapsed.String(),
+	})
+
+	d.SetId(fmt.Sprintf("wait-for-daemon-%s", config.Host))
+	d.Set("ready", ready)
+	d.Set("elapsed_seconds", int(elapsed.Seconds()))
+	d.Set("api_version", apiVersion)
+
+	return nil
+}
+
+// pingDaemon pings the Docker host of the config. The client is created again by
+// each attempt, as creating it already fails while the daemon is down, e.g. with
+// the ping of MakeClientForConfig or the check of the ssh connection.
+func pingDaemon(ctx context.Context, providerConfig *ProviderConfig, config *Config) (types.Ping, error) {
+	client, err := providerConfig.MakeClientForConfig(ctx, config)
+	if err != nil {
+		return types.Ping{}, err
+	}
+	return client.Ping(ctx)
+}
@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAccDockerWaitForDaemonDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: loadTestConfiguration(t, DATA_SOURCE, "docker_wait_for_daemon", "testAccDockerWaitForDaemonDataSource"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.docker_wait_for_daemon.test", "ready", "true"),
+					resource.TestCheckResourceAttrSet("data.docker_wait_for_daemon.test", "api_version"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDockerWaitForDaemonDataSource_unreachable(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(loadTestConfiguration(t, DATA_SOURCE, "docker_wait_for_daemon", "testAccDockerWaitForDaemonDataSourceUnreachable"), true),
+				ExpectError: regexp.MustCompile(`Docker daemon tcp://127.0.0.1:1 was not reachable within 2s`),
+			},
+			{
+				Config: fmt.Sprintf(loadTestConfiguration(t, DATA_SOURCE, "docker_wait_for_daemon", "testAccDockerWaitForDaemonDataSourceUnreachable"), false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.docker_wait_for_daemon.test", "ready", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestDockerWaitForDaemonWaitsForPing(t *testing.T) {
+	var pings atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/_ping") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// the client falls back from HEAD to GET, so only the HEAD requests are attempts
+		attempt := pings.Load()
+		if r.Method == http.MethodHead {
+			attempt = pings.Add(1)
+		}
+		if attempt <= 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("API-Version", "1.41")
+		fmt.Fprint(w, "OK")
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://127.0.0.1:1"},
+	}
+	d := schema.TestResourceDataRaw(t, dataSourceDockerWaitForDaemon().Schema, map[string]interface{}{
+		"host":     "tcp://" + strings.TrimPrefix(server.URL, "http://"),
+		"timeout":  "10s",
+		"interval": "10ms",
+	})
+	if diags := dataSourceDockerWaitForDaemonRead(context.Background(), d, providerConfig); diags.HasError() {
+		t.Fatalf("Expected the daemon to become ready, got %v", diags)
+	}
+	if pings.Load() != 4 {
+		t.Fatalf("Expected the daemon to be pinged until it answered, got %d pings", pings.Load())
+	}
+	if d.Get("ready") != true || d.Get("api_version") != "1.41" {
+		t.Fatalf("Unexpected state %#v", d.State())
+	}
+}
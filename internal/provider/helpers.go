@@ -2,15 +2,19 @@ package provider
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/docker/docker/api/types/filters"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
+// labelToPair returns the key and value of a label without the surrounding whitespace,
+// which is not significant but would be kept by the daemon.
 func labelToPair(label map[string]interface{}) (string, string) {
-	return label["label"].(string), label["value"].(string)
+	return strings.TrimSpace(label["label"].(string)), strings.TrimSpace(label["value"].(string))
 }
 
 func labelSetToMap(labels *schema.Set) map[string]string {
@@ -26,7 +30,13 @@ func labelSetToMap(labels *schema.Set) map[string]string {
 
 func hashLabel(v interface{}) int {
 	labelMap := v.(map[string]interface{})
-	return hashStringLabel(labelMap["label"].(string))
+	return hashStringLabel(strings.TrimSpace(labelMap["label"].(string)))
+}
+
+// suppressLabelWhitespaceDiff suppresses the diff of a label key or value which only
+// differs in the whitespace around it, as the label is created without it.
+func suppressLabelWhitespaceDiff(k, old, new string, d *schema.ResourceData) bool {
+	return strings.TrimSpace(old) == strings.TrimSpace(new)
 }
 
 func hashStringLabel(str string) int {
@@ -55,19 +65,56 @@ func mapToLabelSet(labels map[string]string) *schema.Set {
 	return schema.NewSet(hashLabel, mapped)
 }
 
+// mergeDefaultLabels returns the labels with the default labels of the provider
+// added. The labels of the resource take precedence over the default labels.
+func mergeDefaultLabels(defaults, labels map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return labels
+	}
+	merged := make(map[string]string, len(defaults)+len(labels))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	return merged
+}
+
+// removeDefaultLabels returns the labels without the default labels of the
+// provider which are not declared by the resource, so they don't show up as
+// diff of the declared labels.
+func removeDefaultLabels(labels, defaults, declared map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return labels
+	}
+	removed := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if _, isDefault := defaults[k]; isDefault {
+			if _, isDeclared := declared[k]; !isDeclared {
+				continue
+			}
+		}
+		removed[k] = v
+	}
+	return removed
+}
+
 var labelSchema = &schema.Resource{
 	Schema: map[string]*schema.Schema{
 		"label": {
-			Type:        schema.TypeString,
-			Description: "Name of the label",
-			Required:    true,
-			ForceNew:    true,
+			Type:             schema.TypeString,
+			Description:      "Name of the label",
+			Required:         true,
+			ForceNew:         true,
+			DiffSuppressFunc: suppressLabelWhitespaceDiff,
 		},
 		"value": {
-			Type:        schema.TypeString,
-			Description: "Value of the label",
-			Required:    true,
-			ForceNew:    true,
+			Type:             schema.TypeString,
+			Description:      "Value of the label",
+			Required:         true,
+			ForceNew:         true,
+			DiffSuppressFunc: suppressLabelWhitespaceDiff,
 		},
 	},
 }
@@ -134,3 +181,42 @@ func containsIgnorableErrorMessage(errorMsg string, ignorableErrorMessages ...st
 
 	return false
 }
+
+// buildFilters translates the given filter values, e.g. from the attributes of a data source,
+// into the filter arguments of the Docker API. Empty values are skipped. Values of the
+// 'label' filter are accepted in the 'key=value' and the 'key' form, the latter matches
+// any value of the label.
+func buildFilters(values map[string][]string) filters.Args {
+	args := filters.NewArgs()
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range values[key] {
+			if key == "label" {
+				value = normalizeLabelFilterValue(value)
+			}
+			if strings.TrimSpace(value) == "" {
+				continue
+			}
+			args.Add(key, value)
+		}
+	}
+
+	return args
+}
+
+// normalizeLabelFilterValue strips the whitespace around the key and the value
+// of a 'label' filter, as the daemon would compare it as part of the label.
+func normalizeLabelFilterValue(value string) string {
+	key, labelValue, hasValue := strings.Cut(value, "=")
+	key = strings.TrimSpace(key)
+	if !hasValue || key == "" {
+		return key
+	}
+	return key + "=" + strings.TrimSpace(labelValue)
+}
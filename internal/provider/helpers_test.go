@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestBuildFilters(t *testing.T) {
+	args := buildFilters(map[string][]string{
+		"name":   {"foo"},
+		"driver": {"local", ""},
+		"label": {
+			"com.example.team=backend",
+			" com.example.env = prod ",
+			"com.example.managed",
+			"com.example.empty=",
+			"=value",
+			"",
+		},
+		"dangling": {},
+	})
+
+	if args.Len() != 3 {
+		t.Fatalf("Expected 3 filter keys, got %d: %v", args.Len(), args.Keys())
+	}
+
+	expected := map[string][]string{
+		"name":   {"foo"},
+		"driver": {"local"},
+		"label": {
+			"com.example.empty=",
+			"com.example.env=prod",
+			"com.example.managed",
+			"com.example.team=backend",
+		},
+	}
+	for key, expectedValues := range expected {
+		values := args.Get(key)
+		sort.Strings(values)
+		if !reflect.DeepEqual(values, expectedValues) {
+			t.Fatalf("Expected %v for filter '%s', got %v", expectedValues, key, values)
+		}
+	}
+
+	if !args.ExactMatch("name", "foo") || args.ExactMatch("name", "bar") {
+		t.Fatal("Expected the name filter to match exactly 'foo'")
+	}
+}
+
+func TestDefaultLabels(t *testing.T) {
+	defaults := map[string]string{"owner": "platform", "cost-center": "1234"}
+	declared := map[string]string{"owner": "data", "app": "db"}
+
+	merged := mergeDefaultLabels(defaults, declared)
+	expected := map[string]string{"owner": "data", "cost-center": "1234", "app": "db"}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Fatalf("Expected merged labels %v, got %v", expected, merged)
+	}
+	if !reflect.DeepEqual(removeDefaultLabels(merged, defaults, declared), declared) {
+		t.Fatalf("Expected the declared labels %v, got %v", declared, removeDefaultLabels(merged, defaults, declared))
+	}
+	if labels := mergeDefaultLabels(nil, declared); !reflect.DeepEqual(labels, declared) {
+		t.Fatalf("Expected the labels to be kept without default labels, got %v", labels)
+	}
+}
+
+func TestLabelWhitespace(t *testing.T) {
+	labels := schema.NewSet(hashLabel, []interface{}{
+		map[string]interface{}{"label": " com.example.team ", "value": "backend "},
+		map[string]interface{}{"label": "com.example.team", "value": "backend"},
+	})
+	if labels.Len() != 1 {
+		t.Fatalf("expected the labels to have the same hash, got %d labels", labels.Len())
+	}
+	expected := map[string]string{"com.example.team": "backend"}
+	if got := labelSetToMap(labels); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	if !suppressLabelWhitespaceDiff("", "backend", " backend\n", nil) {
+		t.Fatal("expected the whitespace around the value to be ignored")
+	}
+	if suppressLabelWhitespaceDiff("", "backend", "Backend", nil) {
+		t.Fatal("expected a different value not to be ignored")
+	}
+}
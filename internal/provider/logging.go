@@ -0,0 +1,27 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// Keys of the structured fields attached to the log entries of the provider,
+// so the output of TF_LOG can be filtered by resource and by Docker host.
+const (
+	logFieldResource = "docker_resource"
+	logFieldID       = "docker_resource_id"
+	logFieldHost     = "docker_host"
+)
+
+// logResourceContext returns a context whose log entries carry the type and,
+// once known, the ID of the resource. Log through the tflog functions with the
+// returned context, e.g. tflog.Debug(ctx, "message", map[string]interface{}{...}).
+func logResourceContext(ctx context.Context, resourceType string, d *schema.ResourceData) context.Context {
+	ctx = tflog.SetField(ctx, logFieldResource, resourceType)
+	if d != nil && d.Id() != "" {
+		ctx = tflog.SetField(ctx, logFieldID, d.Id())
+	}
+	return ctx
+}
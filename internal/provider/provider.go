@@ -6,10 +6,13 @@ import (
 	"io"
 	"log"
 	"os"
+	"os/exec"
 	"os/user"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/docker/cli/cli/config/configfile"
 	"github.com/docker/docker/api/types"
@@ -39,7 +42,7 @@ var overrideSchemaElem = &schema.Resource{
 		"host": {
 			Type:        schema.TypeString,
 			Optional:    true,
-			Description: "The Docker daemon address",
+			Description: "The Docker daemon address, or the name of one of the `hosts` of the provider",
 		},
 		"ssh_opts": {
 			Type:        schema.TypeList,
@@ -67,9 +70,53 @@ var overrideSchemaElem = &schema.Resource{
 			Optional:    true,
 			Description: "Path to directory with Docker TLS config",
 		},
+		"insecure": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Description: "If `true`, the TLS certificate of the Docker host is not verified for this resource. Client certificates are still presented if configured. Does not affect other resources.",
+		},
+		"http_headers": {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Sensitive:   true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Additional HTTP headers which are sent with each request to the Docker API, e.g. `Proxy-Authorization`. Merged with the `http_headers` of the provider.",
+		},
+		"cache_key_suffix": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "A suffix which separates the Docker client of this config from the ones of otherwise equal configs. The provider reuses one client per distinct connection config, so e.g. the resources of different tenants share a client and the `max_concurrent_requests` of their Docker host. Resources with a different suffix get their own client and their own limit of concurrent requests, at the cost of more connections to the Docker host.",
+		},
 	},
 }
 
+// hostsSchemaElem is an override block with the name the resources use as host.
+func hostsSchemaElem() *schema.Resource {
+	hostSchema := map[string]*schema.Schema{
+		"name": {
+			Type:             schema.TypeString,
+			Required:         true,
+			Description:      "The name of the host",
+			ValidateDiagFunc: validateStringMatchesPattern(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`),
+		},
+	}
+	for k, v := range overrideSchemaElem.Schema {
+		hostSchema[k] = v
+	}
+	hostSchema["host"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "The Docker daemon address",
+	}
+	hostSchema["api_version"] = &schema.Schema{
+		Type:             schema.TypeString,
+		Optional:         true,
+		ValidateDiagFunc: validateStringMatchesPattern(`^1\.\d+$`),
+		Description:      "The Docker API version for this host, e.g. `1.40` for an older daemon. Takes precedence over the `api_version` of the provider.",
+	}
+	return &schema.Resource{Schema: hostSchema}
+}
+
 var overrideSchema = &schema.Schema{
 	Type:        schema.TypeList,
 	Description: "Override Provider config",
@@ -97,6 +144,12 @@ func New(version string) func() *schema.Provider {
 					},
 					Description: "The Docker daemon address",
 				},
+				"docker_context": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
+					Description:      "The name of a context of the docker CLI, e.g. created with `docker context create`. The Docker host, its TLS certificates and whether the certificate of the host is verified are taken from the docker endpoint of the context instead of `host` and `cert_path`. The contexts are read from the `contexts` directory of `DOCKER_CONFIG` or `~/.docker`. The context `default` uses the `host` of the provider.",
+				},
 				"ssh_opts": {
 					Type:     schema.TypeList,
 					Optional: true,
@@ -110,6 +163,36 @@ func New(version string) func() *schema.Provider {
 					},
 					Description: "Additional SSH option flags to be appended when using `ssh://` protocol",
 				},
+				"ssh_known_hosts_file": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
+					Description:      "Path to the known_hosts file used to verify the host key of the Docker host when using `ssh://` protocol. Translates to `-o UserKnownHostsFile=<path>`.",
+				},
+				"ssh_strict_host_key_checking": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					ValidateDiagFunc: validateStringMatchesPattern(`^(yes|accept-new|no)$`),
+					Description:      "How the host key of the Docker host is verified when using `ssh://` protocol, one of `yes`, `accept-new` or `no`. Translates to `-o StrictHostKeyChecking=<value>`. `no` accepts any host key and makes the connection vulnerable to man-in-the-middle attacks, so it cannot be combined with `ssh_known_hosts_file`.",
+				},
+				"ssh_connect_timeout": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					Default:          "30s",
+					ValidateDiagFunc: validateDurationGeq0(),
+					Description:      "How long to wait for the ssh connection to the Docker host when using `ssh://` protocol, e.g. `30s`. The connection is checked before the first request, so failures of ssh, e.g. a rejected key, are reported as such. `0s` means no timeout. Defaults to `30s`.",
+				},
+				"ssh_binary": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "The ssh executable which is used when using `ssh://` protocol, either a path or a name which is looked up in the `PATH`. Defaults to `ssh`.",
+				},
+				"ssh_env": {
+					Type:        schema.TypeMap,
+					Optional:    true,
+					Description: "Additional environment variables of the ssh executable when using `ssh://` protocol, e.g. `SSH_AUTH_SOCK` to use a specific ssh agent. Not supported on Windows.",
+					Elem:        &schema.Schema{Type: schema.TypeString},
+				},
 				"ca_material": {
 					Type:        schema.TypeString,
 					Optional:    true,
@@ -134,6 +217,32 @@ func New(version string) func() *schema.Provider {
 					DefaultFunc: schema.EnvDefaultFunc("DOCKER_CERT_PATH", ""),
 					Description: "Path to directory with Docker TLS config",
 				},
+				"http_headers": {
+					Type:        schema.TypeMap,
+					Optional:    true,
+					Sensitive:   true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+					Description: "Additional HTTP headers which are sent with each request to the Docker API, e.g. `Proxy-Authorization` or an `Authorization` bearer token of an API gateway in front of the Docker host.",
+				},
+				"ca_append_system": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "If `true`, the CA of `ca_material` or `cert_path` is trusted besides the CAs of the system trust store, e.g. if a proxy in front of the Docker host uses a certificate of a public CA. Otherwise only the CA is trusted. Defaults to `false`.",
+				},
+				"reload_certs": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "If `true`, the client certificate and key are read again from the `cert_path` for each connection to a Docker host, so certificates which are rotated during a long apply, e.g. short-lived mTLS certificates, are picked up. The certificates of `cert_material` and `key_material` can't be reloaded. Defaults to `false`.",
+				},
+
+				"hosts": {
+					Type:        schema.TypeSet,
+					Optional:    true,
+					Description: "Named Docker hosts, which resources can use by setting the name as `host` of their `override` block, e.g. `override { host = \"prod\" }`. The values which are not set are taken from the provider config, and the other values of the `override` block are applied on top.",
+					Elem:        hostsSchemaElem(),
+				},
 
 				"registry_auth": {
 					Type:     schema.TypeSet,
@@ -166,13 +275,13 @@ func New(version string) func() *schema.Provider {
 								Type:        schema.TypeString,
 								Optional:    true,
 								DefaultFunc: schema.EnvDefaultFunc("DOCKER_CONFIG", "~/.docker/config.json"),
-								Description: "Path to docker json file for registry auth. Defaults to `~/.docker/config.json`. If `DOCKER_CONFIG` is set, the value of `DOCKER_CONFIG` is used as the path. `config_file` has predencen over all other options.",
+								Description: "Path to docker json file for registry auth. Defaults to `~/.docker/config.json`. If `DOCKER_CONFIG` is set, the value of `DOCKER_CONFIG` is used as the path. `config_file` has predencen over all other options. The credentials are read again every 30 minutes, so short-lived tokens of credential helpers, e.g. for ECR, are refreshed during long applies.",
 							},
 
 							"config_file_content": {
 								Type:        schema.TypeString,
 								Optional:    true,
-								Description: "Plain content of the docker json file for registry auth. `config_file_content` has precedence over username/password.",
+								Description: "Plain content of the docker json file for registry auth. `config_file_content` has precedence over username/password. Like for `config_file`, the credentials are read again every 30 minutes.",
 							},
 							"auth_disabled": {
 								Type:        schema.TypeBool,
@@ -183,30 +292,123 @@ func New(version string) func() *schema.Provider {
 						},
 					},
 				},
+
+				"max_concurrent_requests": {
+					Type:             schema.TypeInt,
+					Optional:         true,
+					Default:          0,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(0)),
+					Description:      "The maximum number of resources and data sources which are created, read, updated or deleted on a Docker host at the same time. The others wait until one of them is done, so large applies, e.g. with `-parallelism=50`, don't overwhelm the daemon. The limit applies to each Docker host, including the `hosts` and the hosts of `override` blocks. Waits, e.g. of `wait_for` or of a `docker_task`, don't count towards the limit, and `docker_wait_for_daemon` is not limited. `0` means no limit. Defaults to `0`.",
+				},
+
+				"api_version": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					ValidateDiagFunc: validateStringMatchesPattern(`^1\.\d+$`),
+					Description:      "The Docker API version the provider uses, e.g. `1.41`. The Docker hosts must support it, older hosts are rejected with their maximum API version. The `api_version` of one of the `hosts` takes precedence. Defaults to the highest API version the provider and the Docker host support.",
+				},
+
+				"skip_ping": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "If `true`, the Docker hosts are not pinged when the provider connects to them, e.g. if an API gateway in front of the Docker host blocks the `/_ping` endpoint. Errors of the connection are then only reported by the first request. As the API version can't be negotiated without the ping, the `api_version` or `1.41` is used. Defaults to `false`.",
+				},
+
+				"api_retries": {
+					Type:             schema.TypeInt,
+					Optional:         true,
+					Default:          0,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(0)),
+					Description:      "How often the Docker API calls of volumes are retried if they fail with a transient error, e.g. a dropped connection of an ssh tunnel or an internal error of the daemon. Errors like a missing or conflicting volume are not retried. Defaults to `0`.",
+				},
+
+				"api_retry_backoff": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					Default:          "1s",
+					ValidateDiagFunc: validateDurationGeq0(),
+					Description:      "How long to wait before the first retry of a Docker API call, e.g. `1s`. The backoff is doubled for every further retry, up to `30s`. Defaults to `1s`.",
+				},
+
+				"validate_auth": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "If `true`, the credentials of each `registry_auth` block are checked with a login against the registry when the provider is configured. Failed logins are reported as warnings. Defaults to `false`.",
+				},
+
+				"default_volume_driver": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "The driver of the `docker_volume` resources which don't set a `driver`, e.g. the volume plugin of a storage backend. Defaults to the default driver of the Docker host, which is `local`.",
+				},
+
+				"default_labels": {
+					Type:        schema.TypeMap,
+					Optional:    true,
+					Description: "Labels which are added to the `docker_volume` resources, e.g. for ownership or cost tracking. The `labels` of a resource take precedence. The default labels are not part of the `labels` of the resources, but of their `all_labels`. As the labels of a volume can't be changed, changes of the default labels only apply to volumes which are created afterwards.",
+					Elem:        &schema.Schema{Type: schema.TypeString},
+				},
+
+				"registry_mirrors": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					Description: "Mirrors of Docker Hub, e.g. `mirror.example.com` or `http://mirror.example.com:5000`. Images of Docker Hub are pulled from the first mirror which is healthy, i.e. whose `/v2/` endpoint responds with the credentials of its `registry_auth` block. The mirror is checked once per apply, and images are pulled from Docker Hub if no mirror is healthy or the pull from the mirror fails. The Docker host must be able to pull from the mirrors.",
+					Elem: &schema.Schema{
+						Type:         schema.TypeString,
+						ValidateFunc: validation.StringIsNotEmpty,
+					},
+				},
+
+				"mirror_health_timeout": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					Default:          "5s",
+					ValidateDiagFunc: validateDurationGeq0(),
+					Description:      "How long to wait for the response of a registry mirror when checking its health, e.g. `5s`. Defaults to `5s`.",
+				},
 			},
 
 			ResourcesMap: map[string]*schema.Resource{
-				"docker_container":      resourceDockerContainer(),
-				"docker_image":          resourceDockerImage(),
-				"docker_registry_image": resourceDockerRegistryImage(),
-				"docker_network":        resourceDockerNetwork(),
-				"docker_volume":         resourceDockerVolume(),
-				"docker_config":         resourceDockerConfig(),
-				"docker_secret":         resourceDockerSecret(),
-				"docker_service":        resourceDockerService(),
-				"docker_plugin":         resourceDockerPlugin(),
-				"docker_tag":            resourceDockerTag(),
+				"docker_container":         resourceDockerContainer(),
+				"docker_image":             resourceDockerImage(),
+				"docker_registry_image":    resourceDockerRegistryImage(),
+				"docker_network":           resourceDockerNetwork(),
+				"docker_volume":            resourceDockerVolume(),
+				"docker_volume_backup":     resourceDockerVolumeBackup(),
+				"docker_volume_prune":      resourceDockerVolumePrune(),
+				"docker_build_cache_prune": resourceDockerBuildCachePrune(),
+				"docker_task":              resourceDockerTask(),
+				"docker_swarm":             resourceDockerSwarm(),
+				"docker_swarm_node":        resourceDockerSwarmNode(),
+				"docker_config":            resourceDockerConfig(),
+				"docker_secret":            resourceDockerSecret(),
+				"docker_service":           resourceDockerService(),
+				"docker_plugin":            resourceDockerPlugin(),
+				"docker_tag":               resourceDockerTag(),
 			},
 
 			DataSourcesMap: map[string]*schema.Resource{
-				"docker_registry_image": dataSourceDockerRegistryImage(),
-				"docker_network":        dataSourceDockerNetwork(),
-				"docker_plugin":         dataSourceDockerPlugin(),
-				"docker_image":          dataSourceDockerImage(),
-				"docker_logs":           dataSourceDockerLogs(),
+				"docker_registry_image":          dataSourceDockerRegistryImage(),
+				"docker_registry_image_manifest": dataSourceDockerRegistryImageManifest(),
+				"docker_network":                 dataSourceDockerNetwork(),
+				"docker_plugin":                  dataSourceDockerPlugin(),
+				"docker_image":                   dataSourceDockerImage(),
+				"docker_image_save":              dataSourceDockerImageSave(),
+				"docker_logs":                    dataSourceDockerLogs(),
+				"docker_client_cache":            dataSourceDockerClientCache(),
+				"docker_wait_for_daemon":         dataSourceDockerWaitForDaemon(),
+				"docker_volume_exists":           dataSourceDockerVolumeExists(),
+				"docker_resources_by_label":      dataSourceDockerResourcesByLabel(),
+				"docker_container":               dataSourceDockerContainer(),
+				"docker_registry_token":          dataSourceDockerRegistryToken(),
+				"docker_system_df":               dataSourceDockerSystemDF(),
 			},
 		}
 
+		limitConcurrentRequests(p.ResourcesMap)
+		limitConcurrentRequests(p.DataSourcesMap)
 		p.ConfigureContextFunc = configure(version, p)
 
 		return p
@@ -221,6 +423,22 @@ func configure(version string, p *schema.Provider) func(context.Context, *schema
 			SSHOpts[i] = s.(string)
 		}
 
+		SSHOpts, err := appendSSHHostKeyOpts(SSHOpts, d.Get("ssh_known_hosts_file").(string), d.Get("ssh_strict_host_key_checking").(string))
+		if err != nil {
+			return nil, diag.Errorf("Invalid SSH host key verification config: %s", err)
+		}
+
+		sshBinary := d.Get("ssh_binary").(string)
+		if sshBinary != "" {
+			if _, err := exec.LookPath(sshBinary); err != nil {
+				return nil, diag.Errorf("Invalid ssh_binary: %s", err)
+			}
+		}
+		sshEnv := mapTypeMapValsToString(d.Get("ssh_env").(map[string]interface{}))
+		if len(sshEnv) > 0 && runtime.GOOS == "windows" {
+			return nil, diag.Errorf("ssh_env is not supported on Windows")
+		}
+
 		defaultConfig := Config{
 			Host:     d.Get("host").(string),
 			SSHOpts:  SSHOpts,
@@ -229,6 +447,18 @@ func configure(version string, p *schema.Provider) func(context.Context, *schema
 			Key:      d.Get("key_material").(string),
 			CertPath: d.Get("cert_path").(string),
 		}
+		if headers := d.Get("http_headers").(map[string]interface{}); len(headers) > 0 {
+			defaultConfig.HTTPHeaders = mapTypeMapValsToString(headers)
+		}
+		if dockerContext := d.Get("docker_context").(string); dockerContext != "" && dockerContext != "default" {
+			configDir, err := dockerConfigDir()
+			if err != nil {
+				return nil, diag.Errorf("Unable to find the docker config directory: %s", err)
+			}
+			if err := applyDockerContext(&defaultConfig, configDir, dockerContext); err != nil {
+				return nil, diag.Errorf("Invalid docker_context: %s", err)
+			}
+		}
 
 		// Remove
 		/*
@@ -253,8 +483,15 @@ func configure(version string, p *schema.Provider) func(context.Context, *schema
 		//}
 		//Remove
 
+		if err := validateHostScheme(defaultConfig.Host); err != nil {
+			return nil, diag.Errorf("Invalid host: %s", err)
+		}
+		hosts, err := providerSetToHosts(d.Get("hosts").(*schema.Set))
+		if err != nil {
+			return nil, diag.Errorf("Invalid hosts: %s", err)
+		}
+
 		authConfigs := &AuthConfigs{}
-		var err error
 
 		if v, ok := d.GetOk("registry_auth"); ok {
 			authConfigs, err = providerSetToRegistryAuth(v.(*schema.Set))
@@ -268,19 +505,330 @@ func configure(version string, p *schema.Provider) func(context.Context, *schema
 			// DockerClient: client,
 			// Remove
 			DefaultConfig: &defaultConfig,
-			Hosts:         map[string]*schema.ResourceData{},
+			Hosts:         hosts,
 			AuthConfigs:   authConfigs,
 			clientCache:   sync.Map{},
+			hostLimiter:   newHostLimiter(d.Get("max_concurrent_requests").(int)),
+
+			DefaultVolumeDriver: d.Get("default_volume_driver").(string),
+			DefaultLabels:       mapTypeMapValsToString(d.Get("default_labels").(map[string]interface{})),
+			SSHBinary:           sshBinary,
+			SSHEnv:              sshEnv,
+			ReloadCerts:         d.Get("reload_certs").(bool),
+			CAAppendSystem:      d.Get("ca_append_system").(bool),
+		}
+
+		for _, mirror := range d.Get("registry_mirrors").([]interface{}) {
+			providerConfig.RegistryMirrors = append(providerConfig.RegistryMirrors, mirror.(string))
+		}
+		// the values are validated by the schema
+		providerConfig.MirrorHealthTimeout, _ = time.ParseDuration(d.Get("mirror_health_timeout").(string))
+		providerConfig.SSHConnectTimeout, _ = time.ParseDuration(d.Get("ssh_connect_timeout").(string))
+		providerConfig.APIVersion = d.Get("api_version").(string)
+		providerConfig.SkipPing = d.Get("skip_ping").(bool)
+		providerConfig.APIRetries = d.Get("api_retries").(int)
+		providerConfig.APIRetryBackoff, _ = time.ParseDuration(d.Get("api_retry_backoff").(string))
+
+		if d.Get("validate_auth").(bool) && len(authConfigs.Configs) > 0 {
+			skip := map[string]bool{}
+			if v, ok := d.GetOk("registry_auth"); ok {
+				skip = registriesWithDisabledAuth(v.(*schema.Set))
+			}
+			return &providerConfig, validateRegistryAuth(ctx, &providerConfig, skip)
 		}
 
 		return &providerConfig, nil
 	}
 }
 
+// hostLimitExempt are the resources and data sources which are not limited by
+// max_concurrent_requests. docker_wait_for_daemon only pings its host, which is
+// not necessarily the host of its override block, while waiting for a long time.
+var hostLimitExempt = map[string]bool{
+	"docker_wait_for_daemon": true,
+}
+
+// limitConcurrentRequests wraps the CRUD functions of the resources, so they wait
+// for a free slot of their Docker host before they run, see max_concurrent_requests.
+func limitConcurrentRequests(resources map[string]*schema.Resource) {
+	for name, r := range resources {
+		if hostLimitExempt[name] {
+			continue
+		}
+		r.CreateContext = withHostLimit(r.CreateContext)
+		r.ReadContext = withHostLimit(r.ReadContext)
+		r.UpdateContext = withHostLimit(r.UpdateContext)
+		r.DeleteContext = withHostLimit(r.DeleteContext)
+	}
+}
+
+func withHostLimit(f func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+	if f == nil {
+		return nil
+	}
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		providerConfig, ok := meta.(*ProviderConfig)
+		if !ok {
+			return f(ctx, d, meta)
+		}
+		key := providerConfig.getConfig(d).limiterKey()
+		release, err := providerConfig.hostLimiter.acquire(ctx, key)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		slot := &hostSlot{ctx: ctx, limiter: providerConfig.hostLimiter, key: key, release: release}
+		defer func() {
+			if slot.release != nil {
+				slot.release()
+			}
+		}()
+		return f(context.WithValue(ctx, hostSlotKey{}, slot), d, meta)
+	}
+}
+
+// hostSlotKey is the context key of the hostSlot of an operation.
+type hostSlotKey struct{}
+
+// hostSlot is the slot of the Docker host an operation holds, see withHostLimit.
+type hostSlot struct {
+	ctx     context.Context
+	limiter *hostLimiter
+	key     string
+	release func()
+}
+
+// withoutHostSlot runs f without the slot of the Docker host of the operation, so a
+// long wait, e.g. for another container, doesn't block the other operations on the
+// host, see max_concurrent_requests. The slot is acquired again afterwards.
+func withoutHostSlot(ctx context.Context, f func() error) error {
+	slot, ok := ctx.Value(hostSlotKey{}).(*hostSlot)
+	if !ok || slot.release == nil {
+		return f()
+	}
+	slot.release()
+	slot.release = nil
+
+	err := f()
+	release, acquireErr := slot.limiter.acquire(slot.ctx, slot.key)
+	if acquireErr != nil {
+		if err == nil {
+			err = acquireErr
+		}
+		return err
+	}
+	slot.release = release
+	return err
+}
+
+// providerSetToHosts returns the configs of the named hosts of the provider.
+func providerSetToHosts(hostsSet *schema.Set) (map[string]*Config, error) {
+	hosts := map[string]*Config{}
+	for _, rawHost := range hostsSet.List() {
+		name := rawHost.(map[string]interface{})["name"].(string)
+		if _, ok := hosts[name]; ok {
+			return nil, fmt.Errorf("the host %s is defined more than once", name)
+		}
+		hosts[name] = newConfigFromOverride([]interface{}{rawHost})
+		hosts[name].APIVersion = rawHost.(map[string]interface{})["api_version"].(string)
+		if hosts[name].Host != "" {
+			if err := validateHostScheme(hosts[name].Host); err != nil {
+				return nil, fmt.Errorf("the host %s is invalid: %w", name, err)
+			}
+		}
+	}
+	return hosts, nil
+}
+
+// validateRegistryAuth performs a login against each configured registry to catch
+// wrong credentials before a pull or push fails late during apply.
+// Failed logins are only returned as warnings.
+func validateRegistryAuth(ctx context.Context, providerConfig *ProviderConfig, skip map[string]bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	client, err := providerConfig.MakeClient(ctx, nil)
+	if err != nil {
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Unable to validate registry credentials",
+			Detail:   fmt.Sprintf("Failed to create Docker client: %s", err),
+		})
+	}
+
+	for _, registry := range providerConfig.AuthConfigs.Registries() {
+		if skip[registry] {
+			log.Printf("[DEBUG] Skipping validation of registry auth for %s as auth is disabled", registry)
+			continue
+		}
+
+		// The address is normalized, so registries explicitly configured with
+		// http:// are tried by the daemon as insecure registries.
+		authConfig, _ := providerConfig.AuthConfigs.Get(registry)
+		if _, err := client.RegistryLogin(ctx, authConfig); err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf("Invalid credentials for registry %s", registry),
+				Detail:   fmt.Sprintf("Login to registry %s failed: %s", authConfig.ServerAddress, err),
+			})
+			continue
+		}
+		log.Printf("[DEBUG] Validated registry auth for %s", registry)
+	}
+
+	return diags
+}
+
+// registriesWithDisabledAuth returns the hostnames of the registry_auth blocks
+// with auth_disabled, as they only carry dummy credentials.
+func registriesWithDisabledAuth(authList *schema.Set) map[string]bool {
+	disabled := map[string]bool{}
+	for _, auth := range authList.List() {
+		if auth.(map[string]interface{})["auth_disabled"].(bool) {
+			address := auth.(map[string]interface{})["address"].(string)
+			disabled[registryAuthKey(address)] = true
+		}
+	}
+	return disabled
+}
+
 // AuthConfigs represents authentication options to use for the
 // PushImage method accommodating the new X-Registry-Config header
 type AuthConfigs struct {
 	Configs map[string]types.AuthConfig `json:"configs"`
+
+	mu         sync.Mutex
+	refreshers map[string]authRefresher
+	expiries   map[string]time.Time
+	// failures holds the time of the last failed refresh of a registry,
+	// refreshing the registries whose refresh is in progress.
+	failures   map[string]time.Time
+	refreshing map[string]bool
+}
+
+// authRefresher resolves the credentials of a registry again. Credentials which
+// stem from a credential helper, e.g. for ECR or GCR, are short-lived tokens.
+type authRefresher func() (types.AuthConfig, error)
+
+const (
+	// registryAuthTTL is how long resolved credentials of a refreshable registry_auth are used.
+	// The credential helpers cache the tokens themselves, so refreshing is cheap.
+	registryAuthTTL = 30 * time.Minute
+	// registryAuthRefreshMargin is the time before the expiry at which the credentials are refreshed,
+	// so a long pull or push does not run into an expired token.
+	registryAuthRefreshMargin = 5 * time.Minute
+	// registryAuthRetryInterval is the time after a failed refresh before the credential
+	// helper is run again, so a broken helper does not slow down every pull and push.
+	registryAuthRetryInterval = time.Minute
+)
+
+// Get returns the auth config of the registry, which is looked up by its normalized
+// address. Credentials which are about to expire are refreshed first.
+func (a *AuthConfigs) Get(registry string) (types.AuthConfig, bool) {
+	registry = registryAuthKey(registry)
+	a.refresh([]string{registry}, time.Now())
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	authConfig, ok := a.Configs[registry]
+	return authConfig, ok
+}
+
+// All returns a copy of the auth configs of all registries, refreshed like with Get.
+func (a *AuthConfigs) All() map[string]types.AuthConfig {
+	a.refresh(a.Registries(), time.Now())
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	configs := make(map[string]types.AuthConfig, len(a.Configs))
+	for registry, authConfig := range a.Configs {
+		configs[registry] = authConfig
+	}
+	return configs
+}
+
+// Registries returns the sorted normalized addresses of all registries without
+// refreshing their credentials.
+func (a *AuthConfigs) Registries() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	registries := make([]string, 0, len(a.Configs))
+	for registry := range a.Configs {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+	return registries
+}
+
+// setRefreshable stores the auth config of the registry together with the refresher
+// to resolve it again after registryAuthTTL.
+func (a *AuthConfigs) setRefreshable(registry string, authConfig types.AuthConfig, refresher authRefresher, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.refreshers == nil {
+		a.refreshers = make(map[string]authRefresher)
+		a.expiries = make(map[string]time.Time)
+		a.failures = make(map[string]time.Time)
+		a.refreshing = make(map[string]bool)
+	}
+	a.Configs[registry] = authConfig
+	a.refreshers[registry] = refresher
+	a.expiries[registry] = now.Add(registryAuthTTL)
+}
+
+// refresh resolves the credentials of the given registries which are about to expire
+// again. The refreshers run without the lock held, so slow credential helpers do not
+// block the lookups of other registries. If a refresh fails, the previous credentials
+// are kept, as they might still be valid, and the refresh is retried after
+// registryAuthRetryInterval.
+func (a *AuthConfigs) refresh(registries []string, now time.Time) {
+	for _, registry := range registries {
+		refresher := a.startRefresh(registry, now)
+		if refresher == nil {
+			continue
+		}
+
+		log.Printf("[DEBUG] Refreshing registry auth for %s", registry)
+		refreshed, err := refresher()
+		a.finishRefresh(registry, refreshed, err, now)
+	}
+}
+
+// startRefresh returns the refresher of the registry if its credentials are due for
+// a refresh, which is then marked as in progress.
+func (a *AuthConfigs) startRefresh(registry string, now time.Time) authRefresher {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	refresher, ok := a.refreshers[registry]
+	if !ok || a.refreshing[registry] || now.Add(registryAuthRefreshMargin).Before(a.expiries[registry]) {
+		return nil
+	}
+	if failed, ok := a.failures[registry]; ok && now.Before(failed.Add(registryAuthRetryInterval)) {
+		return nil
+	}
+	a.refreshing[registry] = true
+	return refresher
+}
+
+// finishRefresh stores the outcome of the refresh started with startRefresh.
+func (a *AuthConfigs) finishRefresh(registry string, refreshed types.AuthConfig, err error, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.refreshing, registry)
+	if err != nil {
+		log.Printf("[WARN] Unable to refresh registry auth for %s, using the previous credentials: %s", registry, err)
+		a.failures[registry] = now
+		return
+	}
+
+	authConfig := a.Configs[registry]
+	authConfig.Username = refreshed.Username
+	authConfig.Password = refreshed.Password
+	a.Configs[registry] = authConfig
+	a.expiries[registry] = now.Add(registryAuthTTL)
+	delete(a.failures, registry)
 }
 
 // Take the given registry_auth schemas and return a map of registry auth configurations
@@ -292,7 +840,7 @@ func providerSetToRegistryAuth(authList *schema.Set) (*AuthConfigs, error) {
 	for _, auth := range authList.List() {
 		authConfig := types.AuthConfig{}
 		address := auth.(map[string]interface{})["address"].(string)
-		authConfig.ServerAddress = normalizeRegistryAddress(address)
+		authConfig.ServerAddress = NormalizeRegistryAddress(address)
 		registryHostname := convertToHostname(authConfig.ServerAddress)
 
 		username, ok := auth.(map[string]interface{})["username"].(string)
@@ -308,6 +856,7 @@ func providerSetToRegistryAuth(authList *schema.Set) (*AuthConfigs, error) {
 
 		// For each registry_auth block, generate an AuthConfiguration using either
 		// username/password or the given config file
+		var refresher authRefresher
 		if ok && username != "" {
 			log.Println("[DEBUG] Using username for registry auths:", username)
 
@@ -322,18 +871,9 @@ func providerSetToRegistryAuth(authList *schema.Set) (*AuthConfigs, error) {
 			// environment variable and to be backwards compatible
 		} else if configFileContent, ok := auth.(map[string]interface{})["config_file_content"].(string); ok && configFileContent != "" {
 			log.Println("[DEBUG] Parsing file content for registry auths:", configFileContent)
-			r := strings.NewReader(configFileContent)
-
-			c, err := loadConfigFile(r)
-			if err != nil {
-				return nil, fmt.Errorf("Error parsing docker registry config json: %v", err)
+			refresher = func() (types.AuthConfig, error) {
+				return authConfigFromConfigFileContent(configFileContent, registryHostname)
 			}
-			authFileConfig, err := c.GetAuthConfig(registryHostname)
-			if err != nil {
-				return nil, fmt.Errorf("couldn't find registry config for '%s' in file content", registryHostname)
-			}
-			authConfig.Username = authFileConfig.Username
-			authConfig.Password = authFileConfig.Password
 
 			// As last step we check if a config file path is given
 		} else if configFile, ok := auth.(map[string]interface{})["config_file"].(string); ok && configFile != "" {
@@ -349,28 +889,61 @@ func providerSetToRegistryAuth(authList *schema.Set) (*AuthConfigs, error) {
 				}
 				filePath = strings.Replace(filePath, "~", usr.HomeDir, 1)
 			}
-			r, err := os.Open(filePath)
-			if err != nil {
-				return nil, fmt.Errorf("could not open config file from filePath: %s. Error: %v", filePath, err)
-			}
-			c, err := loadConfigFile(r)
-			if err != nil {
-				return nil, fmt.Errorf("could not read and load config file: %v", err)
+			refresher = func() (types.AuthConfig, error) {
+				return authConfigFromConfigFilePath(filePath, registryHostname)
 			}
-			authFileConfig, err := c.GetAuthConfig(registryHostname)
-			if err != nil {
-				return nil, fmt.Errorf("could not get auth config (the credentialhelper did not work or was not found): %v", err)
-			}
-			authConfig.Username = authFileConfig.Username
-			authConfig.Password = authFileConfig.Password
 		}
 
-		authConfigs.Configs[registryHostname] = authConfig
+		if refresher == nil {
+			authConfigs.Configs[registryHostname] = authConfig
+			continue
+		}
+
+		// the credentials of config files may come from a credential helper and expire
+		authFileConfig, err := refresher()
+		if err != nil {
+			return nil, err
+		}
+		authConfig.Username = authFileConfig.Username
+		authConfig.Password = authFileConfig.Password
+		authConfigs.setRefreshable(registryHostname, authConfig, refresher, time.Now())
 	}
 
 	return &authConfigs, nil
 }
 
+// authConfigFromConfigFileContent reads the credentials of the registry from the content of a docker config file.
+func authConfigFromConfigFileContent(configFileContent string, registryHostname string) (types.AuthConfig, error) {
+	c, err := loadConfigFile(strings.NewReader(configFileContent))
+	if err != nil {
+		return types.AuthConfig{}, fmt.Errorf("Error parsing docker registry config json: %v", err)
+	}
+	authFileConfig, err := c.GetAuthConfig(registryHostname)
+	if err != nil {
+		return types.AuthConfig{}, fmt.Errorf("couldn't find registry config for '%s' in file content", registryHostname)
+	}
+	return types.AuthConfig{Username: authFileConfig.Username, Password: authFileConfig.Password}, nil
+}
+
+// authConfigFromConfigFilePath reads the credentials of the registry from a docker config file.
+func authConfigFromConfigFilePath(filePath string, registryHostname string) (types.AuthConfig, error) {
+	r, err := os.Open(filePath)
+	if err != nil {
+		return types.AuthConfig{}, fmt.Errorf("could not open config file from filePath: %s. Error: %v", filePath, err)
+	}
+	defer r.Close()
+
+	c, err := loadConfigFile(r)
+	if err != nil {
+		return types.AuthConfig{}, fmt.Errorf("could not read and load config file: %v", err)
+	}
+	authFileConfig, err := c.GetAuthConfig(registryHostname)
+	if err != nil {
+		return types.AuthConfig{}, fmt.Errorf("could not get auth config (the credentialhelper did not work or was not found): %v", err)
+	}
+	return types.AuthConfig{Username: authFileConfig.Username, Password: authFileConfig.Password}, nil
+}
+
 func loadConfigFile(configData io.Reader) (*configfile.ConfigFile, error) {
 	configFile := configfile.New("")
 	if err := configFile.LoadFromReader(configData); err != nil {
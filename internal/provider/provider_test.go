@@ -2,11 +2,21 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os/exec"
+	"reflect"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/docker/docker/api/types"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
@@ -45,6 +55,65 @@ func TestProvider(t *testing.T) {
 	}
 }
 
+func TestAuthConfigsRefresh(t *testing.T) {
+	authConfigs := &AuthConfigs{Configs: map[string]types.AuthConfig{
+		"registry.example.com": {ServerAddress: "https://registry.example.com", Username: "static", Password: "secret"},
+	}}
+
+	refreshes := 0
+	var refreshErr error
+	refresher := func() (types.AuthConfig, error) {
+		refreshes++
+		return types.AuthConfig{Username: "AWS", Password: fmt.Sprintf("token-%d", refreshes)}, refreshErr
+	}
+	authConfigs.setRefreshable("123456789012.dkr.ecr.eu-central-1.amazonaws.com", types.AuthConfig{
+		ServerAddress: "https://123456789012.dkr.ecr.eu-central-1.amazonaws.com",
+		Username:      "AWS",
+		Password:      "token-0",
+	}, refresher, time.Now())
+
+	authConfig, ok := authConfigs.Get("123456789012.dkr.ecr.eu-central-1.amazonaws.com")
+	if !ok || authConfig.Password != "token-0" || refreshes != 0 {
+		t.Fatalf("Expected the fresh credentials to be used without refresh, got %#v after %d refreshes", authConfig, refreshes)
+	}
+
+	// the credentials expire within the refresh margin
+	authConfigs.expiries["123456789012.dkr.ecr.eu-central-1.amazonaws.com"] = time.Now().Add(registryAuthRefreshMargin / 2)
+	authConfig, _ = authConfigs.Get("123456789012.dkr.ecr.eu-central-1.amazonaws.com")
+	if authConfig.Password != "token-1" || authConfig.ServerAddress != "https://123456789012.dkr.ecr.eu-central-1.amazonaws.com" {
+		t.Fatalf("Expected the credentials to be refreshed, got %#v", authConfig)
+	}
+
+	// a failed refresh keeps the previous credentials
+	refreshErr = errors.New("credential helper not found")
+	authConfigs.expiries["123456789012.dkr.ecr.eu-central-1.amazonaws.com"] = time.Now()
+	all := authConfigs.All()
+	if all["123456789012.dkr.ecr.eu-central-1.amazonaws.com"].Password != "token-1" {
+		t.Fatalf("Expected the previous credentials to be kept, got %#v", all)
+	}
+	if all["registry.example.com"].Password != "secret" || refreshes != 2 {
+		t.Fatalf("Expected the static credentials to be returned without refresh, got %#v after %d refreshes", all, refreshes)
+	}
+
+	// the refresh is not retried right after it failed
+	authConfigs.Get("123456789012.dkr.ecr.eu-central-1.amazonaws.com")
+	if refreshes != 2 {
+		t.Fatalf("Expected no refresh within the retry interval, got %d refreshes", refreshes)
+	}
+
+	refreshErr = nil
+	authConfigs.failures["123456789012.dkr.ecr.eu-central-1.amazonaws.com"] = time.Now().Add(-registryAuthRetryInterval)
+	authConfig, _ = authConfigs.Get("123456789012.dkr.ecr.eu-central-1.amazonaws.com")
+	if authConfig.Password != "token-3" || refreshes != 3 {
+		t.Fatalf("Expected the refresh to be retried after the retry interval, got %#v after %d refreshes", authConfig, refreshes)
+	}
+
+	registries := authConfigs.Registries()
+	if !reflect.DeepEqual(registries, []string{"123456789012.dkr.ecr.eu-central-1.amazonaws.com", "registry.example.com"}) || refreshes != 3 {
+		t.Fatalf("Expected the sorted registries without refresh, got %v after %d refreshes", registries, refreshes)
+	}
+}
+
 func TestAccDockerProvider_WithIncompleteRegistryAuth(t *testing.T) {
 	resource.Test(t, resource.TestCase{
 		PreCheck:          func() { testAccPreCheck(t) },
@@ -90,6 +159,70 @@ func TestAccDockerProvider_WithDisabledRegistryAuth(t *testing.T) {
 	})
 }
 
+func TestAccDockerProvider_WithValidatedRegistryAuth(t *testing.T) {
+	pushOptions := createPushImageOptions("127.0.0.1:15000/tftest-dockerregistryimage-testtest:1.0")
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(loadTestConfiguration(t, RESOURCE, "provider", "testAccDockerProviderValidatedRegistryAuth"), pushOptions.Registry),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.docker_registry_image.foobar", "sha256_digest"),
+				),
+			},
+		},
+	})
+}
+
+func TestValidateRegistryAuth(t *testing.T) {
+	// a Docker host which only accepts the credentials of registry.example.com
+	logins := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/auth"):
+			var authConfig types.AuthConfig
+			if err := json.NewDecoder(r.Body).Decode(&authConfig); err != nil {
+				t.Errorf("Unable to decode auth config: %s", err)
+			}
+			logins = append(logins, authConfig.ServerAddress)
+			if authConfig.ServerAddress != "https://registry.example.com" {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"message": "incorrect username or password"}`)
+				return
+			}
+			fmt.Fprint(w, `{"Status": "Login Succeeded"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+		AuthConfigs: &AuthConfigs{Configs: map[string]types.AuthConfig{
+			"registry.example.com":  {ServerAddress: "https://registry.example.com", Username: "user", Password: "secret"},
+			"wrong.example.com":     {ServerAddress: "https://wrong.example.com", Username: "user", Password: "wrong"},
+			"anonymous.example.com": {ServerAddress: "https://anonymous.example.com", Username: "username", Password: "password"},
+		}},
+	}
+
+	diags := validateRegistryAuth(context.Background(), providerConfig, map[string]bool{"anonymous.example.com": true})
+	if len(diags) != 1 {
+		t.Fatalf("Expected one diagnostic for the wrong credentials, got %#v", diags)
+	}
+	if diags[0].Severity != diag.Warning || diags[0].Summary != "Invalid credentials for registry wrong.example.com" || !strings.Contains(diags[0].Detail, "incorrect username or password") {
+		t.Fatalf("Expected a warning about the wrong credentials, got %#v", diags[0])
+	}
+	if !reflect.DeepEqual(logins, []string{"https://registry.example.com", "https://wrong.example.com"}) {
+		t.Fatalf("Expected a login to each registry with auth, got %v", logins)
+	}
+}
+
 func testAccPreCheck(t *testing.T) {
 	cmd := exec.Command("docker", "version")
 	if err := cmd.Run(); err != nil {
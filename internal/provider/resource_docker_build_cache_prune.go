@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"context"
+	"sort"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/versions"
+	"github.com/docker/go-units"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// buildCachePruneMinAPIVersion is the first API version which supports the options of
+// the prune. Older daemons prune the whole unused build cache regardless of them.
+const buildCachePruneMinAPIVersion = "1.39"
+
+func resourceDockerBuildCachePrune() *schema.Resource {
+	return &schema.Resource{
+		Description: "Removes the build cache of the Docker host, like `docker builder prune` does. The build cache is pruned when the resource is created, change the `triggers` to prune again. Requires the Docker API version 1.39 or higher. Deleting the resource only removes it from the state.",
+
+		CreateContext: resourceDockerBuildCachePruneCreate,
+		ReadContext:   resourceDockerBuildCachePruneRead,
+		DeleteContext: resourceDockerBuildCachePruneDelete,
+
+		Schema: map[string]*schema.Schema{
+			"override": {
+				Type:        schema.TypeList,
+				Description: "Override Provider config",
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Elem:        overrideSchemaElem,
+			},
+			"all": {
+				Type:        schema.TypeBool,
+				Description: "If `true`, all unused build cache is removed, not only the dangling cache. Defaults to `false`.",
+				Optional:    true,
+				Default:     false,
+				ForceNew:    true,
+			},
+			"keep_storage": {
+				Type:             schema.TypeString,
+				Description:      "The amount of build cache to keep, e.g. `10GB`. The cache which was used least recently is removed first.",
+				Optional:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validateBytesSize(),
+			},
+			"filters": {
+				Type:        schema.TypeMap,
+				Description: "Only prune the build cache matching these filters, e.g. `until = \"24h\"` or `type = \"regular\"`.",
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Description: "A map of arbitrary strings that, when changed, will force the build cache to be pruned again.",
+				Optional:    true,
+				ForceNew:    true,
+			},
+			"caches_deleted": {
+				Type:        schema.TypeList,
+				Description: "The IDs of the removed build cache records.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"space_reclaimed": {
+				Type:        schema.TypeInt,
+				Description: "The disk space reclaimed in bytes.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceDockerBuildCachePruneCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	ctx = logResourceContext(ctx, "docker_build_cache_prune", d)
+	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if err != nil {
+		return diag.Errorf("failed to create Docker client: %v", err)
+	}
+
+	client.NegotiateAPIVersion(ctx)
+	if versions.LessThan(client.ClientVersion(), buildCachePruneMinAPIVersion) {
+		return diag.Errorf("Pruning the build cache requires Docker API version %s or higher, but the Docker host supports %s", buildCachePruneMinAPIVersion, client.ClientVersion())
+	}
+
+	options := types.BuildCachePruneOptions{
+		All:     d.Get("all").(bool),
+		Filters: buildFilters(buildCachePruneFilters(d.Get("filters").(map[string]interface{}))),
+	}
+	if keepStorage := d.Get("keep_storage").(string); keepStorage != "" {
+		options.KeepStorage, err = units.RAMInBytes(keepStorage)
+		if err != nil {
+			return diag.Errorf("Invalid keep_storage %q: %s", keepStorage, err)
+		}
+	}
+
+	report, err := client.BuildCachePrune(ctx, options)
+	if err != nil {
+		return diag.Errorf("Unable to prune the build cache: %s", err)
+	}
+	cachesDeleted := report.CachesDeleted
+	sort.Strings(cachesDeleted)
+	tflog.Info(ctx, "Pruned build cache", map[string]interface{}{"caches": len(cachesDeleted), "space_reclaimed": report.SpaceReclaimed})
+
+	d.SetId(id.PrefixedUniqueId("build-cache-prune-"))
+	d.Set("caches_deleted", cachesDeleted)
+	d.Set("space_reclaimed", int(report.SpaceReclaimed))
+
+	return nil
+}
+
+func resourceDockerBuildCachePruneRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// the prune is a one-off operation, there is nothing to refresh
+	return nil
+}
+
+func resourceDockerBuildCachePruneDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
+
+func buildCachePruneFilters(values map[string]interface{}) map[string][]string {
+	pruneFilters := make(map[string][]string, len(values))
+	for key, value := range values {
+		pruneFilters[key] = []string{value.(string)}
+	}
+	return pruneFilters
+}
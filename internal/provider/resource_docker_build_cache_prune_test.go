@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestResourceDockerBuildCachePruneCreate(t *testing.T) {
+	var query string
+	newServer := func(apiVersion string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("API-Version", apiVersion)
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/_ping"):
+				fmt.Fprint(w, "OK")
+			case strings.HasSuffix(r.URL.Path, "/build/prune"):
+				query = r.URL.RawQuery
+				fmt.Fprint(w, `{"CachesDeleted": ["tz3rk", "a1b2c"], "SpaceReclaimed": 2048}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	}
+	create := func(server *httptest.Server) (*schema.ResourceData, error) {
+		providerConfig := &ProviderConfig{
+			DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+		}
+		d := schema.TestResourceDataRaw(t, resourceDockerBuildCachePrune().Schema, map[string]interface{}{
+			"all":          true,
+			"keep_storage": "1KB",
+			"filters":      map[string]interface{}{"until": "24h"},
+		})
+		if diags := resourceDockerBuildCachePruneCreate(context.Background(), d, providerConfig); diags.HasError() {
+			return d, fmt.Errorf("%s", diags[0].Summary)
+		}
+		return d, nil
+	}
+
+	server := newServer("1.41")
+	defer server.Close()
+	d, err := create(server)
+	if err != nil {
+		t.Fatalf("Expected the build cache to be pruned, got %s", err)
+	}
+	if !strings.Contains(query, "all=1") || !strings.Contains(query, "keep-storage=1024") || !strings.Contains(query, "until") {
+		t.Fatalf("Expected the options to be sent to the daemon, got %s", query)
+	}
+	if d.Get("caches_deleted.0") != "a1b2c" || d.Get("caches_deleted.1") != "tz3rk" || d.Get("space_reclaimed") != 2048 {
+		t.Fatalf("Expected the sorted IDs of the caches and the reclaimed space, got %#v", d.State())
+	}
+
+	oldServer := newServer("1.38")
+	defer oldServer.Close()
+	query = ""
+	_, err = create(oldServer)
+	if err == nil || !strings.Contains(err.Error(), "requires Docker API version 1.39 or higher, but the Docker host supports 1.38") {
+		t.Fatalf("Expected an error for the old daemon, got %v", err)
+	}
+	if query != "" {
+		t.Fatalf("Expected the build cache of the old daemon not to be pruned, got %s", query)
+	}
+}
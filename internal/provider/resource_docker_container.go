@@ -5,6 +5,7 @@ import (
 	"log"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceDockerContainer() *schema.Resource {
@@ -15,10 +16,11 @@ func resourceDockerContainer() *schema.Resource {
 		ReadContext:   resourceDockerContainerRead,
 		UpdateContext: resourceDockerContainerUpdate,
 		DeleteContext: resourceDockerContainerDelete,
+		CustomizeDiff: resourceDockerContainerCustomizeDiff,
 		MigrateState:  resourceDockerContainerMigrateState,
 		SchemaVersion: 2,
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: resourceDockerContainerImport,
 		},
 		StateUpgraders: []schema.StateUpgrader{
 			{
@@ -46,14 +48,15 @@ func resourceDockerContainer() *schema.Resource {
 
 			"rm": {
 				Type:        schema.TypeBool,
-				Description: "If `true`, then the container will be automatically removed when it exits. Defaults to `false`.",
+				Description: "If `true`, then the container will be automatically removed when it exits. If `must_run` is `false` too, the container is kept in the state once the daemon removed it, so one-off containers are not created again. Defaults to `false`.",
 				Default:     false,
 				Optional:    true,
+				ForceNew:    true,
 			},
 
 			"read_only": {
 				Type:        schema.TypeBool,
-				Description: "If `true`, the container will be started as readonly. Defaults to `false`.",
+				Description: "If `true`, the root filesystem of the container is mounted read-only, so the container can only write to its volumes, mounts and `tmpfs`. Defaults to `false`.",
 				Default:     false,
 				Optional:    true,
 				ForceNew:    true,
@@ -80,6 +83,92 @@ func resourceDockerContainer() *schema.Resource {
 				Optional:    true,
 			},
 
+			"wait_for_port": {
+				Type:        schema.TypeList,
+				Description: "Waits after the start of the container until a port of the container accepts connections. The published host port is dialed if the port is published, otherwise the IP address of the container. Useful for images without a `HEALTHCHECK`.",
+				Optional:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"port": {
+							Type:             schema.TypeInt,
+							Description:      "The port inside the container.",
+							Required:         true,
+							ValidateDiagFunc: validation.ToDiagFunc(validation.IsPortNumber),
+						},
+						"protocol": {
+							Type:             schema.TypeString,
+							Description:      "How the port is checked, either `tcp` to wait for accepted connections or `http` to wait for a response with the expected status code. Defaults to `tcp`.",
+							Default:          "tcp",
+							Optional:         true,
+							ValidateDiagFunc: validateStringMatchesPattern(`^(tcp|http)$`),
+						},
+						"path": {
+							Type:        schema.TypeString,
+							Description: "The path requested if the `protocol` is `http`. Defaults to `/`.",
+							Default:     "/",
+							Optional:    true,
+						},
+						"status_code": {
+							Type:             schema.TypeInt,
+							Description:      "The expected status code if the `protocol` is `http`. Any `2xx` status code is accepted if not set.",
+							Optional:         true,
+							ValidateDiagFunc: validateIntegerGeqThan(100),
+						},
+						"timeout": {
+							Type:             schema.TypeInt,
+							Description:      "The timeout in seconds to wait for the port. Defaults to `60`.",
+							Default:          60,
+							Optional:         true,
+							ValidateDiagFunc: validateIntegerGeqThan(1),
+						},
+						"interval": {
+							Type:             schema.TypeInt,
+							Description:      "The interval in seconds between two checks of the port. Defaults to `1`.",
+							Default:          1,
+							Optional:         true,
+							ValidateDiagFunc: validateIntegerGeqThan(1),
+						},
+					},
+				},
+			},
+
+			"wait_for": {
+				Type:        schema.TypeList,
+				Description: "Waits before the start of the container until other containers are healthy or running, e.g. until its database accepts connections. Unlike `depends_on`, which only waits until the other containers are created, the state of the containers is polled. The containers are waited for in the given order.",
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"container": {
+							Type:        schema.TypeString,
+							Description: "The ID or name of the container, e.g. `docker_container.db.id`.",
+							Required:    true,
+						},
+						"condition": {
+							Type:             schema.TypeString,
+							Description:      "The state the container has to be in, either `healthy` to wait until its health check passes or `running`. Containers without a health check can only be waited for until they are `running`. Defaults to `healthy`.",
+							Default:          "healthy",
+							Optional:         true,
+							ValidateDiagFunc: validateStringMatchesPattern(`^(healthy|running)$`),
+						},
+						"timeout": {
+							Type:             schema.TypeInt,
+							Description:      "The timeout in seconds to wait for the container. Defaults to `60`.",
+							Default:          60,
+							Optional:         true,
+							ValidateDiagFunc: validateIntegerGeqThan(1),
+						},
+						"interval": {
+							Type:             schema.TypeInt,
+							Description:      "The interval in seconds between two checks of the container. Defaults to `1`.",
+							Default:          1,
+							Optional:         true,
+							ValidateDiagFunc: validateIntegerGeqThan(1),
+						},
+					},
+				},
+			},
+
 			"attach": {
 				Type:        schema.TypeBool,
 				Description: "If `true` attach to the container after its creation and waits the end of its execution. Defaults to `false`.",
@@ -109,6 +198,13 @@ func resourceDockerContainer() *schema.Resource {
 			// this will delete and re-create the container
 			// following the principle that the containers
 			// should be pristine when started.
+			"pull_if_missing": {
+				Type:        schema.TypeBool,
+				Description: "If `true`, then the image is pulled with the registry auth of the provider if it is not on the Docker host, like `docker run` does. If the image is removed while the container is created, it is pulled again and the creation is retried once. If `false`, then the image must be on the Docker host, e.g. by a `docker_image` resource. Defaults to `true`.",
+				Default:     true,
+				Optional:    true,
+			},
+
 			"must_run": {
 				Type:        schema.TypeBool,
 				Description: "If `true`, then the Docker container will be kept running. If `false`, then as long as the container exists, Terraform assumes it is successful. Defaults to `true`.",
@@ -128,6 +224,12 @@ func resourceDockerContainer() *schema.Resource {
 				Computed:    true,
 			},
 
+			"wait_for_port_elapsed_seconds": {
+				Type:        schema.TypeInt,
+				Description: "The number of seconds it took until the port of `wait_for_port` was ready after the start of the container.",
+				Computed:    true,
+			},
+
 			// ForceNew is not true for image because we need to
 			// sane this against Docker image IDs, as each image
 			// can have multiple names/tags attached do it.
@@ -139,23 +241,25 @@ func resourceDockerContainer() *schema.Resource {
 			},
 
 			"hostname": {
-				Type:        schema.TypeString,
-				Description: "Hostname of the container.",
-				Optional:    true,
-				ForceNew:    true,
-				Computed:    true,
+				Type:             schema.TypeString,
+				Description:      "Hostname of the container. Defaults to the short ID of the container.",
+				Optional:         true,
+				ForceNew:         true,
+				Computed:         true,
+				ValidateDiagFunc: validateHostname(),
 			},
 
 			"domainname": {
-				Type:        schema.TypeString,
-				Description: "Domain name of the container.",
-				Optional:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "Domain name of the container.",
+				Optional:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validateHostname(),
 			},
 
 			"command": {
 				Type:        schema.TypeList,
-				Description: "The command to use to start the container. For example, to run `/usr/bin/myprogram -f baz.conf` set the command to be `[\"/usr/bin/myprogram\",\"-f\",\"baz.con\"]`.",
+				Description: "The command to use to start the container. For example, to run `/usr/bin/myprogram -f baz.conf` set the command to be `[\"/usr/bin/myprogram\",\"-f\",\"baz.conf\"]`. The command is passed as arguments to the `entrypoint`. Defaults to the command of the image, unless the `entrypoint` is set.",
 				Optional:    true,
 				ForceNew:    true,
 				Computed:    true,
@@ -164,7 +268,7 @@ func resourceDockerContainer() *schema.Resource {
 
 			"entrypoint": {
 				Type:        schema.TypeList,
-				Description: "The command to use as the Entrypoint for the container. The Entrypoint allows you to configure a container to run as an executable. For example, to run `/usr/bin/myprogram` when starting a container, set the entrypoint to be `\"/usr/bin/myprogra\"]`.",
+				Description: "The command to use as the Entrypoint for the container. The Entrypoint allows you to configure a container to run as an executable. For example, to run `/usr/bin/myprogram` when starting a container, set the entrypoint to be `[\"/usr/bin/myprogram\"]`. Like with `docker run --entrypoint`, the command of the image is not used if the entrypoint is set, so set the `command` as well if needed. `[\"\"]` resets the entrypoint of the image. Defaults to the entrypoint of the image.",
 				Optional:    true,
 				ForceNew:    true,
 				Computed:    true,
@@ -172,11 +276,11 @@ func resourceDockerContainer() *schema.Resource {
 			},
 
 			"user": {
-				Type:        schema.TypeString,
-				Description: "User used for run the first process. Format is `user` or `user:group` which user and group can be passed literraly or by name.",
-				Optional:    true,
-				ForceNew:    true,
-				Elem:        &schema.Schema{Type: schema.TypeString},
+				Type:             schema.TypeString,
+				Description:      "User used for run the first process. Format is `user` or `user:group` which user and group can be passed literraly or by name, e.g. `1000:1000` or `nginx`. Running a `privileged` container as another user doesn't drop the privileges of the container.",
+				Optional:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validateStringMatchesPattern(`^[^:\s]+(:[^:\s]+)?$`),
 				DiffSuppressFunc: func(k, oldV, newV string, d *schema.ResourceData) bool {
 					// treat "" as a no-op, which is Docker's default value
 					if newV == "" {
@@ -188,11 +292,14 @@ func resourceDockerContainer() *schema.Resource {
 
 			"dns": {
 				Type:        schema.TypeSet,
-				Description: "DNS servers to use.",
+				Description: "The IP addresses of the DNS servers to use instead of the ones of the Docker host.",
 				Optional:    true,
 				ForceNew:    true,
-				Elem:        &schema.Schema{Type: schema.TypeString},
-				Set:         schema.HashString,
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validateIPAddress(),
+				},
+				Set: schema.HashString,
 			},
 
 			"dns_opts": {
@@ -229,9 +336,10 @@ func resourceDockerContainer() *schema.Resource {
 			},
 
 			"max_retry_count": {
-				Type:        schema.TypeInt,
-				Description: "The maximum amount of times to an attempt a restart when `restart` is set to 'on-failure'.",
-				Optional:    true,
+				Type:             schema.TypeInt,
+				Description:      "The maximum amount of times to an attempt a restart when `restart` is set to 'on-failure'. Can only be set for 'on-failure'. Defaults to `0`, which restarts the container without a limit.",
+				Optional:         true,
+				ValidateDiagFunc: validateIntegerGeqThan(0),
 			},
 			"working_dir": {
 				Type:        schema.TypeString,
@@ -248,7 +356,7 @@ func resourceDockerContainer() *schema.Resource {
 			},
 			"remove_volumes": {
 				Type:        schema.TypeBool,
-				Description: "If `true`, it will remove anonymous volumes associated with the container. Defaults to `true`.",
+				Description: "If `true`, it will remove anonymous volumes associated with the container, e.g. the volumes of the image or of `volumes` without `volume_name`, when the container is destroyed or replaced. Named volumes, including the ones of `docker_volume` resources and named `mounts`, are never removed by the Docker daemon, so they are kept regardless. Defaults to `true`.",
 				Default:     true,
 				Optional:    true,
 			},
@@ -283,12 +391,15 @@ func resourceDockerContainer() *schema.Resource {
 			},
 			"security_opts": {
 				Type:        schema.TypeSet,
-				Description: "List of string values to customize labels for MLS systems, such as SELinux. See https://docs.docker.com/engine/reference/run/#security-configuration.",
+				Description: "List of security options of the container, e.g. `no-new-privileges`, `apparmor=<profile>`, `seccomp=<profile>` or `label=<option>` for SELinux. The seccomp profile can be the path of a JSON file, which is read like by the docker CLI. See https://docs.docker.com/engine/reference/run/#security-configuration.",
 				Optional:    true,
 				ForceNew:    true,
 				Computed:    true,
-				Elem:        &schema.Schema{Type: schema.TypeString},
-				Set:         schema.HashString,
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validateSecurityOpt(),
+				},
+				Set: schema.HashString,
 			},
 			"runtime": {
 				Type:        schema.TypeString,
@@ -298,15 +409,16 @@ func resourceDockerContainer() *schema.Resource {
 				Computed:    true,
 			},
 			"stop_signal": {
-				Type:        schema.TypeString,
-				Description: "Signal to stop a container (default `SIGTERM`).",
-				Optional:    true,
-				ForceNew:    true,
-				Computed:    true,
+				Type:             schema.TypeString,
+				Description:      "Signal to stop a container (default `SIGTERM`). Also sent when the container is stopped on destroy.",
+				Optional:         true,
+				ForceNew:         true,
+				Computed:         true,
+				ValidateDiagFunc: validateStopSignal(),
 			},
 			"stop_timeout": {
 				Type:        schema.TypeInt,
-				Description: "Timeout (in seconds) to stop a container.",
+				Description: "Timeout (in seconds) to stop a container. Also used as grace period to stop the container on destroy if `destroy_grace_seconds` is not set.",
 				Optional:    true,
 				ForceNew:    true,
 				Computed:    true,
@@ -325,7 +437,7 @@ func resourceDockerContainer() *schema.Resource {
 						},
 						"source": {
 							Type:        schema.TypeString,
-							Description: "Mount source (e.g. a volume name, a host path).",
+							Description: "Mount source (e.g. a volume name, a host path). A managed volume can be mounted with `docker_volume.foo.name`.",
 							Optional:    true,
 						},
 						"type": {
@@ -336,7 +448,7 @@ func resourceDockerContainer() *schema.Resource {
 						},
 						"read_only": {
 							Type:        schema.TypeBool,
-							Description: "Whether the mount should be read-only.",
+							Description: "Whether the mount should be read-only. The same volume can be mounted read-only in some containers and writable in others.",
 							Optional:    true,
 						},
 						"bind_options": {
@@ -352,12 +464,23 @@ func resourceDockerContainer() *schema.Resource {
 										Optional:         true,
 										ValidateDiagFunc: validateStringMatchesPattern(`^(private|rprivate|shared|rshared|slave|rslave)$`),
 									},
+									"non_recursive": {
+										Type:        schema.TypeBool,
+										Description: "If `true`, submounts of the source are not mounted recursively. Defaults to `false`.",
+										Optional:    true,
+									},
+									"consistency": {
+										Type:             schema.TypeString,
+										Description:      "The consistency requirement of the mount: `default`, `consistent`, `cached` or `delegated`. It speeds up bind mounts on Docker Desktop for Mac and is ignored on Linux.",
+										Optional:         true,
+										ValidateDiagFunc: validateStringMatchesPattern(`^(default|consistent|cached|delegated)$`),
+									},
 								},
 							},
 						},
 						"volume_options": {
 							Type:        schema.TypeList,
-							Description: "Optional configuration for the volume type.",
+							Description: "Optional configuration for the volume type. The options are only applied if the volume does not exist and is created for the container, so they have no effect on the volume of a `docker_volume`.",
 							Optional:    true,
 							MaxItems:    1,
 							Elem: &schema.Resource{
@@ -449,6 +572,13 @@ func resourceDockerContainer() *schema.Resource {
 							Optional:    true,
 							ForceNew:    true,
 						},
+						"selinux_relabel": {
+							Type:             schema.TypeString,
+							Description:      "Relabels the `host_path` with an SELinux label, so the container can access it on hosts with SELinux in enforcing mode. `shared` allows all containers to access the content, like the `z` option of `docker run -v`. `private` allows only this container to access it, like the `Z` option. Only supported for a `host_path`. Leave it unset on hosts without SELinux.",
+							Optional:         true,
+							ForceNew:         true,
+							ValidateDiagFunc: validateStringMatchesPattern(`^(shared|private)$`),
+						},
 					},
 				},
 			},
@@ -456,6 +586,7 @@ func resourceDockerContainer() *schema.Resource {
 				Type:        schema.TypeMap,
 				Description: "A map of container directories which should be replaced by `tmpfs mounts`, and their corresponding mount options.",
 				Optional:    true,
+				ForceNew:    true,
 			},
 			"ports": {
 				Type:        schema.TypeList,
@@ -465,18 +596,20 @@ func resourceDockerContainer() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"internal": {
-							Type:        schema.TypeInt,
-							Description: "Port within the container.",
-							Required:    true,
-							ForceNew:    true,
+							Type:             schema.TypeInt,
+							Description:      "Port within the container.",
+							Required:         true,
+							ForceNew:         true,
+							ValidateDiagFunc: validation.ToDiagFunc(validation.IsPortNumber),
 						},
 
 						"external": {
-							Type:        schema.TypeInt,
-							Description: "Port exposed out of the container. If not given a free random port `>= 32768` will be used.",
-							Optional:    true,
-							Computed:    true,
-							ForceNew:    true,
+							Type:             schema.TypeInt,
+							Description:      "Port exposed out of the container. If not given a free random port `>= 32768` will be used, which can be referenced as `ports[0].external`.",
+							Optional:         true,
+							Computed:         true,
+							ForceNew:         true,
+							ValidateDiagFunc: validation.ToDiagFunc(validation.IsPortNumberOrZero),
 						},
 
 						"ip": {
@@ -496,11 +629,12 @@ func resourceDockerContainer() *schema.Resource {
 						},
 
 						"protocol": {
-							Type:        schema.TypeString,
-							Description: "Protocol that can be used over this port. Defaults to `tcp`.",
-							Default:     "tcp",
-							Optional:    true,
-							ForceNew:    true,
+							Type:             schema.TypeString,
+							Description:      "Protocol that can be used over this port, one of `tcp`, `udp` or `sctp`. Defaults to `tcp`.",
+							Default:          "tcp",
+							Optional:         true,
+							ForceNew:         true,
+							ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{"tcp", "udp", "sctp"}, false)),
 						},
 					},
 				},
@@ -509,23 +643,25 @@ func resourceDockerContainer() *schema.Resource {
 
 			"host": {
 				Type:        schema.TypeSet,
-				Description: "Additional hosts to add to the container.",
+				Description: "Additional hosts to add to the `/etc/hosts` file of the container.",
 				Optional:    true,
 				ForceNew:    true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"ip": {
-							Type:        schema.TypeString,
-							Description: "IP address this hostname should resolve to.",
-							Required:    true,
-							ForceNew:    true,
+							Type:             schema.TypeString,
+							Description:      "IP address this hostname should resolve to, or `host-gateway` for the IP address of the Docker host, which requires Docker 20.10 or higher.",
+							Required:         true,
+							ForceNew:         true,
+							ValidateDiagFunc: validateExtraHostIP(),
 						},
 
 						"host": {
-							Type:        schema.TypeString,
-							Description: "Hostname to add",
-							Required:    true,
-							ForceNew:    true,
+							Type:             schema.TypeString,
+							Description:      "Hostname to add",
+							Required:         true,
+							ForceNew:         true,
+							ValidateDiagFunc: validateStringMatchesPattern(`^[^\s:]+$`),
 						},
 					},
 				},
@@ -539,10 +675,11 @@ func resourceDockerContainer() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"name": {
-							Type:        schema.TypeString,
-							Description: "The name of the ulimit",
-							Required:    true,
-							ForceNew:    true,
+							Type:             schema.TypeString,
+							Description:      "The name of the ulimit, e.g. `nofile` or `nproc`.",
+							Required:         true,
+							ForceNew:         true,
+							ValidateDiagFunc: validateUlimitName(),
 						},
 						"soft": {
 							Type:        schema.TypeInt,
@@ -653,10 +790,11 @@ func resourceDockerContainer() *schema.Resource {
 							ForceNew:    true,
 						},
 						"permissions": {
-							Type:        schema.TypeString,
-							Description: "The cgroup permissions given to the container to access the device. Defaults to `rwm`.",
-							Optional:    true,
-							ForceNew:    true,
+							Type:             schema.TypeString,
+							Description:      "The cgroup permissions given to the container to access the device, any combination of `r` (read), `w` (write) and `m` (mknod). Defaults to `rwm`.",
+							Optional:         true,
+							ForceNew:         true,
+							ValidateDiagFunc: validateDevicePermissions(),
 						},
 					},
 				},
@@ -668,36 +806,54 @@ func resourceDockerContainer() *schema.Resource {
 				Optional:    true,
 			},
 
+			"destroy_wait_seconds": {
+				Type:             schema.TypeInt,
+				Description:      "The number of seconds to wait for the container to exit after it was stopped on destroy, e.g. for stateful services to flush their data. The container is removed forcibly afterwards, `0` removes it right away. Defaults to `30`.",
+				Optional:         true,
+				Default:          30,
+				ValidateDiagFunc: validateIntegerGeqThan(0),
+			},
+
 			"labels": {
 				Type:        schema.TypeSet,
-				Description: "User-defined key/value metadata",
+				Description: "User-defined key/value metadata. The labels of the image and the labels which the Docker daemon or tools like Docker Desktop add are not refreshed into `labels`, but are part of `all_labels`.",
 				Optional:    true,
 				ForceNew:    true,
 				Computed:    true,
 				Elem:        labelSchema,
 			},
 
+			"all_labels": {
+				Type:        schema.TypeMap,
+				Description: "All labels of the container, including the labels of its image and the system labels of Docker, e.g. `com.docker.compose.*` or `desktop.docker.io/*`.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+
 			"memory": {
-				Type:             schema.TypeInt,
-				Description:      "The memory limit for the container in MBs.",
+				Type:             schema.TypeString,
+				Description:      "The memory limit for the container, e.g. `512m` or `1g`. A number without unit is the limit in MBs.",
 				Optional:         true,
-				ValidateDiagFunc: validateIntegerGeqThan(0),
+				ValidateDiagFunc: validateMBSize(),
+				DiffSuppressFunc: suppressEquivalentMBSize,
 			},
 
 			"memory_swap": {
-				Type:             schema.TypeInt,
-				Description:      "The total memory limit (memory + swap) for the container in MBs. This setting may compute to `-1` after `terraform apply` if the target host doesn't support memory swap, when that is the case docker will use a soft limitation.",
+				Type:             schema.TypeString,
+				Description:      "The total memory limit (memory + swap) for the container, e.g. `1g`. A number without unit is the limit in MBs, `-1` is unlimited swap. This setting may compute to `-1` after `terraform apply` if the target host doesn't support memory swap, when that is the case docker will use a soft limitation.",
 				Optional:         true,
-				ValidateDiagFunc: validateIntegerGeqThan(-1),
+				ValidateDiagFunc: validateMemorySwap(),
+				DiffSuppressFunc: suppressEquivalentMBSize,
 			},
 
 			"shm_size": {
-				Type:             schema.TypeInt,
-				Description:      "Size of `/dev/shm` in MBs.",
+				Type:             schema.TypeString,
+				Description:      "Size of `/dev/shm`, e.g. `256m` or `1g`. A number without unit is the size in MBs. Defaults to the size of the daemon, usually `64m`.",
 				Optional:         true,
 				ForceNew:         true,
 				Computed:         true,
-				ValidateDiagFunc: validateIntegerGeqThan(0),
+				ValidateDiagFunc: validateMBSize(),
+				DiffSuppressFunc: suppressEquivalentMBSize,
 			},
 
 			"cpu_shares": {
@@ -707,6 +863,34 @@ func resourceDockerContainer() *schema.Resource {
 				ValidateDiagFunc: validateIntegerGeqThan(0),
 			},
 
+			"cpus": {
+				Type:             schema.TypeString,
+				Description:      "The number of CPUs the container can use, e.g. `1.5`, like `docker run --cpus`. Can't be combined with `cpu_quota` and `cpu_period`.",
+				Optional:         true,
+				ConflictsWith:    []string{"cpu_quota", "cpu_period"},
+				ValidateDiagFunc: validateCpus(),
+				DiffSuppressFunc: func(k, oldV, newV string, d *schema.ResourceData) bool {
+					// e.g. 1.5 and 1.50 are the same number of CPUs
+					oldCpus, oldErr := parseCpus(oldV)
+					newCpus, newErr := parseCpus(newV)
+					return oldErr == nil && newErr == nil && oldCpus == newCpus
+				},
+			},
+
+			"cpu_quota": {
+				Type:             schema.TypeInt,
+				Description:      "The CPU time in microseconds the container can use in each `cpu_period`.",
+				Optional:         true,
+				ValidateDiagFunc: validateIntegerGeqThan(0),
+			},
+
+			"cpu_period": {
+				Type:             schema.TypeInt,
+				Description:      "The length of the CPU period in microseconds for `cpu_quota`. Defaults to the period of the Docker host, usually `100000`.",
+				Optional:         true,
+				ValidateDiagFunc: validateIntegerGeqThan(0),
+			},
+
 			"cpu_set": {
 				Type:             schema.TypeString,
 				Description:      "A comma-separated list or hyphen-separated range of CPUs a container can use, e.g. `0-1`.",
@@ -715,16 +899,17 @@ func resourceDockerContainer() *schema.Resource {
 			},
 
 			"log_driver": {
-				Type:        schema.TypeString,
-				Description: "The logging driver to use for the container.",
-				Computed:    true,
-				Optional:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "The logging driver to use for the container, e.g. `json-file`, `local`, `fluentd`, `gelf` or `awslogs`. Defaults to the logging driver of the daemon.",
+				Computed:         true,
+				Optional:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validateLogDriver(),
 			},
 
 			"log_opts": {
 				Type:        schema.TypeMap,
-				Description: "Key/value pairs to use as options for the logging driver.",
+				Description: "Key/value pairs to use as options for the logging driver, e.g. `max-size` and `max-file` for the rotation of the `json-file` driver.",
 				Optional:    true,
 				ForceNew:    true,
 			},
@@ -784,10 +969,19 @@ func resourceDockerContainer() *schema.Resource {
 			},
 
 			"pid_mode": {
-				Type:        schema.TypeString,
-				Description: "he PID (Process) Namespace mode for the container. Either `container:<name|id>` or `host`.",
-				Optional:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "The PID (Process) Namespace mode for the container. Either `container:<name|id>` or `host`.",
+				Optional:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validateStringMatchesPattern(`^(host|container:.+)$`),
+			},
+			"uts_mode": {
+				Type:             schema.TypeString,
+				Description:      "The UTS namespace mode for the container. Only `host` is supported, which uses the hostname of the Docker host, so it can't be combined with `hostname`.",
+				Optional:         true,
+				ForceNew:         true,
+				ConflictsWith:    []string{"hostname"},
+				ValidateDiagFunc: validateStringMatchesPattern(`^host$`),
 			},
 			"userns_mode": {
 				Type:        schema.TypeString,
@@ -852,6 +1046,7 @@ func resourceDockerContainer() *schema.Resource {
 				Description: "A test to perform to check that the container is healthy",
 				MaxItems:    1,
 				Optional:    true,
+				ForceNew:    true,
 				Computed:    true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
@@ -901,21 +1096,23 @@ func resourceDockerContainer() *schema.Resource {
 			},
 
 			"sysctls": {
-				Type:        schema.TypeMap,
-				Description: "A map of kernel parameters (sysctls) to set in the container.",
-				Optional:    true,
-				ForceNew:    true,
+				Type:             schema.TypeMap,
+				Description:      "A map of kernel parameters (sysctls) to set in the container, e.g. `net.core.somaxconn`. Only namespaced sysctls are supported.",
+				Optional:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validateSysctls(),
 			},
 			"ipc_mode": {
-				Type:        schema.TypeString,
-				Description: "IPC sharing mode for the container. Possible values are: `none`, `private`, `shareable`, `container:<name|id>` or `host`.",
-				Optional:    true,
-				ForceNew:    true,
-				Computed:    true,
+				Type:             schema.TypeString,
+				Description:      "IPC sharing mode for the container. Possible values are: `none`, `private`, `shareable`, `container:<name|id>` or `host`.",
+				Optional:         true,
+				ForceNew:         true,
+				Computed:         true,
+				ValidateDiagFunc: validateStringMatchesPattern(`^(none|private|shareable|host|container:.+)$`),
 			},
 			"group_add": {
 				Type:        schema.TypeSet,
-				Description: "Additional groups for the container user",
+				Description: "Additional groups for the container user, by name or GID.",
 				Optional:    true,
 				ForceNew:    true,
 				Elem:        &schema.Schema{Type: schema.TypeString},
@@ -923,20 +1120,21 @@ func resourceDockerContainer() *schema.Resource {
 			},
 			"init": {
 				Type:        schema.TypeBool,
-				Description: "Configured whether an init process should be injected for this container. If unset this will default to the `dockerd` defaults.",
+				Description: "If `true`, an init process, like `docker run --init`, runs as PID 1 of the container, which forwards signals and reaps zombie processes. If unset this will default to the `dockerd` defaults.",
 				Optional:    true,
+				ForceNew:    true,
 				Computed:    true,
 			},
 			"tty": {
 				Type:        schema.TypeBool,
-				Description: "If `true`, allocate a pseudo-tty (`docker run -t`). Defaults to `false`.",
+				Description: "If `true`, allocate a pseudo-tty (`docker run -t`). Some entrypoints behave differently with a TTY, e.g. shells run interactively and programs color their output. The stdout and stderr of the container are merged then. Defaults to `false`.",
 				Default:     false,
 				Optional:    true,
 				ForceNew:    true,
 			},
 			"stdin_open": {
 				Type:        schema.TypeBool,
-				Description: "If `true`, keep STDIN open even if not attached (`docker run -i`). Defaults to `false`.",
+				Description: "If `true`, keep STDIN open even if not attached (`docker run -i`). Together with `tty`, it keeps a shell as entrypoint running like `docker run -it`, as the shell waits for input instead of exiting. Nothing is written to STDIN, so it should not be combined with `attach`. Defaults to `false`.",
 				Default:     false,
 				Optional:    true,
 				ForceNew:    true,
@@ -948,10 +1146,56 @@ func resourceDockerContainer() *schema.Resource {
 				ForceNew:    true,
 			},
 			"gpus": {
-				Type:        schema.TypeString,
-				Description: "GPU devices to add to the container. Currently, only the value `all` is supported. Passing any other value will result in unexpected behavior.",
-				Optional:    true,
-				ForceNew:    true,
+				Type:          schema.TypeString,
+				Description:   "GPU devices to add to the container. Currently, only the value `all` is supported. Passing any other value will result in unexpected behavior.",
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"device_requests"},
+			},
+			"device_requests": {
+				Type:          schema.TypeList,
+				Description:   "Requests devices like GPUs from a device driver of the Docker host, e.g. the NVIDIA container runtime. Requires Docker API version `1.40` or higher.",
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"gpus"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"driver": {
+							Type:        schema.TypeString,
+							Description: "The name of the device driver, e.g. `nvidia`.",
+							Optional:    true,
+							ForceNew:    true,
+						},
+						"count": {
+							Type:             schema.TypeInt,
+							Description:      "The number of devices to request, `-1` requests all devices. Conflicts with `device_ids`.",
+							Optional:         true,
+							ForceNew:         true,
+							ValidateDiagFunc: validateIntegerGeqThan(-1),
+						},
+						"device_ids": {
+							Type:        schema.TypeList,
+							Description: "The IDs of the devices to request, e.g. the index or UUID of a GPU. Conflicts with `count`.",
+							Optional:    true,
+							ForceNew:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"capabilities": {
+							Type:        schema.TypeList,
+							Description: "The capabilities the devices must all have, e.g. `[\"gpu\"]`.",
+							Optional:    true,
+							ForceNew:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"options": {
+							Type:        schema.TypeMap,
+							Description: "Driver specific options.",
+							Optional:    true,
+							ForceNew:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
 			},
 			"cgroupns_mode": {
 				Type:        schema.TypeString,
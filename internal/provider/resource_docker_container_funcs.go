@@ -11,7 +11,13 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"log"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,9 +26,13 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/go-connections/nat"
 	"github.com/docker/go-units"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -31,6 +41,9 @@ const (
 	containerReadRefreshTimeoutMillisecondsDefault = 15000
 	containerReadRefreshWaitBeforeRefreshes        = 100 * time.Millisecond
 	containerReadRefreshDelay                      = 100 * time.Millisecond
+
+	// hostGatewayName is resolved by the daemon to the IP address of the Docker host
+	hostGatewayName = "host-gateway"
 )
 
 var (
@@ -41,6 +54,175 @@ var (
 	errContainerFailedToBeInHealthyState = errors.New("container failed to be in healthy state")
 )
 
+// containerUpdatableAttributes are the attributes which can be changed on a
+// running container via ContainerUpdate without recreating it.
+var containerUpdatableAttributes = []string{
+	"restart", "max_retry_count", "cpu_shares", "memory", "cpu_set", "memory_swap",
+	"cpus", "cpu_quota", "cpu_period",
+}
+
+// containerProviderAttributes only control how the provider handles the
+// container and are never sent to the Docker daemon on update, so changing
+// them neither updates nor recreates the container.
+var containerProviderAttributes = []string{
+	"start", "wait", "wait_timeout", "wait_for_port", "wait_for", "attach", "logs", "must_run",
+	"destroy_grace_seconds", "destroy_wait_seconds", "remove_volumes",
+	"container_read_refresh_timeout_milliseconds", "override", "pull_if_missing",
+}
+
+// isNoSuchImageError returns true if a container could not be created because its
+// image is not on the Docker host. The daemon returns not found for missing networks too.
+func isNoSuchImageError(err error) bool {
+	return errdefs.IsNotFound(err) && strings.Contains(err.Error(), "No such image")
+}
+
+// resourceDockerContainerCustomizeDiff rejects a max_retry_count for restart policies
+// other than on-failure, which the daemon only rejects when the container is created.
+// It recreates the container if a CPU limit is removed, as the daemon keeps limits
+// which are updated to zero.
+func resourceDockerContainerCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	for _, key := range []string{"cpus", "cpu_quota", "cpu_period"} {
+		if d.Id() != "" && d.HasChange(key) && d.NewValueKnown(key) && isZeroCPULimit(d.Get(key)) {
+			if err := d.ForceNew(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	if rawConfig := d.GetRawConfig(); !rawConfig.IsNull() {
+		if err := containerPortsError(rawConfig.GetAttr("ports")); err != nil {
+			return err
+		}
+	}
+
+	if !d.NewValueKnown("restart") || !d.NewValueKnown("max_retry_count") {
+		return nil
+	}
+	return validateRestartPolicy(d.Get("restart").(string), d.Get("max_retry_count").(int))
+}
+
+// containerPortsError returns an error if the configured ports publish the same
+// port twice or bind the same port of the Docker host twice, which the daemon only
+// rejects when the container is started. Ports with unknown values are skipped.
+func containerPortsError(ports cty.Value) error {
+	if ports.IsNull() || !ports.IsKnown() {
+		return nil
+	}
+	published := map[string]bool{}
+	hostPorts := map[string]bool{}
+	for it := ports.ElementIterator(); it.Next(); {
+		_, port := it.Element()
+		if port.IsNull() || !port.IsWhollyKnown() {
+			continue
+		}
+		internal, _ := port.GetAttr("internal").AsBigFloat().Int64()
+		protocol := "tcp"
+		if v := port.GetAttr("protocol"); !v.IsNull() {
+			protocol = v.AsString()
+		}
+		ip := "0.0.0.0"
+		if v := port.GetAttr("ip"); !v.IsNull() && v.AsString() != "" {
+			ip = v.AsString()
+		}
+		var external int64
+		if v := port.GetAttr("external"); !v.IsNull() {
+			external, _ = v.AsBigFloat().Int64()
+		}
+
+		key := fmt.Sprintf("%d/%s on %s:%d", internal, protocol, ip, external)
+		if published[key] {
+			return fmt.Errorf("the port %d/%s is published more than once on %s with the same external port", internal, protocol, ip)
+		}
+		published[key] = true
+
+		if external == 0 {
+			// a random port of the Docker host
+			continue
+		}
+		hostPort := fmt.Sprintf("%s:%d/%s", ip, external, protocol)
+		if hostPorts[hostPort] {
+			return fmt.Errorf("the external port %s is used by more than one of the ports", hostPort)
+		}
+		hostPorts[hostPort] = true
+	}
+	return nil
+}
+
+var portAllocatedRegexp = regexp.MustCompile(`Bind for (\S+) failed: port is already allocated|listen \w+ (\S+): bind: address already in use`)
+
+// containerStartError returns the error of a container which could not be started,
+// with the port of the Docker host if it is already used by another container or
+// process.
+func containerStartError(err error) error {
+	if match := portAllocatedRegexp.FindStringSubmatch(err.Error()); match != nil {
+		hostPort := match[1]
+		if hostPort == "" {
+			hostPort = match[2]
+		}
+		return fmt.Errorf("the port %s of the Docker host is already used by another container or process, choose another external port: %w", hostPort, err)
+	}
+	return err
+}
+
+// isZeroCPULimit returns true if the value of cpus, cpu_quota or cpu_period sets
+// no limit.
+func isZeroCPULimit(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		cpus, err := parseCpus(v)
+		return err == nil && cpus == 0
+	case int:
+		return v == 0
+	}
+	return false
+}
+
+// validateRestartPolicy returns an error if the retry count can't be used with the
+// restart policy.
+func validateRestartPolicy(restart string, maxRetryCount int) error {
+	if maxRetryCount != 0 && restart != "on-failure" {
+		return fmt.Errorf("max_retry_count can only be set if restart is 'on-failure', not '%s'", restart)
+	}
+	return nil
+}
+
+// privilegedContainerWarnings warns about settings which look like they restrict a
+// privileged container, but don't.
+func privilegedContainerWarnings(user string, capDrop []string, privileged bool) diag.Diagnostics {
+	if !privileged {
+		return nil
+	}
+	var diags diag.Diagnostics
+	if name, _, _ := strings.Cut(user, ":"); name != "" && name != "root" && name != "0" {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Privileged container runs as non-root user",
+			Detail:   fmt.Sprintf("The container runs as user '%s', but it is privileged, so it still has access to all devices of the Docker host and the user can gain all capabilities.", user),
+		})
+	}
+	if len(capDrop) > 0 {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Capabilities are not dropped from privileged container",
+			Detail:   fmt.Sprintf("The container is privileged, so it has all capabilities and dropping %s has no effect.", strings.Join(capDrop, ", ")),
+		})
+	}
+	return diags
+}
+
+// interactiveContainerWarnings warns about settings of `docker run -i` which can't
+// work without a terminal, as the provider never writes to or closes STDIN.
+func interactiveContainerWarnings(stdinOpen, attach bool) diag.Diagnostics {
+	if !stdinOpen || !attach {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  "Attached container keeps STDIN open",
+		Detail:   "The STDIN of the container is kept open because of stdin_open, but nothing is written to it. A command which reads from STDIN, like a shell, never exits then, so the apply waits for the attached container forever.",
+	}}
+}
+
 // NOTE mavogel: we keep this global var for tracking
 // the time in the create and read func
 var creationTime time.Time
@@ -51,11 +233,13 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 	if errC != nil {
 		return diag.Errorf(fmt.Sprint(errC))
 	}
-	authConfigs := meta.(*ProviderConfig).AuthConfigs
 	image := d.Get("image").(string)
-	_, err = findImage(ctx, image, client, authConfigs, "")
-	if err != nil {
-		return diag.Errorf("Unable to create container with image %s: %s", image, err)
+	pullIfMissing := d.Get("pull_if_missing").(bool)
+	if pullIfMissing {
+		_, err = findImage(ctx, image, client, meta.(*ProviderConfig), "")
+		if err != nil {
+			return diag.Errorf("Unable to create container with image %s: %s", image, err)
+		}
 	}
 	var stopTimeout *int
 	if v, ok := d.GetOk("stop_timeout"); ok {
@@ -88,6 +272,12 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 
 	if v, ok := d.GetOk("entrypoint"); ok {
 		config.Entrypoint = stringListToStringSlice(v.([]interface{}))
+		// [""] resets the entrypoint of the image, like docker run --entrypoint=""
+		for i, v := range config.Entrypoint {
+			if v == "" && (i > 0 || len(config.Entrypoint) > 1) {
+				return diag.Errorf("values for entrypoint may not be empty, except for a single empty value to reset the entrypoint of the image")
+			}
+		}
 	}
 
 	if v, ok := d.GetOk("user"); ok {
@@ -113,7 +303,10 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 
 	extraUlimits := []*units.Ulimit{}
 	if v, ok := d.GetOk("ulimit"); ok {
-		extraUlimits = ulimitsToDockerUlimits(v.(*schema.Set))
+		extraUlimits, err = ulimitsToDockerUlimits(v.(*schema.Set))
+		if err != nil {
+			return diag.Errorf("Invalid ulimit: %s", err)
+		}
 	}
 	volumes := map[string]struct{}{}
 	binds := []string{}
@@ -181,6 +374,12 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 							if value, ok := rawBindOptions["propagation"]; ok {
 								mountInstance.BindOptions.Propagation = mount.Propagation(value.(string))
 							}
+							if value, ok := rawBindOptions["non_recursive"]; ok {
+								mountInstance.BindOptions.NonRecursive = value.(bool)
+							}
+							if value, ok := rawBindOptions["consistency"]; ok {
+								mountInstance.Consistency = mount.Consistency(value.(string))
+							}
 						}
 					}
 				}
@@ -197,13 +396,13 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 								mountInstance.VolumeOptions.Labels = labelSetToMap(value.(*schema.Set))
 							}
 							// because it is not possible to nest maps
-							if value, ok := rawVolumeOptions["driver_name"]; ok {
+							if value, ok := rawVolumeOptions["driver_name"]; ok && value.(string) != "" {
 								if mountInstance.VolumeOptions.DriverConfig == nil {
 									mountInstance.VolumeOptions.DriverConfig = &mount.Driver{}
 								}
 								mountInstance.VolumeOptions.DriverConfig.Name = value.(string)
 							}
-							if value, ok := rawVolumeOptions["driver_options"]; ok {
+							if value, ok := rawVolumeOptions["driver_options"]; ok && len(value.(map[string]interface{})) > 0 {
 								if mountInstance.VolumeOptions.DriverConfig == nil {
 									mountInstance.VolumeOptions.DriverConfig = &mount.Driver{}
 								}
@@ -257,6 +456,11 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 		hostConfig.PortBindings = portBindings
 	}
 	if len(extraHosts) != 0 {
+		for _, extraHost := range extraHosts {
+			if strings.HasSuffix(extraHost, ":"+hostGatewayName) && versions.LessThan(client.ClientVersion(), "1.41") {
+				return diag.Errorf("%s requires Docker API version 1.41 or higher, but the Docker host supports %s", hostGatewayName, client.ClientVersion())
+			}
+		}
 		hostConfig.ExtraHosts = extraHosts
 	}
 	if len(binds) != 0 {
@@ -295,29 +499,43 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 	}
 
 	if v, ok := d.GetOk("security_opts"); ok {
-		hostConfig.SecurityOpt = stringSetToStringSlice(v.(*schema.Set))
+		hostConfig.SecurityOpt, err = securityOptsToDockerSecurityOpts(v.(*schema.Set))
+		if err != nil {
+			return diag.Errorf("Invalid security_opts: %s", err)
+		}
 	}
 
+	// the values are validated by the schema
 	if v, ok := d.GetOk("memory"); ok {
-		hostConfig.Memory = int64(v.(int)) * 1024 * 1024
+		hostConfig.Memory, _ = parseMBSize(v.(string))
 	}
 
 	if v, ok := d.GetOk("memory_swap"); ok {
-		swap := int64(v.(int))
-		if swap > 0 {
-			swap = swap * 1024 * 1024
-		}
-		hostConfig.MemorySwap = swap
+		hostConfig.MemorySwap, _ = parseMemorySwap(v.(string))
 	}
 
 	if v, ok := d.GetOk("shm_size"); ok {
-		hostConfig.ShmSize = int64(v.(int)) * 1024 * 1024
+		// the value is validated by the schema
+		hostConfig.ShmSize, _ = parseMBSize(v.(string))
 	}
 
 	if v, ok := d.GetOk("cpu_shares"); ok {
 		hostConfig.CPUShares = int64(v.(int))
 	}
 
+	if v, ok := d.GetOk("cpus"); ok {
+		// the value is validated by the schema
+		hostConfig.NanoCPUs, _ = parseCpus(v.(string))
+	}
+
+	if v, ok := d.GetOk("cpu_quota"); ok {
+		hostConfig.CPUQuota = int64(v.(int))
+	}
+
+	if v, ok := d.GetOk("cpu_period"); ok {
+		hostConfig.CPUPeriod = int64(v.(int))
+	}
+
 	if v, ok := d.GetOk("cpu_set"); ok {
 		hostConfig.CpusetCpus = v.(string)
 	}
@@ -338,6 +556,10 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 		hostConfig.PidMode = container.PidMode(v.(string))
 	}
 
+	if v, ok := d.GetOk("uts_mode"); ok {
+		hostConfig.UTSMode = container.UTSMode(v.(string))
+	}
+
 	if v, ok := d.GetOk("sysctls"); ok {
 		hostConfig.Sysctls = mapTypeMapValsToString(v.(map[string]interface{}))
 	}
@@ -359,6 +581,16 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 			log.Printf("[WARN] GPU support requires docker version 1.40 or higher")
 		}
 	}
+	if v, ok := d.GetOk("device_requests"); ok {
+		if versions.LessThan(client.ClientVersion(), "1.40") {
+			return diag.Errorf("device_requests require Docker API version 1.40 or higher, but the Docker host supports %s", client.ClientVersion())
+		}
+		deviceRequests, err := deviceRequestListToDockerDeviceRequests(v.([]interface{}))
+		if err != nil {
+			return diag.Errorf("Error setting device_requests: %s", err)
+		}
+		hostConfig.DeviceRequests = deviceRequests
+	}
 
 	if v, ok := d.GetOk("cgroupns_mode"); ok {
 		if client.ClientVersion() >= "1.41" {
@@ -373,8 +605,11 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 		}
 	}
 
-	init := d.Get("init").(bool)
-	hostConfig.Init = &init
+	// without init the daemon decides, e.g. by the 'init' of its daemon.json
+	if rawConfig := d.GetRawConfig(); !rawConfig.IsNull() && !rawConfig.GetAttr("init").IsNull() {
+		init := d.Get("init").(bool)
+		hostConfig.Init = &init
+	}
 
 	if v, ok := d.GetOk("storage_opts"); ok {
 		hostConfig.StorageOpt = mapTypeMapValsToString(v.(map[string]interface{}))
@@ -383,17 +618,30 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 	var retContainer container.ContainerCreateCreatedBody
 
 	// TODO mavogel add platform later which comes from API v1.41. Currently we pass nil
-	if retContainer, err = client.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, d.Get("name").(string)); err != nil {
+	retContainer, err = client.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, d.Get("name").(string))
+	if err != nil && pullIfMissing && isNoSuchImageError(err) {
+		// the image can be removed after it was looked up, e.g. by the destroy of a docker_image
+		log.Printf("[INFO] Image %s was removed, pulling it again to create the container", image)
+		if err := pullImage(ctx, &Data{}, client, meta.(*ProviderConfig), image, ""); err != nil {
+			return diag.Errorf("Unable to create container with image %s: %s", image, err)
+		}
+		retContainer, err = client.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, d.Get("name").(string))
+	}
+	if err != nil {
 		return diag.Errorf("Unable to create container: %s", err)
 	}
 	log.Printf("[INFO] retContainer %#v", retContainer)
 	d.SetId(retContainer.ID)
+	// e.g. that the kernel does not support swap limits, like the docker CLI prints them
+	diags := meta.(*ProviderConfig).daemonWarnings(ctx, d, fmt.Sprintf("creating container %s", d.Get("name").(string)), retContainer.Warnings)
+	diags = append(diags, privilegedContainerWarnings(config.User, hostConfig.CapDrop, hostConfig.Privileged)...)
+	diags = append(diags, interactiveContainerWarnings(config.OpenStdin, d.Get("attach").(bool))...)
 
 	// But overwrite them with the future ones, if set
 	if v, ok := d.GetOk("networks_advanced"); ok {
 		if err := client.NetworkDisconnect(ctx, "bridge", retContainer.ID, false); err != nil {
 			if !containsIgnorableErrorMessage(err.Error(), "is not connected to the network bridge") {
-				return diag.Errorf("Unable to disconnect the default network: %s", err)
+				return append(diags, diag.Errorf("Unable to disconnect the default network: %s", err)...)
 			}
 		}
 
@@ -414,7 +662,7 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 			endpointConfig.IPAMConfig = endpointIPAMConfig
 
 			if err := client.NetworkConnect(ctx, networkID, retContainer.ID, endpointConfig); err != nil {
-				return diag.Errorf("Unable to connect to network '%s': %s", networkID, err)
+				return append(diags, diag.Errorf("Unable to connect to network '%s': %s", networkID, err)...)
 			}
 		}
 	}
@@ -436,10 +684,10 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 			}
 
 			if setParams == 0 {
-				return diag.Errorf("error with upload content: one of 'content', 'content_base64', or 'source' must be set")
+				return append(diags, diag.Errorf("error with upload content: one of 'content', 'content_base64', or 'source' must be set")...)
 			}
 			if setParams > 1 {
-				return diag.Errorf("error with upload content: only one of 'content', 'content_base64', or 'source' can be set")
+				return append(diags, diag.Errorf("error with upload content: only one of 'content', 'content_base64', or 'source' can be set")...)
 			}
 
 			var contentToUpload string
@@ -453,7 +701,7 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 			if source != "" {
 				sourceContent, err := os.ReadFile(source)
 				if err != nil {
-					return diag.Errorf("could not read file: %s", err)
+					return append(diags, diag.Errorf("could not read file: %s", err)...)
 				}
 				contentToUpload = string(sourceContent)
 			}
@@ -474,29 +722,41 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 				ModTime: time.Now(),
 			}
 			if err := tw.WriteHeader(hdr); err != nil {
-				return diag.Errorf("Error creating tar archive: %s", err)
+				return append(diags, diag.Errorf("Error creating tar archive: %s", err)...)
 			}
 			if _, err := tw.Write([]byte(contentToUpload)); err != nil {
-				return diag.Errorf("Error creating tar archive: %s", err)
+				return append(diags, diag.Errorf("Error creating tar archive: %s", err)...)
 			}
 			if err := tw.Close(); err != nil {
-				return diag.Errorf("Error creating tar archive: %s", err)
+				return append(diags, diag.Errorf("Error creating tar archive: %s", err)...)
 			}
 
 			dstPath := "/"
 			uploadContent := bytes.NewReader(buf.Bytes())
 			options := types.CopyToContainerOptions{}
 			if err := client.CopyToContainer(ctx, retContainer.ID, dstPath, uploadContent, options); err != nil {
-				return diag.Errorf("Unable to upload volume content: %s", err)
+				return append(diags, diag.Errorf("Unable to upload volume content: %s", err)...)
 			}
 		}
 	}
 
 	if d.Get("start").(bool) {
+		err := withoutHostSlot(ctx, func() error {
+			for _, waitFor := range d.Get("wait_for").([]interface{}) {
+				if err := waitForDependency(ctx, client, waitFor.(map[string]interface{})); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return append(diags, diag.FromErr(err)...)
+		}
+
 		creationTime = time.Now()
 		options := types.ContainerStartOptions{}
 		if err := client.ContainerStart(ctx, retContainer.ID, options); err != nil {
-			return diag.Errorf("Unable to start container: %s", err)
+			return append(diags, diag.Errorf("Unable to start container: %s", containerStartError(err))...)
 		}
 
 		if d.Get("wait").(bool) {
@@ -521,16 +781,30 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 			defer cancel()
 			result := make(chan error, 1)
 			go waitForHealthyState(result)
-			select {
-			case <-ctx.Done():
-				log.Printf("[ERROR] Container %s failed to be in healthy state in time", retContainer.ID)
-				return diag.FromErr(errContainerFailedToBeInHealthyState)
-
-			case err := <-result:
-				if err != nil {
-					return diag.FromErr(err)
+			err := withoutHostSlot(ctx, func() error {
+				select {
+				case <-ctx.Done():
+					log.Printf("[ERROR] Container %s failed to be in healthy state in time", retContainer.ID)
+					return errContainerFailedToBeInHealthyState
+				case err := <-result:
+					return err
 				}
+			})
+			if err != nil {
+				return append(diags, diag.FromErr(err)...)
+			}
+		}
+
+		if v, ok := d.GetOk("wait_for_port"); ok && len(v.([]interface{})) > 0 && v.([]interface{})[0] != nil {
+			var elapsed time.Duration
+			err := withoutHostSlot(ctx, func() (err error) {
+				elapsed, err = waitForContainerPort(ctx, client, retContainer.ID, v.([]interface{})[0].(map[string]interface{}))
+				return err
+			})
+			if err != nil {
+				return append(diags, diag.FromErr(err)...)
 			}
+			d.Set("wait_for_port_elapsed_seconds", int(elapsed.Seconds()))
 		}
 	}
 
@@ -569,7 +843,7 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 		select {
 		case err := <-errAttachCh:
 			if err != nil {
-				return diag.Errorf("Unable to wait container end of execution: %s", err)
+				return append(diags, diag.Errorf("Unable to wait container end of execution: %s", err)...)
 			}
 		case <-attachCh:
 			if d.Get("logs").(bool) {
@@ -582,7 +856,7 @@ func resourceDockerContainerCreate(ctx context.Context, d *schema.ResourceData,
 		}
 	}
 
-	return resourceDockerContainerRead(ctx, d, meta)
+	return append(diags, resourceDockerContainerRead(ctx, d, meta)...)
 }
 
 func resourceDockerContainerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -603,6 +877,10 @@ func resourceDockerContainerRead(ctx context.Context, d *schema.ResourceData, me
 		return diag.FromErr(err)
 	}
 	if apiContainer == nil {
+		if isAutoRemovedContainer(d) {
+			log.Printf("[INFO] Container %s was removed by the daemon after it exited, keeping it in state", d.Id())
+			return nil
+		}
 		// This container doesn't exist anymore
 		d.SetId("")
 		return nil
@@ -619,6 +897,11 @@ func resourceDockerContainerRead(ctx context.Context, d *schema.ResourceData, me
 
 	containerRaw, err := stateConf.WaitForStateContext(ctx)
 	if err != nil {
+		if isAutoRemovedContainer(d) && containsIgnorableErrorMessage(err.Error(), "No such container") {
+			// the container exited and was removed since it was listed
+			log.Printf("[INFO] Container %s was removed by the daemon after it exited, keeping it in state", d.Id())
+			return nil
+		}
 		if errors.Is(err, errContainerFailedToBeCreated) {
 			return resourceDockerContainerDelete(ctx, d, meta)
 		}
@@ -651,12 +934,20 @@ func resourceDockerContainerRead(ctx context.Context, d *schema.ResourceData, me
 	// Read Network Settings
 	if container.NetworkSettings != nil {
 		d.Set("bridge", container.NetworkSettings.Bridge)
-		if err := d.Set("ports", flattenContainerPorts(container.NetworkSettings.Ports)); err != nil {
+		ports := flattenContainerPorts(container.NetworkSettings.Ports)
+		if len(ports) == 0 && !container.State.Running {
+			// the ports of a stopped container are not bound, so the configured bindings are read
+			ports = keepAssignedExternalPorts(flattenContainerPorts(container.HostConfig.PortBindings), d.Get("ports").([]interface{}))
+		}
+		if err := d.Set("ports", ports); err != nil {
 			log.Printf("[WARN] failed to set ports from API: %s", err)
 		}
 		if err := d.Set("network_data", flattenContainerNetworks(container.NetworkSettings)); err != nil {
 			log.Printf("[WARN] failed to set network settings from API: %s", err)
 		}
+		if err := d.Set("networks_advanced", flattenContainerNetworksAdvanced(container, networksAdvancedNames(d))); err != nil {
+			log.Printf("[WARN] failed to set networks from API: %s", err)
+		}
 	}
 
 	// TODO all the other attributes
@@ -677,10 +968,15 @@ func resourceDockerContainerRead(ctx context.Context, d *schema.ResourceData, me
 	d.Set("user", container.Config.User)
 	d.Set("dns", container.HostConfig.DNS)
 	d.Set("dns_opts", container.HostConfig.DNSOptions)
-	d.Set("security_opts", container.HostConfig.SecurityOpt)
+	d.Set("security_opts", flattenSecurityOpts(container.HostConfig.SecurityOpt, stringSetToStringSlice(d.Get("security_opts").(*schema.Set))))
 	d.Set("dns_search", container.HostConfig.DNSSearch)
 	d.Set("publish_all_ports", container.HostConfig.PublishAllPorts)
-	d.Set("restart", container.HostConfig.RestartPolicy.Name)
+	// the daemon returns no name for containers which were created without a policy
+	restart := container.HostConfig.RestartPolicy.Name
+	if restart == "" {
+		restart = "no"
+	}
+	d.Set("restart", restart)
 	d.Set("max_retry_count", container.HostConfig.RestartPolicy.MaximumRetryCount)
 
 	// From what I can tell Init being nullable is only for container creation to allow
@@ -719,26 +1015,33 @@ func resourceDockerContainerRead(ctx context.Context, d *schema.ResourceData, me
 	// For detail, please see the following URLs.
 	// https://github.com/terraform-providers/terraform-provider-docker/issues/242
 	// https://github.com/terraform-providers/terraform-provider-docker/pull/269
+	// The effective labels are kept apart, so labels of the image and the system
+	// labels added by the daemon or Docker Desktop don't cause a diff.
+	d.Set("all_labels", container.Config.Labels)
 
 	d.Set("privileged", container.HostConfig.Privileged)
 	if err = d.Set("devices", flattenDevices(container.HostConfig.Devices)); err != nil {
 		log.Printf("[WARN] failed to set container hostconfig devices from API: %s", err)
 	}
 	// "destroy_grace_seconds" can't be imported
-	d.Set("memory", container.HostConfig.Memory/1024/1024)
-	if container.HostConfig.MemorySwap > 0 {
-		d.Set("memory_swap", container.HostConfig.MemorySwap/1024/1024)
+	d.Set("memory", flattenMBSize(container.HostConfig.Memory, d.Get("memory").(string)))
+	if container.HostConfig.MemorySwap < 0 {
+		d.Set("memory_swap", "-1")
 	} else {
-		d.Set("memory_swap", container.HostConfig.MemorySwap)
+		d.Set("memory_swap", flattenMBSize(container.HostConfig.MemorySwap, d.Get("memory_swap").(string)))
 	}
-	d.Set("shm_size", container.HostConfig.ShmSize/1024/1024)
+	d.Set("shm_size", flattenMBSize(container.HostConfig.ShmSize, d.Get("shm_size").(string)))
 	d.Set("cpu_shares", container.HostConfig.CPUShares)
 	d.Set("cpu_set", container.HostConfig.CpusetCpus)
+	d.Set("cpus", flattenCpus(container.HostConfig.NanoCPUs, d.Get("cpus").(string)))
+	d.Set("cpu_quota", container.HostConfig.CPUQuota)
+	d.Set("cpu_period", container.HostConfig.CPUPeriod)
 	d.Set("log_driver", container.HostConfig.LogConfig.Type)
 	d.Set("log_opts", container.HostConfig.LogConfig.Config)
 	d.Set("storage_opts", container.HostConfig.StorageOpt)
 	d.Set("network_mode", container.HostConfig.NetworkMode)
 	d.Set("pid_mode", container.HostConfig.PidMode)
+	d.Set("uts_mode", container.HostConfig.UTSMode)
 	d.Set("userns_mode", container.HostConfig.UsernsMode)
 	// "upload" can't be imported
 	if container.Config.Healthcheck != nil {
@@ -761,10 +1064,14 @@ func resourceDockerContainerRead(ctx context.Context, d *schema.ResourceData, me
 	d.Set("stop_timeout", container.Config.StopTimeout)
 
 	if len(container.HostConfig.DeviceRequests) > 0 {
-		// TODO pass the original gpus property string back to the resource
-		// var gpuOpts opts.GpuOpts
-		// gpuOpts = opts.GpuOpts{container.HostConfig.DeviceRequests}
-		d.Set("gpus", "all")
+		if _, ok := d.GetOk("gpus"); ok {
+			// TODO pass the original gpus property string back to the resource
+			// var gpuOpts opts.GpuOpts
+			// gpuOpts = opts.GpuOpts{container.HostConfig.DeviceRequests}
+			d.Set("gpus", "all")
+		} else {
+			d.Set("device_requests", flattenDeviceRequests(container.HostConfig.DeviceRequests))
+		}
 	}
 
 	return nil
@@ -825,71 +1132,97 @@ func resourceDockerContainerReadRefreshFunc(ctx context.Context,
 }
 
 func resourceDockerContainerUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	attrs := []string{
-		"restart", "max_retry_count", "cpu_shares", "memory", "cpu_set", "memory_swap",
-	}
-	for _, attr := range attrs {
-		if d.HasChange(attr) {
-
-			// TODO update ulimits
-			// Updating ulimits seems not to work well.
-			// It succeeds to run `DockerClient.ContainerUpdate` with `ulimit` but actually `ulimit` aren't changed.
-			// https://github.com/terraform-providers/terraform-provider-docker/pull/236#discussion_r373819536
-			// ulimits := []*units.Ulimit{}
-			// if v, ok := d.GetOk("ulimit"); ok {
-			// 	ulimits = ulimitsToDockerUlimits(v.(*schema.Set))
-			// }
-
-			updateConfig := container.UpdateConfig{
-				RestartPolicy: container.RestartPolicy{
-					Name:              d.Get("restart").(string),
-					MaximumRetryCount: d.Get("max_retry_count").(int),
-				},
-				Resources: container.Resources{
-					CPUShares:  int64(d.Get("cpu_shares").(int)),
-					Memory:     int64(d.Get("memory").(int)) * 1024 * 1024,
-					CpusetCpus: d.Get("cpu_set").(string),
-					// Ulimits:    ulimits,
-				},
-			}
-
-			if ms, ok := d.GetOk("memory_swap"); ok {
-				a := int64(ms.(int))
-				if a > 0 {
-					a = a * 1024 * 1024
-				}
-				updateConfig.Resources.MemorySwap = a
-			}
-			client, errC := meta.(*ProviderConfig).MakeClient(ctx, d)
-			if errC != nil {
-				return diag.Errorf(fmt.Sprint(errC))
-			}
-			_, err := client.ContainerUpdate(ctx, d.Id(), updateConfig)
-			if err != nil {
-				return diag.Errorf("Unable to update a container: %v", err)
-			}
-			break
+	if !d.HasChanges(containerUpdatableAttributes...) {
+		return nil
+	}
+
+	// TODO update ulimits
+	// Updating ulimits seems not to work well.
+	// It succeeds to run `DockerClient.ContainerUpdate` with `ulimit` but actually `ulimit` aren't changed.
+	// https://github.com/terraform-providers/terraform-provider-docker/pull/236#discussion_r373819536
+	// ulimits := []*units.Ulimit{}
+	// if v, ok := d.GetOk("ulimit"); ok {
+	// 	ulimits, _ = ulimitsToDockerUlimits(v.(*schema.Set))
+	// }
+
+	updateConfig := container.UpdateConfig{
+		RestartPolicy: container.RestartPolicy{
+			Name:              d.Get("restart").(string),
+			MaximumRetryCount: d.Get("max_retry_count").(int),
+		},
+		Resources: container.Resources{
+			CPUShares:  int64(d.Get("cpu_shares").(int)),
+			CpusetCpus: d.Get("cpu_set").(string),
+			CPUQuota:   int64(d.Get("cpu_quota").(int)),
+			CPUPeriod:  int64(d.Get("cpu_period").(int)),
+			// Ulimits:    ulimits,
+		},
+	}
+	// the values are validated by the schema
+	updateConfig.Resources.NanoCPUs, _ = parseCpus(d.Get("cpus").(string))
+	if memory, ok := d.GetOk("memory"); ok {
+		updateConfig.Resources.Memory, _ = parseMBSize(memory.(string))
+	}
+	if ms, ok := d.GetOk("memory_swap"); ok {
+		updateConfig.Resources.MemorySwap, _ = parseMemorySwap(ms.(string))
+	}
+	client, errC := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if errC != nil {
+		return diag.Errorf(fmt.Sprint(errC))
+	}
+	log.Printf("[INFO] Updating container '%s' in place", d.Id())
+	updateResponse, err := client.ContainerUpdate(ctx, d.Id(), updateConfig)
+	if err != nil {
+		if isAutoRemovedContainer(d) && containsIgnorableErrorMessage(err.Error(), "No such container") {
+			log.Printf("[INFO] Container %s was removed by the daemon after it exited, nothing to update", d.Id())
+			return nil
 		}
+		return diag.Errorf("Unable to update a container: %v", err)
 	}
-	return nil
+	return meta.(*ProviderConfig).daemonWarnings(ctx, d, fmt.Sprintf("updating container %s", d.Get("name").(string)), updateResponse.Warnings)
 }
 
 func resourceDockerContainerDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	ctx = logResourceContext(ctx, "docker_container", d)
 	client, errC := meta.(*ProviderConfig).MakeClient(ctx, d)
 	if errC != nil {
 		return diag.Errorf(fmt.Sprint(errC))
 	}
 
 	if !d.Get("attach").(bool) {
-		// Stop the container before removing if destroy_grace_seconds is defined
-		var timeout time.Duration
-		if d.Get("destroy_grace_seconds").(int) > 0 {
-			timeout = time.Duration(int32(d.Get("destroy_grace_seconds").(int))) * time.Second
+		// Stop the container before removing if destroy_grace_seconds is defined.
+		// Otherwise the daemon stops it gracefully with the stop_signal and
+		// stop_timeout of the container, if a stop_timeout is set.
+		timeout := containerStopTimeout(d.Get("destroy_grace_seconds").(int), d.Get("stop_timeout").(int))
+
+		if timeout != nil {
+			tflog.Info(ctx, "Stopping container", map[string]interface{}{"timeout": timeout.String()})
+		} else {
+			tflog.Info(ctx, "Stopping container with the stop signal and timeout of the container", map[string]interface{}{"signal": d.Get("stop_signal").(string), "timeout": d.Get("stop_timeout").(int)})
+		}
+		if err := client.ContainerStop(ctx, d.Id(), timeout); err != nil {
+			if !containsIgnorableErrorMessage(err.Error(), "No such container") {
+				return diag.Errorf("Error stopping container %s: %s", d.Id(), err)
+			}
+			tflog.Info(ctx, "Container is already gone", map[string]interface{}{"error": err.Error()})
 		}
 
-		log.Printf("[INFO] Stopping Container '%s' with timeout %v", d.Id(), timeout)
-		if err := client.ContainerStop(ctx, d.Id(), &timeout); err != nil {
-			return diag.Errorf("Error stopping container %s: %s", d.Id(), err)
+		// the daemon may report the container as running for a moment after the
+		// stop, so it is removed only once it has exited
+		if waitTimeout := time.Duration(d.Get("destroy_wait_seconds").(int)) * time.Second; waitTimeout > 0 {
+			tflog.Info(ctx, "Waiting for container to exit", map[string]interface{}{"timeout": waitTimeout.String()})
+			stateConf := &retry.StateChangeConf{
+				Pending:    []string{"running"},
+				Target:     []string{"exited", "removed"},
+				Refresh:    containerStoppedRefreshFunc(ctx, client, d.Id()),
+				Timeout:    waitTimeout,
+				MinTimeout: containerReadRefreshWaitBeforeRefreshes,
+				Delay:      containerReadRefreshDelay,
+			}
+			if _, err := stateConf.WaitForStateContext(ctx); err != nil {
+				// the container is killed by the forced removal
+				tflog.Warn(ctx, "Container did not exit, removing it forcibly", map[string]interface{}{"error": err.Error()})
+			}
 		}
 	}
 
@@ -899,7 +1232,7 @@ func resourceDockerContainerDelete(ctx context.Context, d *schema.ResourceData,
 		Force:         true,
 	}
 
-	log.Printf("[INFO] Removing Container '%s'", d.Id())
+	tflog.Info(ctx, "Removing container")
 	if err := client.ContainerRemove(ctx, d.Id(), removeOpts); err != nil {
 		if !containsIgnorableErrorMessage(err.Error(), "No such container", "is already in progress") {
 			return diag.Errorf("Error deleting container %s: %s", d.Id(), err)
@@ -911,22 +1244,124 @@ func resourceDockerContainerDelete(ctx context.Context, d *schema.ResourceData,
 		waitCondition = container.WaitConditionRemoved
 	}
 
-	log.Printf("[INFO] Waiting for Container '%s' with condition '%s'", d.Id(), waitCondition)
+	tflog.Info(ctx, "Waiting for container", map[string]interface{}{"condition": string(waitCondition)})
 	waitOkC, errorC := client.ContainerWait(ctx, d.Id(), waitCondition)
 	select {
 	case waitOk := <-waitOkC:
-		log.Printf("[INFO] Container exited with code [%v]: '%s'", waitOk.StatusCode, d.Id())
+		tflog.Info(ctx, "Container exited", map[string]interface{}{"exit_code": waitOk.StatusCode})
 	case err := <-errorC:
 		if !containsIgnorableErrorMessage(err.Error(), "No such container", "is already in progress") {
 			return diag.Errorf("Error waiting for container removal '%s': %s", d.Id(), err)
 		}
-		log.Printf("[INFO] Waiting for Container '%s' errord: '%s'", d.Id(), err.Error())
+		tflog.Info(ctx, "Waiting for container failed", map[string]interface{}{"error": err.Error()})
 	}
 
 	d.SetId("")
 	return nil
 }
 
+// resourceDockerContainerImport imports a container by its ID or name. Besides the
+// attributes which are refreshed by the read, it sets the env, labels, volumes and
+// networks of the container which aren't taken over from its image.
+func resourceDockerContainerImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := client.ContainerInspect(ctx, d.Id())
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, fmt.Errorf("container %s does not exist", d.Id())
+		}
+		return nil, fmt.Errorf("unable to inspect container %s: %w", d.Id(), err)
+	}
+	d.SetId(infos.ID)
+
+	// the provider attributes can't be read from the container, so they get
+	// their defaults. A stopped container must not be removed by the refresh.
+	containerSchema := resourceDockerContainer().Schema
+	for _, attr := range containerProviderAttributes {
+		if def := containerSchema[attr].Default; def != nil {
+			d.Set(attr, def)
+		}
+	}
+	d.Set("must_run", infos.State.Running)
+	d.Set("start", infos.State.Running)
+
+	imageConfig := &container.Config{}
+	image, _, err := client.ImageInspectWithRaw(ctx, infos.Image)
+	if err != nil {
+		log.Printf("[WARN] Unable to inspect image %s of container %s, its env and labels are imported too: %s", infos.Image, infos.ID, err)
+	} else if image.Config != nil {
+		imageConfig = image.Config
+	}
+
+	d.Set("env", flattenImportedEnv(infos.Config.Env, imageConfig.Env))
+	d.Set("labels", mapToLabelSet(flattenImportedLabels(infos.Config.Labels, imageConfig.Labels)))
+	if err := d.Set("volumes", flattenImportedVolumes(infos, imageConfig.Volumes)); err != nil {
+		log.Printf("[WARN] failed to set volumes of container %s: %s", infos.ID, err)
+	}
+	if err := d.Set("networks_advanced", flattenImportedNetworks(infos)); err != nil {
+		log.Printf("[WARN] failed to set networks of container %s: %s", infos.ID, err)
+	}
+	for _, setting := range unsupportedContainerSettings(infos) {
+		log.Printf("[WARN] Container %s uses %s, which can't be managed by the provider and is not imported", infos.ID, setting)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// containerStopTimeout returns the timeout for stopping the container on destroy.
+// nil lets the daemon use the stop timeout configured on the container.
+func containerStopTimeout(destroyGraceSeconds, stopTimeout int) *time.Duration {
+	var timeout time.Duration
+	if destroyGraceSeconds > 0 {
+		timeout = time.Duration(int32(destroyGraceSeconds)) * time.Second
+		return &timeout
+	}
+	if stopTimeout > 0 {
+		return nil
+	}
+	return &timeout
+}
+
+// containerStoppedRefreshFunc reports whether the container has exited after it
+// was stopped. A container which is already removed, e.g. by auto_remove, has
+// stopped too.
+func containerStoppedRefreshFunc(ctx context.Context, client *client.Client, containerID string) retry.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		container, err := client.ContainerInspect(ctx, containerID)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				return containerID, "removed", nil
+			}
+			return nil, "", err
+		}
+		if container.State.Running || container.State.Restarting {
+			tflog.Debug(ctx, "Container is still running", map[string]interface{}{"status": container.State.Status})
+			return container, "running", nil
+		}
+		return container, "exited", nil
+	}
+}
+
+// networksAdvancedNames returns the names of the networks in networks_advanced.
+func networksAdvancedNames(d *schema.ResourceData) []string {
+	names := []string{}
+	for _, rawNetwork := range d.Get("networks_advanced").(*schema.Set).List() {
+		names = append(names, rawNetwork.(map[string]interface{})["name"].(string))
+	}
+	return names
+}
+
+// isAutoRemovedContainer returns true if the container is removed by the daemon
+// when it exits and doesn't need to keep running. Such a one-off container is
+// expected to disappear, so it is kept in the state instead of being created again.
+func isAutoRemovedContainer(d *schema.ResourceData) bool {
+	return d.Get("rm").(bool) && !d.Get("must_run").(bool)
+}
+
 func fetchDockerContainer(ctx context.Context, ID string, client *client.Client) (*types.Container, error) {
 	apiContainers, err := client.ContainerList(ctx, types.ContainerListOptions{All: true})
 	if err != nil {
@@ -941,3 +1376,199 @@ func fetchDockerContainer(ctx context.Context, ID string, client *client.Client)
 
 	return nil, nil
 }
+
+// waitForContainerPort checks the port of the started container in the configured
+// interval until it is ready or the timeout is reached, and returns how long it took.
+func waitForContainerPort(ctx context.Context, client *client.Client, containerID string, waitForPort map[string]interface{}) (time.Duration, error) {
+	port := waitForPort["port"].(int)
+	protocol := waitForPort["protocol"].(string)
+	timeout := time.Duration(waitForPort["timeout"].(int)) * time.Second
+	interval := time.Duration(waitForPort["interval"].(int)) * time.Second
+
+	infos, err := client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return 0, fmt.Errorf("error inspecting container state: %s", err)
+	}
+	address, err := containerPortAddress(client.DaemonHost(), infos, port)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err = checkContainerPort(ctx, protocol, address, waitForPort["path"].(string), waitForPort["status_code"].(int))
+		if err == nil {
+			elapsed := time.Since(start)
+			log.Printf("[INFO] Port %d of container %s is ready at %s after %s", port, containerID, address, elapsed.Round(time.Millisecond))
+			return elapsed, nil
+		}
+		log.Printf("[DEBUG] Waiting for port %d of container %s at %s: %s", port, containerID, address, err)
+
+		select {
+		case <-ctx.Done():
+			return time.Since(start), fmt.Errorf("port %d of container %s was not ready at %s after %s: %s", port, containerID, address, time.Since(start).Round(time.Millisecond), err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForDependency polls the container of a wait_for block until it is in the state
+// of its condition. It fails right away if the container can't get into the state.
+func waitForDependency(ctx context.Context, client *client.Client, waitFor map[string]interface{}) error {
+	containerID := waitFor["container"].(string)
+	condition := waitFor["condition"].(string)
+	timeout := time.Duration(waitFor["timeout"].(int)) * time.Second
+	interval := time.Duration(waitFor["interval"].(int)) * time.Second
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		infos, err := client.ContainerInspect(ctx, containerID)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				return fmt.Errorf("container %s to wait for does not exist", containerID)
+			}
+			if ctx.Err() == nil {
+				return fmt.Errorf("error inspecting container %s to wait for: %s", containerID, err)
+			}
+		} else {
+			ready, state, err := dependencyState(infos, condition)
+			if err != nil {
+				return fmt.Errorf("unable to wait for container %s to be %s: %s", containerID, condition, err)
+			}
+			if ready {
+				log.Printf("[INFO] Container %s is %s after %s", containerID, condition, time.Since(start).Round(time.Millisecond))
+				return nil
+			}
+			log.Printf("[DEBUG] Waiting for container %s to be %s, it is %s", containerID, condition, state)
+			if ctx.Err() != nil {
+				return fmt.Errorf("container %s was not %s after %s, it is %s", containerID, condition, time.Since(start).Round(time.Millisecond), state)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("container %s was not %s after %s", containerID, condition, time.Since(start).Round(time.Millisecond))
+		case <-ticker.C:
+		}
+	}
+}
+
+// dependencyState returns whether the container is in the state of the condition,
+// and its current state for the messages. It returns an error if the container can't
+// get into the state, as it has no health check or stopped without a restart policy.
+func dependencyState(infos types.ContainerJSON, condition string) (bool, string, error) {
+	if infos.ContainerJSONBase == nil || infos.State == nil {
+		return false, "", fmt.Errorf("the container has no state")
+	}
+	state := infos.State.Status
+
+	if !infos.State.Running && !infos.State.Restarting {
+		policy := ""
+		if infos.HostConfig != nil {
+			policy = infos.HostConfig.RestartPolicy.Name
+		}
+		// created containers are started by their own resource
+		if state != "created" && (policy == "" || policy == "no") {
+			return false, state, fmt.Errorf("the container is %s with exit code %d and is not restarted", state, infos.State.ExitCode)
+		}
+		return false, state, nil
+	}
+
+	if condition == "running" {
+		return infos.State.Running, state, nil
+	}
+	if infos.State.Health == nil {
+		return false, state, fmt.Errorf("the container has no health check, wait until it is running instead")
+	}
+	return infos.State.Health.Status == types.Healthy, state + " and " + infos.State.Health.Status, nil
+}
+
+// containerPortAddress returns the address to reach the port of the container. The
+// published host port is preferred, as the IP address of the container is usually
+// only reachable from the Docker host itself.
+func containerPortAddress(daemonHost string, infos types.ContainerJSON, port int) (string, error) {
+	if infos.NetworkSettings == nil {
+		return "", fmt.Errorf("container %s has no network settings", infos.ID)
+	}
+
+	for _, binding := range infos.NetworkSettings.Ports[nat.Port(fmt.Sprintf("%d/tcp", port))] {
+		if binding.HostPort == "" {
+			continue
+		}
+		hostIP := binding.HostIP
+		if hostIP == "" || hostIP == "0.0.0.0" || hostIP == "::" {
+			hostIP = daemonHostname(daemonHost)
+		}
+		return net.JoinHostPort(hostIP, binding.HostPort), nil
+	}
+
+	containerIP := infos.NetworkSettings.IPAddress
+	if containerIP == "" {
+		// sorted, so the same network is used on every run
+		networkNames := make([]string, 0, len(infos.NetworkSettings.Networks))
+		for name := range infos.NetworkSettings.Networks {
+			networkNames = append(networkNames, name)
+		}
+		sort.Strings(networkNames)
+		for _, name := range networkNames {
+			if ip := infos.NetworkSettings.Networks[name].IPAddress; ip != "" {
+				containerIP = ip
+				break
+			}
+		}
+	}
+	if containerIP == "" {
+		return "", fmt.Errorf("port %d of container %s is not published and the container has no IP address", port, infos.ID)
+	}
+	return net.JoinHostPort(containerIP, strconv.Itoa(port)), nil
+}
+
+// daemonHostname returns the hostname of the Docker host, which is the local
+// machine for unix sockets and named pipes.
+func daemonHostname(daemonHost string) string {
+	u, err := url.Parse(daemonHost)
+	if err != nil || u.Hostname() == "" || u.Scheme == "unix" || u.Scheme == "npipe" {
+		return "127.0.0.1"
+	}
+	return u.Hostname()
+}
+
+// checkContainerPort returns nil if the port accepts connections or, for http,
+// responds with the expected status code.
+func checkContainerPort(ctx context.Context, protocol, address, path string, statusCode int) error {
+	if protocol != "http" {
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+address+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if statusCode != 0 && resp.StatusCode != statusCode {
+		return fmt.Errorf("got status code %d instead of %d", resp.StatusCode, statusCode)
+	}
+	if statusCode == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		return fmt.Errorf("got status code %d instead of 2xx", resp.StatusCode)
+	}
+	return nil
+}
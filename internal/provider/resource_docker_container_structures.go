@@ -1,11 +1,16 @@
 package provider
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/docker/cli/opts"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/go-connections/nat"
@@ -28,9 +33,15 @@ func (s byPortAndProtocol) Less(i, j int) bool {
 	iPort, _ := strconv.Atoi(iSplit[0])
 	jSplit := strings.Split(string(s[j]), "/")
 	jPort, _ := strconv.Atoi(jSplit[0])
+	if iPort == jPort {
+		return s[i] < s[j]
+	}
 	return iPort < jPort
 }
 
+// flattenContainerPorts normalizes the port bindings read back from the Docker API, so
+// they match the ports of the config: the host IP defaults to 0.0.0.0 and the IPv6
+// binding the daemon adds next to the IPv4 binding of a port is left out.
 func flattenContainerPorts(in nat.PortMap) []interface{} {
 	out := make([]interface{}, 0)
 
@@ -41,23 +52,56 @@ func flattenContainerPorts(in nat.PortMap) []interface{} {
 	sort.Sort(byPortAndProtocol(internalPortKeys))
 
 	for _, portKey := range internalPortKeys {
-		m := make(map[string]interface{})
-
-		portBindings := in[nat.Port(portKey)]
+		port := nat.Port(portKey)
+		portBindings := in[port]
 		for _, portBinding := range portBindings {
-			portProtocolSplit := strings.Split(string(portKey), "/")
-			convertedInternal, _ := strconv.Atoi(portProtocolSplit[0])
+			hostIP := portBinding.HostIP
+			if hostIP == "" {
+				hostIP = "0.0.0.0"
+			}
+			if hostIP == "::" && hasPortBinding(portBindings, "0.0.0.0", portBinding.HostPort) {
+				continue
+			}
 			convertedExternal, _ := strconv.Atoi(portBinding.HostPort)
-			m["internal"] = convertedInternal
-			m["external"] = convertedExternal
-			m["ip"] = portBinding.HostIP
-			m["protocol"] = portProtocolSplit[1]
-			out = append(out, m)
+			out = append(out, map[string]interface{}{
+				"internal": port.Int(),
+				"external": convertedExternal,
+				"ip":       hostIP,
+				"protocol": port.Proto(),
+			})
 		}
 	}
 	return out
 }
 
+func hasPortBinding(portBindings []nat.PortBinding, hostIP string, hostPort string) bool {
+	for _, portBinding := range portBindings {
+		if (portBinding.HostIP == hostIP || portBinding.HostIP == "" && hostIP == "0.0.0.0") && portBinding.HostPort == hostPort {
+			return true
+		}
+	}
+	return false
+}
+
+// keepAssignedExternalPorts sets the random host ports of the bindings of a stopped
+// container, which are only known while it is running, to the ports assigned before.
+func keepAssignedExternalPorts(ports []interface{}, statePorts []interface{}) []interface{} {
+	for _, port := range ports {
+		port := port.(map[string]interface{})
+		if port["external"].(int) != 0 {
+			continue
+		}
+		for _, statePort := range statePorts {
+			statePort := statePort.(map[string]interface{})
+			if statePort["internal"] == port["internal"] && statePort["protocol"] == port["protocol"] && statePort["ip"] == port["ip"] {
+				port["external"] = statePort["external"]
+				break
+			}
+		}
+	}
+	return ports
+}
+
 func flattenContainerNetworks(in *types.NetworkSettings) []interface{} {
 	out := make([]interface{}, 0)
 	if in == nil || in.Networks == nil || len(in.Networks) == 0 {
@@ -80,6 +124,194 @@ func flattenContainerNetworks(in *types.NetworkSettings) []interface{} {
 	return out
 }
 
+// flattenImportedEnv returns the env of an imported container without the
+// variables which are taken over unchanged from its image.
+func flattenImportedEnv(containerEnv []string, imageEnv []string) []string {
+	fromImage := make(map[string]bool, len(imageEnv))
+	for _, env := range imageEnv {
+		fromImage[env] = true
+	}
+
+	out := []string{}
+	for _, env := range containerEnv {
+		if !fromImage[env] {
+			out = append(out, env)
+		}
+	}
+	return out
+}
+
+// systemLabelPrefixes are the label namespaces of Docker and Docker Desktop, whose
+// labels are added to containers by the daemon or tools like Compose.
+var systemLabelPrefixes = append([]string{"desktop.docker.io/"}, reservedLabelPrefixes...)
+
+// isSystemLabel returns true if the label is in one of the systemLabelPrefixes.
+func isSystemLabel(name string) bool {
+	for _, prefix := range systemLabelPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenImportedLabels returns the labels of an imported container without
+// the labels which are taken over unchanged from its image and without the
+// system labels, which are only part of the all_labels.
+func flattenImportedLabels(containerLabels map[string]string, imageLabels map[string]string) map[string]string {
+	out := map[string]string{}
+	for name, value := range containerLabels {
+		if imageValue, ok := imageLabels[name]; ok && imageValue == value {
+			continue
+		}
+		if isSystemLabel(name) {
+			continue
+		}
+		out[name] = value
+	}
+	return out
+}
+
+// flattenImportedVolumes returns the binds, the volumes from other containers and the
+// anonymous volumes of an imported container. The volumes of its image are skipped.
+func flattenImportedVolumes(infos types.ContainerJSON, imageVolumes map[string]struct{}) []interface{} {
+	out := make([]interface{}, 0)
+	bound := map[string]bool{}
+	for _, bind := range infos.HostConfig.Binds {
+		parts := strings.Split(bind, ":")
+		if len(parts) < 2 {
+			continue
+		}
+		m := map[string]interface{}{
+			"container_path": parts[1],
+			"read_only":      false,
+		}
+		if strings.HasPrefix(parts[0], "/") {
+			m["host_path"] = parts[0]
+		} else {
+			m["volume_name"] = parts[0]
+		}
+		if len(parts) > 2 {
+			for _, option := range strings.Split(parts[2], ",") {
+				switch option {
+				case "ro":
+					m["read_only"] = true
+				case "z":
+					m["selinux_relabel"] = "shared"
+				case "Z":
+					m["selinux_relabel"] = "private"
+				}
+			}
+		}
+		bound[parts[1]] = true
+		out = append(out, m)
+	}
+
+	for _, fromContainer := range infos.HostConfig.VolumesFrom {
+		out = append(out, map[string]interface{}{
+			"from_container": fromContainer,
+		})
+	}
+
+	containerPaths := make([]string, 0, len(infos.Config.Volumes))
+	for containerPath := range infos.Config.Volumes {
+		if _, ok := imageVolumes[containerPath]; ok || bound[containerPath] {
+			continue
+		}
+		containerPaths = append(containerPaths, containerPath)
+	}
+	sort.Strings(containerPaths)
+	for _, containerPath := range containerPaths {
+		out = append(out, map[string]interface{}{
+			"container_path": containerPath,
+		})
+	}
+	return out
+}
+
+// flattenImportedNetworks returns the networks an imported container is connected to
+// besides the one of its network mode.
+func flattenImportedNetworks(infos types.ContainerJSON) []interface{} {
+	return flattenContainerNetworksAdvanced(infos, nil)
+}
+
+// flattenContainerNetworksAdvanced returns the networks a container is connected to
+// besides the one of its network mode. Networks which are configured by their ID
+// keep the ID as name. The short ID of the container is skipped from the aliases
+// because the daemon adds it to every user-defined network.
+func flattenContainerNetworksAdvanced(infos types.ContainerJSON, configuredNames []string) []interface{} {
+	out := make([]interface{}, 0)
+	if infos.NetworkSettings == nil {
+		return out
+	}
+
+	networkMode := string(infos.HostConfig.NetworkMode)
+	if networkMode == "" || networkMode == "default" {
+		networkMode = "bridge"
+	}
+	shortID := infos.ID
+	if len(shortID) > 12 {
+		shortID = shortID[:12]
+	}
+
+	for networkName, networkData := range infos.NetworkSettings.Networks {
+		if networkName == networkMode || networkData == nil {
+			continue
+		}
+		aliases := []string{}
+		for _, alias := range networkData.Aliases {
+			if alias != shortID {
+				aliases = append(aliases, alias)
+			}
+		}
+		m := map[string]interface{}{
+			"name":    configuredNetworkName(networkName, networkData.NetworkID, configuredNames),
+			"aliases": aliases,
+		}
+		if networkData.IPAMConfig != nil {
+			m["ipv4_address"] = networkData.IPAMConfig.IPv4Address
+			m["ipv6_address"] = networkData.IPAMConfig.IPv6Address
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// configuredNetworkName returns the configured name of the network, which is its
+// name, its ID or a prefix of its ID. The name of the network is returned if it is
+// not configured.
+func configuredNetworkName(networkName, networkID string, configuredNames []string) string {
+	for _, configured := range configuredNames {
+		if configured == networkName || configured == networkID ||
+			(len(configured) >= 12 && strings.HasPrefix(networkID, configured)) {
+			return configured
+		}
+	}
+	return networkName
+}
+
+// unsupportedContainerSettings returns the settings of a container which have no
+// attribute in the resource and thus get lost when it is recreated.
+func unsupportedContainerSettings(infos types.ContainerJSON) []string {
+	out := []string{}
+	if len(infos.HostConfig.Links) > 0 {
+		out = append(out, fmt.Sprintf("the links %s", strings.Join(infos.HostConfig.Links, ", ")))
+	}
+	if infos.HostConfig.VolumeDriver != "" {
+		out = append(out, fmt.Sprintf("the volume driver %s", infos.HostConfig.VolumeDriver))
+	}
+	if infos.HostConfig.CgroupParent != "" {
+		out = append(out, fmt.Sprintf("the cgroup parent %s", infos.HostConfig.CgroupParent))
+	}
+	if infos.HostConfig.PidsLimit != nil && *infos.HostConfig.PidsLimit > 0 {
+		out = append(out, fmt.Sprintf("the pids limit %d", *infos.HostConfig.PidsLimit))
+	}
+	if infos.Config.MacAddress != "" {
+		out = append(out, fmt.Sprintf("the MAC address %s", infos.Config.MacAddress))
+	}
+	return out
+}
+
 func stringListToStringSlice(stringList []interface{}) []string {
 	ret := []string{}
 	for _, v := range stringList {
@@ -169,7 +401,7 @@ func portSetToDockerPorts(ports []interface{}) (map[nat.Port]struct{}, map[nat.P
 	return retExposedPorts, retPortBindings
 }
 
-func ulimitsToDockerUlimits(extraUlimits *schema.Set) []*units.Ulimit {
+func ulimitsToDockerUlimits(extraUlimits *schema.Set) ([]*units.Ulimit, error) {
 	retExtraUlimits := []*units.Ulimit{}
 
 	for _, ulimitInt := range extraUlimits.List() {
@@ -179,10 +411,14 @@ func ulimitsToDockerUlimits(extraUlimits *schema.Set) []*units.Ulimit {
 			Soft: int64(ulimits["soft"].(int)),
 			Hard: int64(ulimits["hard"].(int)),
 		}
+		// -1 means unlimited
+		if u.Hard != -1 && (u.Soft == -1 || u.Soft > u.Hard) {
+			return nil, fmt.Errorf("the soft limit %d of ulimit '%s' is greater than the hard limit %d", u.Soft, u.Name, u.Hard)
+		}
 		retExtraUlimits = append(retExtraUlimits, u)
 	}
 
-	return retExtraUlimits
+	return retExtraUlimits, nil
 }
 
 func extraHostsSetToContainerExtraHosts(extraHosts *schema.Set) []string {
@@ -212,6 +448,10 @@ func volumeSetToDockerVolumes(volumes *schema.Set) (map[string]struct{}, []strin
 			volumeName = volume["host_path"].(string)
 		}
 		readOnly := volume["read_only"].(bool)
+		selinuxRelabel, _ := volume["selinux_relabel"].(string)
+		if selinuxRelabel != "" && len(volume["host_path"].(string)) == 0 {
+			return retVolumeMap, retHostConfigBinds, retVolumeFromContainers, fmt.Errorf("selinux_relabel of the volume entry for %q can only be set for a bind mount of a host_path", containerPath)
+		}
 
 		switch {
 		case len(fromContainer) == 0 && len(containerPath) == 0:
@@ -225,8 +465,16 @@ func volumeSetToDockerVolumes(volumes *schema.Set) (map[string]struct{}, []strin
 			if readOnly {
 				readWrite = "ro"
 			}
+			options := readWrite
+			// the daemon relabels the host path with the shared or the private label of the container
+			switch selinuxRelabel {
+			case "shared":
+				options += ",z"
+			case "private":
+				options += ",Z"
+			}
 			retVolumeMap[containerPath] = struct{}{}
-			retHostConfigBinds = append(retHostConfigBinds, volumeName+":"+containerPath+":"+readWrite)
+			retHostConfigBinds = append(retHostConfigBinds, volumeName+":"+containerPath+":"+options)
 		default:
 			retVolumeMap[containerPath] = struct{}{}
 		}
@@ -235,6 +483,133 @@ func volumeSetToDockerVolumes(volumes *schema.Set) (map[string]struct{}, []strin
 	return retVolumeMap, retHostConfigBinds, retVolumeFromContainers, nil
 }
 
+// securityOptsToDockerSecurityOpts returns the security options for the daemon. Like
+// the docker CLI does, the seccomp profile of a 'seccomp=<path>' option is read from
+// the file, because the daemon expects the profile itself.
+func securityOptsToDockerSecurityOpts(securityOpts *schema.Set) ([]string, error) {
+	out := []string{}
+	for _, securityOpt := range stringSetToStringSlice(securityOpts) {
+		if key, profile, _ := cutSecurityOpt(securityOpt); key == "seccomp" && isSeccompProfilePath(profile) {
+			content, err := os.ReadFile(profile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read seccomp profile %s: %w", profile, err)
+			}
+			compacted := &bytes.Buffer{}
+			if err := json.Compact(compacted, content); err != nil {
+				return nil, fmt.Errorf("seccomp profile %s is no valid JSON: %w", profile, err)
+			}
+			securityOpt = "seccomp=" + compacted.String()
+		}
+		out = append(out, securityOpt)
+	}
+	return out, nil
+}
+
+// flattenSecurityOpts returns the security options of a container. A seccomp profile
+// which was read from a file is replaced by the configured option with the path
+// of the file, so the content of the profile doesn't show up as a diff.
+func flattenSecurityOpts(in []string, stateSecurityOpts []string) []string {
+	seccompPathOpt := ""
+	for _, securityOpt := range stateSecurityOpts {
+		if key, profile, _ := cutSecurityOpt(securityOpt); key == "seccomp" && isSeccompProfilePath(profile) {
+			seccompPathOpt = securityOpt
+		}
+	}
+
+	out := []string{}
+	for _, securityOpt := range in {
+		if key, profile, _ := cutSecurityOpt(securityOpt); key == "seccomp" && strings.HasPrefix(profile, "{") && seccompPathOpt != "" {
+			securityOpt = seccompPathOpt
+		}
+		out = append(out, securityOpt)
+	}
+	return out
+}
+
+// isSeccompProfilePath returns true if the seccomp profile is the path of a file
+// instead of 'unconfined' or the JSON of the profile.
+func isSeccompProfilePath(profile string) bool {
+	return profile != "" && profile != "unconfined" && !strings.HasPrefix(profile, "{")
+}
+
+// parseMBSize returns a size in bytes, e.g. of '256m'. A number without unit is the
+// size in MBs, which shm_size, memory and memory_swap were before they accepted units.
+func parseMBSize(value string) (int64, error) {
+	if size, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if size < 0 {
+			return 0, fmt.Errorf("the size must not be negative")
+		}
+		return size * 1024 * 1024, nil
+	}
+	return units.RAMInBytes(value)
+}
+
+// parseMemorySwap returns the memory_swap in bytes, which is -1 for unlimited swap.
+func parseMemorySwap(value string) (int64, error) {
+	if value == "-1" {
+		return -1, nil
+	}
+	return parseMBSize(value)
+}
+
+// flattenMBSize returns a size in MBs, unless the configured value is the same size
+// with a unit. A size of 0 is an unset limit, unless 0 is configured.
+func flattenMBSize(size int64, stateSize string) string {
+	if size == 0 && stateSize == "" {
+		return ""
+	}
+	if configured, err := parseMBSize(stateSize); err == nil && configured == size {
+		return stateSize
+	}
+	if size%(1024*1024) != 0 {
+		return fmt.Sprintf("%db", size)
+	}
+	return strconv.FormatInt(size/1024/1024, 10)
+}
+
+// suppressEquivalentMBSize suppresses the diff of the same sizes, e.g. of 1g and
+// 1024. An unset size is 0, which is how the limits without a value were stored
+// before they accepted units.
+func suppressEquivalentMBSize(k, oldV, newV string, d *schema.ResourceData) bool {
+	parse := func(value string) (int64, error) {
+		if value == "" {
+			return 0, nil
+		}
+		return parseMemorySwap(value)
+	}
+	oldSize, oldErr := parse(oldV)
+	newSize, newErr := parse(newV)
+	return oldErr == nil && newErr == nil && oldSize == newSize
+}
+
+// parseCpus returns the number of CPUs in billionths, e.g. of '1.5'. An empty
+// value is no limit.
+func parseCpus(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	var cpus opts.NanoCPUs
+	if err := cpus.Set(value); err != nil {
+		return 0, err
+	}
+	if cpus.Value() < 0 {
+		return 0, fmt.Errorf("the number of CPUs must not be negative")
+	}
+	return cpus.Value(), nil
+}
+
+// flattenCpus returns the number of CPUs, unless the configured value is the
+// same number written differently.
+func flattenCpus(nanoCPUs int64, stateCpus string) string {
+	if configured, err := parseCpus(stateCpus); err == nil && configured == nanoCPUs {
+		return stateCpus
+	}
+	if nanoCPUs == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(float64(nanoCPUs)/1e9, 'f', -1, 64)
+}
+
 func deviceSetToDockerDevices(devices *schema.Set) []container.DeviceMapping {
 	retDevices := []container.DeviceMapping{}
 	for _, deviceInt := range devices.List() {
@@ -270,19 +645,22 @@ func getDockerContainerMounts(container types.ContainerJSON) []map[string]interf
 			"type":      mount.Type,
 			"read_only": mount.ReadOnly,
 		}
-		if mount.BindOptions != nil {
-			m["bind_options"] = []map[string]interface{}{
-				{
-					"propagation": mount.BindOptions.Propagation,
-				},
+		if mount.BindOptions != nil || mount.Consistency != "" {
+			bindOptions := map[string]interface{}{
+				"consistency": mount.Consistency,
+			}
+			if mount.BindOptions != nil {
+				bindOptions["propagation"] = mount.BindOptions.Propagation
+				bindOptions["non_recursive"] = mount.BindOptions.NonRecursive
 			}
+			m["bind_options"] = []map[string]interface{}{bindOptions}
 		}
 		if mount.VolumeOptions != nil {
 			labels := []map[string]string{}
 			for k, v := range mount.VolumeOptions.Labels {
 				labels = append(labels, map[string]string{
-					"label":  k,
-					"volume": v,
+					"label": k,
+					"value": v,
 				})
 			}
 			opt := map[string]interface{}{
@@ -314,7 +692,8 @@ func getDockerContainerMounts(container types.ContainerJSON) []map[string]interf
 func flattenExtraHosts(in []string) []interface{} {
 	extraHosts := make([]interface{}, len(in))
 	for i, extraHost := range in {
-		extraHostSplit := strings.Split(extraHost, ":")
+		// the IP address can be an IPv6 address
+		extraHostSplit := strings.SplitN(extraHost, ":", 2)
 		extraHosts[i] = map[string]interface{}{
 			"host": extraHostSplit[0],
 			"ip":   extraHostSplit[1],
@@ -349,3 +728,46 @@ func flattenDevices(in []container.DeviceMapping) []interface{} {
 
 	return devices
 }
+
+func deviceRequestListToDockerDeviceRequests(deviceRequests []interface{}) ([]container.DeviceRequest, error) {
+	retDeviceRequests := []container.DeviceRequest{}
+	for _, deviceRequestInt := range deviceRequests {
+		deviceRequestMap := deviceRequestInt.(map[string]interface{})
+		deviceIDs := stringListToStringSlice(deviceRequestMap["device_ids"].([]interface{}))
+		count := deviceRequestMap["count"].(int)
+		if count != 0 && len(deviceIDs) > 0 {
+			return nil, errors.New("count and device_ids cannot be set both")
+		}
+
+		deviceRequest := container.DeviceRequest{
+			Driver:    deviceRequestMap["driver"].(string),
+			Count:     count,
+			DeviceIDs: deviceIDs,
+			Options:   mapTypeMapValsToString(deviceRequestMap["options"].(map[string]interface{})),
+		}
+		if capabilities := stringListToStringSlice(deviceRequestMap["capabilities"].([]interface{})); len(capabilities) > 0 {
+			deviceRequest.Capabilities = [][]string{capabilities}
+		}
+		retDeviceRequests = append(retDeviceRequests, deviceRequest)
+	}
+	return retDeviceRequests, nil
+}
+
+func flattenDeviceRequests(in []container.DeviceRequest) []interface{} {
+	deviceRequests := make([]interface{}, len(in))
+	for i, deviceRequest := range in {
+		capabilities := []string{}
+		for _, c := range deviceRequest.Capabilities {
+			capabilities = append(capabilities, c...)
+		}
+		deviceRequests[i] = map[string]interface{}{
+			"driver":       deviceRequest.Driver,
+			"count":        deviceRequest.Count,
+			"device_ids":   deviceRequest.DeviceIDs,
+			"capabilities": capabilities,
+			"options":      deviceRequest.Options,
+		}
+	}
+
+	return deviceRequests
+}
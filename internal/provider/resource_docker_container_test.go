@@ -4,13 +4,17 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -19,7 +23,14 @@ import (
 	"github.com/docker/docker/api/types/container"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -33,6 +44,803 @@ func TestMapTypeMapValsToStringSlice(t *testing.T) {
 	}
 }
 
+func TestResourceDockerContainerUpdatePartitioning(t *testing.T) {
+	resourceSchema := resourceDockerContainer().Schema
+
+	contains := func(attrs []string, name string) bool {
+		for _, attr := range attrs {
+			if attr == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, name := range append(containerUpdatableAttributes, containerProviderAttributes...) {
+		s, ok := resourceSchema[name]
+		if !ok {
+			t.Fatalf("attribute %q is not part of the container schema", name)
+		}
+		if s.ForceNew {
+			t.Errorf("attribute %q should be changed without recreating the container", name)
+		}
+	}
+
+	for name, s := range resourceSchema {
+		if !s.Optional && !s.Required {
+			// computed only attributes are never changed by the user
+			continue
+		}
+		if contains(containerUpdatableAttributes, name) || contains(containerProviderAttributes, name) {
+			continue
+		}
+		if !s.ForceNew {
+			t.Errorf("attribute %q cannot be updated in place and should force a new container", name)
+		}
+	}
+}
+
+func TestContainerStopTimeout(t *testing.T) {
+	if timeout := containerStopTimeout(30, 10); timeout == nil || *timeout != 30*time.Second {
+		t.Fatalf("Expected destroy_grace_seconds to take precedence, got %v", timeout)
+	}
+	if timeout := containerStopTimeout(0, 10); timeout != nil {
+		t.Fatalf("Expected the stop timeout of the container to be used, got %v", *timeout)
+	}
+	if timeout := containerStopTimeout(0, 0); timeout == nil || *timeout != 0 {
+		t.Fatalf("Expected the container to be stopped immediately, got %v", timeout)
+	}
+}
+
+func TestContainerStoppedRefreshFunc(t *testing.T) {
+	inspections := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/containers/db/json"):
+			// the container is still flushing its data on the first inspect
+			inspections++
+			fmt.Fprintf(w, `{"Id": "db", "State": {"Status": "running", "Running": %t}}`, inspections == 1)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "No such container"}`)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+	client, err := providerConfig.MakeClientForConfig(context.Background(), providerConfig.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refresh := containerStoppedRefreshFunc(context.Background(), client, "db")
+	for _, expected := range []string{"running", "exited"} {
+		if _, state, err := refresh(); err != nil || state != expected {
+			t.Fatalf("Expected the container to be %s, got %s: %v", expected, state, err)
+		}
+	}
+	if _, state, err := containerStoppedRefreshFunc(context.Background(), client, "gone")(); err != nil || state != "removed" {
+		t.Fatalf("Expected the container to be removed, got %s: %v", state, err)
+	}
+}
+
+func TestWaitForDependency(t *testing.T) {
+	inspections := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/containers/db/json"):
+			// the database gets healthy on the second inspect
+			inspections++
+			health := "starting"
+			if inspections > 1 {
+				health = "healthy"
+			}
+			fmt.Fprintf(w, `{"Id": "db", "State": {"Status": "running", "Running": true, "Health": {"Status": %q}}}`, health)
+		case strings.HasSuffix(r.URL.Path, "/containers/cache/json"):
+			fmt.Fprint(w, `{"Id": "cache", "State": {"Status": "running", "Running": true}}`)
+		case strings.HasSuffix(r.URL.Path, "/containers/init/json"):
+			fmt.Fprint(w, `{"Id": "init", "State": {"Status": "exited", "ExitCode": 1}, "HostConfig": {"RestartPolicy": {"Name": "no"}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "No such container"}`)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+	client, err := providerConfig.MakeClientForConfig(context.Background(), providerConfig.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor := func(container, condition string) error {
+		return waitForDependency(context.Background(), client, map[string]interface{}{
+			"container": container,
+			"condition": condition,
+			"timeout":   5,
+			"interval":  1,
+		})
+	}
+
+	if err := waitFor("db", "healthy"); err != nil || inspections != 2 {
+		t.Fatalf("Expected to wait until the container is healthy, got %v after %d inspections", err, inspections)
+	}
+	if err := waitFor("cache", "running"); err != nil {
+		t.Fatalf("Expected the running container to be ready, got %s", err)
+	}
+	for container, expected := range map[string]string{
+		"cache":   "has no health check",
+		"init":    "is exited with exit code 1 and is not restarted",
+		"missing": "does not exist",
+	} {
+		if err := waitFor(container, "healthy"); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Expected the wait for %s to fail with %q, got %v", container, expected, err)
+		}
+	}
+}
+
+func TestValidateRestartPolicy(t *testing.T) {
+	if err := validateRestartPolicy("on-failure", 3); err != nil {
+		t.Fatalf("Expected a retry count to be valid for on-failure, got %s", err)
+	}
+	if err := validateRestartPolicy("always", 0); err != nil {
+		t.Fatalf("Expected no retry count to be valid for always, got %s", err)
+	}
+	if err := validateRestartPolicy("unless-stopped", 3); err == nil {
+		t.Fatal("Expected a retry count to be invalid for unless-stopped")
+	}
+}
+
+func TestContainerPortsError(t *testing.T) {
+	port := func(internal int, external interface{}, ip string) cty.Value {
+		externalValue := cty.NullVal(cty.Number)
+		if external != nil {
+			externalValue = cty.NumberIntVal(int64(external.(int)))
+		}
+		ipValue := cty.NullVal(cty.String)
+		if ip != "" {
+			ipValue = cty.StringVal(ip)
+		}
+		return cty.ObjectVal(map[string]cty.Value{
+			"internal": cty.NumberIntVal(int64(internal)),
+			"external": externalValue,
+			"ip":       ipValue,
+			"protocol": cty.NullVal(cty.String),
+		})
+	}
+
+	for _, ports := range [][]cty.Value{
+		{port(80, 8080, ""), port(80, 8081, ""), port(443, nil, "")},
+		{port(80, 8080, "127.0.0.1"), port(81, 8080, "10.0.0.1")},
+		{port(80, nil, ""), port(81, nil, "")},
+	} {
+		if err := containerPortsError(cty.ListVal(ports)); err != nil {
+			t.Fatalf("Expected the ports to be valid, got %s", err)
+		}
+	}
+
+	if err := containerPortsError(cty.ListVal([]cty.Value{port(80, nil, ""), port(80, nil, "0.0.0.0")})); err == nil || !strings.Contains(err.Error(), "80/tcp is published more than once") {
+		t.Fatalf("Expected an error for the duplicate port, got %v", err)
+	}
+	if err := containerPortsError(cty.ListVal([]cty.Value{port(80, 8080, ""), port(81, 8080, "")})); err == nil || !strings.Contains(err.Error(), "0.0.0.0:8080/tcp is used by more than one") {
+		t.Fatalf("Expected an error for the duplicate external port, got %v", err)
+	}
+}
+
+func TestContainerStartError(t *testing.T) {
+	err := containerStartError(errors.New("driver failed programming external connectivity on endpoint tf-test: Bind for 0.0.0.0:8080 failed: port is already allocated"))
+	if !strings.Contains(err.Error(), "the port 0.0.0.0:8080 of the Docker host is already used") {
+		t.Fatalf("Expected the error to name the port of the Docker host, got %s", err)
+	}
+	err = containerStartError(errors.New("Error starting userland proxy: listen tcp4 0.0.0.0:5432: bind: address already in use"))
+	if !strings.Contains(err.Error(), "the port 0.0.0.0:5432 of the Docker host") {
+		t.Fatalf("Expected the error to name the port of the Docker host, got %s", err)
+	}
+	if err := containerStartError(errors.New("no such file")); err.Error() != "no such file" {
+		t.Fatalf("Expected other errors to be unchanged, got %s", err)
+	}
+}
+
+func TestPrivilegedContainerWarnings(t *testing.T) {
+	if diags := privilegedContainerWarnings("1000:1000", []string{"NET_RAW"}, false); len(diags) != 0 {
+		t.Fatalf("Expected no warnings for an unprivileged container, got %v", diags)
+	}
+	for _, user := range []string{"", "root", "0:0"} {
+		if diags := privilegedContainerWarnings(user, nil, true); len(diags) != 0 {
+			t.Fatalf("Expected no warnings for the user %q, got %v", user, diags)
+		}
+	}
+	if diags := privilegedContainerWarnings("nginx:nginx", []string{"NET_RAW"}, true); len(diags) != 2 {
+		t.Fatalf("Expected warnings for the user and the dropped capabilities, got %v", diags)
+	}
+}
+
+func TestInteractiveContainerWarnings(t *testing.T) {
+	for _, settings := range [][2]bool{{false, false}, {true, false}, {false, true}} {
+		if diags := interactiveContainerWarnings(settings[0], settings[1]); len(diags) != 0 {
+			t.Fatalf("Expected no warnings for stdin_open %t and attach %t, got %v", settings[0], settings[1], diags)
+		}
+	}
+	if diags := interactiveContainerWarnings(true, true); len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Fatalf("Expected a warning for the attached container with open STDIN, got %v", diags)
+	}
+}
+
+func TestDeviceRequestListToDockerDeviceRequests(t *testing.T) {
+	deviceRequests, err := deviceRequestListToDockerDeviceRequests([]interface{}{
+		map[string]interface{}{
+			"driver":       "nvidia",
+			"count":        -1,
+			"device_ids":   []interface{}{},
+			"capabilities": []interface{}{"gpu"},
+			"options":      map[string]interface{}{},
+		},
+		map[string]interface{}{
+			"driver":       "",
+			"count":        0,
+			"device_ids":   []interface{}{"0", "GPU-3a23c669"},
+			"capabilities": []interface{}{"gpu", "utility"},
+			"options":      map[string]interface{}{"foo": "bar"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []container.DeviceRequest{
+		{Driver: "nvidia", Count: -1, DeviceIDs: []string{}, Capabilities: [][]string{{"gpu"}}, Options: map[string]string{}},
+		{Count: 0, DeviceIDs: []string{"0", "GPU-3a23c669"}, Capabilities: [][]string{{"gpu", "utility"}}, Options: map[string]string{"foo": "bar"}},
+	}
+	if !reflect.DeepEqual(deviceRequests, expected) {
+		t.Fatalf("Expected %#v, got %#v", expected, deviceRequests)
+	}
+
+	flattened := flattenDeviceRequests(deviceRequests)
+	if capabilities := flattened[1].(map[string]interface{})["capabilities"]; !reflect.DeepEqual(capabilities, []string{"gpu", "utility"}) {
+		t.Fatalf("Expected the capabilities to be flattened, got %#v", capabilities)
+	}
+
+	_, err = deviceRequestListToDockerDeviceRequests([]interface{}{
+		map[string]interface{}{
+			"driver":       "",
+			"count":        2,
+			"device_ids":   []interface{}{"0"},
+			"capabilities": []interface{}{},
+			"options":      map[string]interface{}{},
+		},
+	})
+	if err == nil {
+		t.Fatal("Expected an error if count and device_ids are set both")
+	}
+}
+
+func TestGetDockerContainerMounts(t *testing.T) {
+	c := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			HostConfig: &container.HostConfig{
+				Mounts: []mount.Mount{
+					{
+						Type:   mount.TypeVolume,
+						Source: "data",
+						Target: "/data",
+						VolumeOptions: &mount.VolumeOptions{
+							NoCopy: true,
+							Labels: map[string]string{"com.example.backup": "daily"},
+						},
+					},
+					{
+						Type:        mount.TypeBind,
+						Source:      "/srv",
+						Target:      "/srv",
+						ReadOnly:    true,
+						BindOptions: &mount.BindOptions{Propagation: mount.PropagationRPrivate, NonRecursive: true},
+					},
+					{
+						Type:        mount.TypeBind,
+						Source:      "/src",
+						Target:      "/app",
+						Consistency: mount.ConsistencyCached,
+					},
+				},
+			},
+		},
+	}
+
+	mounts := getDockerContainerMounts(c)
+	if len(mounts) != 3 {
+		t.Fatalf("Expected 3 mounts, got %d", len(mounts))
+	}
+
+	volumeOptions := mounts[0]["volume_options"].([]map[string]interface{})[0]
+	expectedLabels := []map[string]string{{"label": "com.example.backup", "value": "daily"}}
+	if !reflect.DeepEqual(volumeOptions["labels"], expectedLabels) {
+		t.Fatalf("Expected labels %v, got %v", expectedLabels, volumeOptions["labels"])
+	}
+	if volumeOptions["no_copy"] != true {
+		t.Fatalf("Expected no_copy to be true, got %v", volumeOptions["no_copy"])
+	}
+
+	bindOptions := mounts[1]["bind_options"].([]map[string]interface{})[0]
+	if bindOptions["propagation"] != mount.PropagationRPrivate || bindOptions["non_recursive"] != true {
+		t.Fatalf("Unexpected bind options %v", bindOptions)
+	}
+
+	bindOptions = mounts[2]["bind_options"].([]map[string]interface{})[0]
+	if bindOptions["consistency"] != mount.ConsistencyCached {
+		t.Fatalf("Expected the consistency of the mount without bind options, got %v", bindOptions)
+	}
+}
+
+func TestContainerPortAddress(t *testing.T) {
+	infos := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: "abc"},
+		NetworkSettings: &types.NetworkSettings{
+			NetworkSettingsBase: types.NetworkSettingsBase{
+				Ports: nat.PortMap{
+					"80/tcp":   []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "32768"}},
+					"8080/tcp": []nat.PortBinding{{HostIP: "192.168.1.10", HostPort: "8080"}},
+				},
+			},
+			Networks: map[string]*network.EndpointSettings{
+				"tf-test": {IPAddress: "172.18.0.2"},
+			},
+		},
+	}
+
+	cases := []struct {
+		DaemonHost string
+		Port       int
+		Expected   string
+	}{
+		{DaemonHost: "unix:///var/run/docker.sock", Port: 80, Expected: "127.0.0.1:32768"},
+		{DaemonHost: "tcp://docker-host:2376", Port: 80, Expected: "docker-host:32768"},
+		{DaemonHost: "ssh://user@docker-host:22", Port: 80, Expected: "docker-host:32768"},
+		{DaemonHost: "tcp://docker-host:2376", Port: 8080, Expected: "192.168.1.10:8080"},
+		{DaemonHost: "unix:///var/run/docker.sock", Port: 5432, Expected: "172.18.0.2:5432"},
+	}
+	for _, tc := range cases {
+		address, err := containerPortAddress(tc.DaemonHost, infos, tc.Port)
+		if err != nil {
+			t.Fatalf("Expected no error for port %d, got %s", tc.Port, err)
+		}
+		if address != tc.Expected {
+			t.Fatalf("Expected address %s for port %d on %s, got %s", tc.Expected, tc.Port, tc.DaemonHost, address)
+		}
+	}
+
+	infos.NetworkSettings.Networks = nil
+	if _, err := containerPortAddress("unix:///var/run/docker.sock", infos, 5432); err == nil {
+		t.Fatal("Expected an error for an unpublished port of a container without IP address")
+	}
+}
+
+func TestCheckContainerPort(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ready" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "http://")
+	ctx := context.Background()
+
+	if err := checkContainerPort(ctx, "tcp", address, "", 0); err != nil {
+		t.Fatalf("Expected the tcp check to succeed, got %s", err)
+	}
+	if err := checkContainerPort(ctx, "http", address, "/ready", 0); err != nil {
+		t.Fatalf("Expected the http check to accept a 2xx status code, got %s", err)
+	}
+	if err := checkContainerPort(ctx, "http", address, "/", 0); err == nil {
+		t.Fatal("Expected the http check to fail on a 503 status code")
+	}
+	if err := checkContainerPort(ctx, "http", address, "/", http.StatusServiceUnavailable); err != nil {
+		t.Fatalf("Expected the http check to accept the configured status code, got %s", err)
+	}
+
+	server.Close()
+	if err := checkContainerPort(ctx, "tcp", address, "", 0); err == nil {
+		t.Fatal("Expected the tcp check to fail on a closed port")
+	}
+}
+
+func TestFlattenContainerPorts(t *testing.T) {
+	ports := flattenContainerPorts(nat.PortMap{
+		"8080/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "32768"}, {HostIP: "::", HostPort: "32768"}},
+		"53/udp":   []nat.PortBinding{{HostIP: "", HostPort: "5353"}},
+		"53/tcp":   []nat.PortBinding{{HostIP: "127.0.0.1", HostPort: "5353"}},
+		"9000/tcp": nil,
+	})
+	expected := []interface{}{
+		map[string]interface{}{"internal": 53, "external": 5353, "ip": "127.0.0.1", "protocol": "tcp"},
+		map[string]interface{}{"internal": 53, "external": 5353, "ip": "0.0.0.0", "protocol": "udp"},
+		map[string]interface{}{"internal": 8080, "external": 32768, "ip": "0.0.0.0", "protocol": "tcp"},
+	}
+	if !reflect.DeepEqual(ports, expected) {
+		t.Fatalf("Expected ports %v, got %v", expected, ports)
+	}
+
+	stopped := keepAssignedExternalPorts(flattenContainerPorts(nat.PortMap{
+		"8080/tcp": []nat.PortBinding{{HostIP: "", HostPort: ""}},
+	}), expected)
+	if external := stopped[0].(map[string]interface{})["external"]; external != 32768 {
+		t.Fatalf("Expected the assigned external port 32768 to be kept, got %v", external)
+	}
+}
+
+func TestFlattenExtraHosts(t *testing.T) {
+	extraHosts := flattenExtraHosts([]string{"db:10.0.0.2", "host.docker.internal:host-gateway", "ipv6:fe80::1"})
+	expected := []interface{}{
+		map[string]interface{}{"host": "db", "ip": "10.0.0.2"},
+		map[string]interface{}{"host": "host.docker.internal", "ip": "host-gateway"},
+		map[string]interface{}{"host": "ipv6", "ip": "fe80::1"},
+	}
+	if !reflect.DeepEqual(extraHosts, expected) {
+		t.Fatalf("Expected extra hosts %v, got %v", expected, extraHosts)
+	}
+}
+
+func TestFlattenImportedContainer(t *testing.T) {
+	pidsLimit := int64(100)
+	infos := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID: "9a550c0f0163d39d77222d3efd58701b625d47676c25c686c95b5b92d1cba6fd",
+			HostConfig: &container.HostConfig{
+				NetworkMode: "default",
+				Binds:       []string{"/var/log:/logs:ro", "data:/data:rw"},
+				VolumesFrom: []string{"other"},
+				Links:       []string{"/db:/foo/db"},
+				Resources:   container.Resources{PidsLimit: &pidsLimit},
+			},
+		},
+		Config: &container.Config{
+			Env:     []string{"PATH=/usr/local/bin:/usr/bin", "NGINX_VERSION=1.23.3", "DEBUG=1"},
+			Labels:  map[string]string{"maintainer": "NGINX", "env": "prod", "version": "2", "com.docker.compose.project": "web", "desktop.docker.io/binds/0/Source": "/src"},
+			Volumes: map[string]struct{}{"/cache": {}, "/data": {}, "/logs": {}, "/tmp": {}},
+		},
+		NetworkSettings: &types.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				"bridge": {},
+				"backend": {
+					Aliases:    []string{"9a550c0f0163", "web"},
+					IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: "10.0.1.5"},
+				},
+			},
+		},
+	}
+	imageConfig := &container.Config{
+		Env:     []string{"PATH=/usr/local/bin:/usr/bin", "NGINX_VERSION=1.23.3"},
+		Labels:  map[string]string{"maintainer": "NGINX", "version": "1"},
+		Volumes: map[string]struct{}{"/cache": {}},
+	}
+
+	env := flattenImportedEnv(infos.Config.Env, imageConfig.Env)
+	if !reflect.DeepEqual(env, []string{"DEBUG=1"}) {
+		t.Errorf("Expected only the env of the container, got %v", env)
+	}
+
+	labels := flattenImportedLabels(infos.Config.Labels, imageConfig.Labels)
+	expectedLabels := map[string]string{"env": "prod", "version": "2"}
+	if !reflect.DeepEqual(labels, expectedLabels) {
+		t.Errorf("Expected labels %v, got %v", expectedLabels, labels)
+	}
+
+	volumes := flattenImportedVolumes(infos, imageConfig.Volumes)
+	expectedVolumes := []interface{}{
+		map[string]interface{}{"container_path": "/logs", "host_path": "/var/log", "read_only": true},
+		map[string]interface{}{"container_path": "/data", "volume_name": "data", "read_only": false},
+		map[string]interface{}{"from_container": "other"},
+		map[string]interface{}{"container_path": "/tmp"},
+	}
+	if !reflect.DeepEqual(volumes, expectedVolumes) {
+		t.Errorf("Expected volumes %v, got %v", expectedVolumes, volumes)
+	}
+
+	networks := flattenImportedNetworks(infos)
+	expectedNetworks := []interface{}{
+		map[string]interface{}{"name": "backend", "aliases": []string{"web"}, "ipv4_address": "10.0.1.5", "ipv6_address": ""},
+	}
+	if !reflect.DeepEqual(networks, expectedNetworks) {
+		t.Errorf("Expected networks %v, got %v", expectedNetworks, networks)
+	}
+
+	unsupported := unsupportedContainerSettings(infos)
+	expectedUnsupported := []string{"the links /db:/foo/db", "the pids limit 100"}
+	if !reflect.DeepEqual(unsupported, expectedUnsupported) {
+		t.Errorf("Expected unsupported settings %v, got %v", expectedUnsupported, unsupported)
+	}
+}
+
+func TestVolumeSetToDockerVolumesSELinuxRelabel(t *testing.T) {
+	volumesSchema := resourceDockerContainer().Schema["volumes"].Elem.(*schema.Resource)
+	volumes := schema.NewSet(schema.HashResource(volumesSchema), []interface{}{
+		map[string]interface{}{"container_path": "/data", "host_path": "/srv/data", "volume_name": "", "from_container": "", "read_only": false, "selinux_relabel": "shared"},
+		map[string]interface{}{"container_path": "/config", "host_path": "/srv/config", "volume_name": "", "from_container": "", "read_only": true, "selinux_relabel": "private"},
+		map[string]interface{}{"container_path": "/logs", "host_path": "/var/log", "volume_name": "", "from_container": "", "read_only": false, "selinux_relabel": ""},
+	})
+	_, binds, _, err := volumeSetToDockerVolumes(volumes)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	sort.Strings(binds)
+	expectedBinds := []string{"/srv/config:/config:ro,Z", "/srv/data:/data:rw,z", "/var/log:/logs:rw"}
+	if !reflect.DeepEqual(binds, expectedBinds) {
+		t.Fatalf("Expected binds %v, got %v", expectedBinds, binds)
+	}
+
+	named := schema.NewSet(schema.HashResource(volumesSchema), []interface{}{
+		map[string]interface{}{"container_path": "/data", "host_path": "", "volume_name": "data", "from_container": "", "read_only": false, "selinux_relabel": "shared"},
+	})
+	if _, _, _, err := volumeSetToDockerVolumes(named); err == nil {
+		t.Fatal("Expected an error for the relabeling of a named volume")
+	}
+
+	infos := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			HostConfig: &container.HostConfig{Binds: expectedBinds},
+		},
+		Config: &container.Config{},
+	}
+	expectedVolumes := []interface{}{
+		map[string]interface{}{"container_path": "/config", "host_path": "/srv/config", "read_only": true, "selinux_relabel": "private"},
+		map[string]interface{}{"container_path": "/data", "host_path": "/srv/data", "read_only": false, "selinux_relabel": "shared"},
+		map[string]interface{}{"container_path": "/logs", "host_path": "/var/log", "read_only": false},
+	}
+	if imported := flattenImportedVolumes(infos, nil); !reflect.DeepEqual(imported, expectedVolumes) {
+		t.Fatalf("Expected imported volumes %v, got %v", expectedVolumes, imported)
+	}
+}
+
+func TestFlattenContainerNetworksAdvanced(t *testing.T) {
+	infos := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:         "9a550c0f0163d39d77222d3efd58701b625d47676c25c686c95b5b92d1cba6fd",
+			HostConfig: &container.HostConfig{NetworkMode: "default"},
+		},
+		NetworkSettings: &types.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				"bridge": {NetworkID: "1d2b5e0b4e5e"},
+				"backend": {
+					NetworkID:  "6f0d7a1c3b9e8f7a6d5c4b3a29180716f5e4d3c2b1a0f9e8d7c6b5a493827160",
+					Aliases:    []string{"9a550c0f0163", "db"},
+					IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: "10.0.1.5"},
+				},
+			},
+		},
+	}
+
+	networks := flattenContainerNetworksAdvanced(infos, []string{"6f0d7a1c3b9e8f7a6d5c4b3a29180716f5e4d3c2b1a0f9e8d7c6b5a493827160"})
+	expected := []interface{}{
+		map[string]interface{}{"name": "6f0d7a1c3b9e8f7a6d5c4b3a29180716f5e4d3c2b1a0f9e8d7c6b5a493827160", "aliases": []string{"db"}, "ipv4_address": "10.0.1.5", "ipv6_address": ""},
+	}
+	if !reflect.DeepEqual(networks, expected) {
+		t.Errorf("Expected the network to keep its configured ID, got %v", networks)
+	}
+
+	networks = flattenContainerNetworksAdvanced(infos, nil)
+	if name := networks[0].(map[string]interface{})["name"]; name != "backend" {
+		t.Errorf("Expected the name of the network, got %v", name)
+	}
+}
+
+func TestResourceDockerContainerCreateWarnings(t *testing.T) {
+	warning := "Your kernel does not support swap limit capabilities or the cgroup is not mounted. Memory limited without swap."
+	containerID := "9a550c0f0163d39d77222d3efd58701b625d47676c25c686c95b5b92d1cba6fd"
+	// a Docker host which only knows the endpoints to create and read a container
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/containers/create"):
+			fmt.Fprintf(w, `{"Id": %q, "Warnings": [%q]}`, containerID, warning)
+		case strings.HasSuffix(r.URL.Path, "/containers/json"):
+			fmt.Fprintf(w, `[{"Id": %q, "Names": ["/tf-test"]}]`, containerID)
+		case strings.HasSuffix(r.URL.Path, "/containers/"+containerID+"/json"):
+			fmt.Fprintf(w, `{"Id": %q, "Name": "/tf-test", "Image": "sha256:abc", "State": {"Running": false}, "Config": {}, "HostConfig": {}}`, containerID)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+	d := schema.TestResourceDataRaw(t, resourceDockerContainer().Schema, map[string]interface{}{
+		"name":            "tf-test",
+		"image":           "busybox:latest",
+		"start":           false,
+		"must_run":        false,
+		"pull_if_missing": false,
+	})
+
+	diags := resourceDockerContainerCreate(context.Background(), d, providerConfig)
+	if diags.HasError() {
+		t.Fatalf("Expected the container to be created, got %#v", diags)
+	}
+	if len(diags) != 1 || diags[0].Severity != diag.Warning || diags[0].Detail != warning {
+		t.Fatalf("Expected the warning of the Docker host, got %#v", diags)
+	}
+	if d.Id() != containerID {
+		t.Fatalf("Expected the container ID %s, got %s", containerID, d.Id())
+	}
+}
+
+func TestResourceDockerContainerReadAutoRemoved(t *testing.T) {
+	containerID := "9a550c0f0163d39d77222d3efd58701b625d47676c25c686c95b5b92d1cba6fd"
+	// a Docker host which already removed the container
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/containers/json"):
+			fmt.Fprint(w, `[]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+	for _, tc := range []struct {
+		rm       bool
+		mustRun  bool
+		expected string
+	}{
+		{rm: true, mustRun: false, expected: containerID},
+		{rm: true, mustRun: true, expected: ""},
+		{rm: false, mustRun: false, expected: ""},
+	} {
+		d := schema.TestResourceDataRaw(t, resourceDockerContainer().Schema, map[string]interface{}{
+			"name":     "tf-test",
+			"image":    "busybox:latest",
+			"rm":       tc.rm,
+			"must_run": tc.mustRun,
+		})
+		d.SetId(containerID)
+
+		if diags := resourceDockerContainerRead(context.Background(), d, providerConfig); diags.HasError() {
+			t.Fatalf("Expected the removed container to be read, got %#v", diags)
+		}
+		if d.Id() != tc.expected {
+			t.Errorf("Expected the ID %q with rm=%t and must_run=%t, got %q", tc.expected, tc.rm, tc.mustRun, d.Id())
+		}
+	}
+}
+
+func TestSecurityOptsWithSeccompProfileFile(t *testing.T) {
+	profilePath := filepath.Join(t.TempDir(), "seccomp.json")
+	if err := os.WriteFile(profilePath, []byte("{\n  \"defaultAction\": \"SCMP_ACT_ERRNO\"\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stateOpts := []string{"no-new-privileges", "seccomp=" + profilePath}
+
+	securityOpts, err := securityOptsToDockerSecurityOpts(schema.NewSet(schema.HashString, []interface{}{stateOpts[0], stateOpts[1]}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	sort.Strings(securityOpts)
+	expected := []string{"no-new-privileges", `seccomp={"defaultAction":"SCMP_ACT_ERRNO"}`}
+	if !reflect.DeepEqual(securityOpts, expected) {
+		t.Fatalf("Expected security options %v, got %v", expected, securityOpts)
+	}
+
+	// the profile the daemon returns is read back as the path
+	if flattened := flattenSecurityOpts(securityOpts, stateOpts); !reflect.DeepEqual(flattened, []string{"no-new-privileges", "seccomp=" + profilePath}) {
+		t.Fatalf("Expected the seccomp profile to be read back as path, got %v", flattened)
+	}
+	if flattened := flattenSecurityOpts([]string{"seccomp=unconfined"}, stateOpts); !reflect.DeepEqual(flattened, []string{"seccomp=unconfined"}) {
+		t.Fatalf("Expected other seccomp profiles to be read back unchanged, got %v", flattened)
+	}
+
+	if _, err := securityOptsToDockerSecurityOpts(schema.NewSet(schema.HashString, []interface{}{"seccomp=/does/not/exist.json"})); err == nil {
+		t.Fatal("Expected an error for a missing seccomp profile")
+	}
+}
+
+func TestMBSize(t *testing.T) {
+	for value, expected := range map[string]int64{
+		"128":  128 * 1024 * 1024,
+		"256m": 256 * 1024 * 1024,
+		"1g":   1024 * 1024 * 1024,
+		"512k": 512 * 1024,
+	} {
+		size, err := parseMBSize(value)
+		if err != nil || size != expected {
+			t.Errorf("Expected %q to be %d bytes, got %d: %v", value, expected, size, err)
+		}
+	}
+
+	if flattened := flattenMBSize(1024*1024*1024, "1g"); flattened != "1g" {
+		t.Errorf("Expected the configured size to be kept, got %s", flattened)
+	}
+	if flattened := flattenMBSize(64*1024*1024, ""); flattened != "64" {
+		t.Errorf("Expected the default size in MBs, got %s", flattened)
+	}
+	if flattened := flattenMBSize(64*1024*1024, "128m"); flattened != "64" {
+		t.Errorf("Expected a changed size in MBs, got %s", flattened)
+	}
+	if flattened := flattenMBSize(1000, ""); flattened != "1000b" {
+		t.Errorf("Expected a size which is no multiple of a MB in bytes, got %s", flattened)
+	}
+	if flattened := flattenMBSize(0, ""); flattened != "" {
+		t.Errorf("Expected an unset limit to stay unset, got %s", flattened)
+	}
+
+	if swap, err := parseMemorySwap("-1"); err != nil || swap != -1 {
+		t.Errorf("Expected -1 to be unlimited swap, got %d: %v", swap, err)
+	}
+	for _, tc := range []struct {
+		oldV, newV string
+		suppress   bool
+	}{
+		{"512", "512m", true},
+		{"1024", "1g", true},
+		{"0", "", true},
+		{"-1", "-1", true},
+		{"512", "1g", false},
+		{"", "512m", false},
+	} {
+		if suppress := suppressEquivalentMBSize("memory", tc.oldV, tc.newV, nil); suppress != tc.suppress {
+			t.Errorf("Expected the diff from %q to %q to be suppressed: %t, got %t", tc.oldV, tc.newV, tc.suppress, suppress)
+		}
+	}
+}
+
+func TestCpus(t *testing.T) {
+	for value, expected := range map[string]int64{
+		"":     0,
+		"1":    1000000000,
+		"1.5":  1500000000,
+		"0.25": 250000000,
+	} {
+		cpus, err := parseCpus(value)
+		if err != nil || cpus != expected {
+			t.Errorf("Expected %q to be %d nano CPUs, got %d: %v", value, expected, cpus, err)
+		}
+	}
+
+	if flattened := flattenCpus(1500000000, "1.50"); flattened != "1.50" {
+		t.Errorf("Expected the configured number of CPUs to be kept, got %s", flattened)
+	}
+	if flattened := flattenCpus(1500000000, "2"); flattened != "1.5" {
+		t.Errorf("Expected a changed number of CPUs, got %s", flattened)
+	}
+	if flattened := flattenCpus(0, ""); flattened != "" {
+		t.Errorf("Expected no limit, got %s", flattened)
+	}
+}
+
+func TestIsNoSuchImageError(t *testing.T) {
+	if !isNoSuchImageError(errdefs.NotFound(errors.New("No such image: busybox:latest"))) {
+		t.Fatal("Expected a missing image to be detected")
+	}
+	if isNoSuchImageError(errdefs.NotFound(errors.New("network foo not found"))) {
+		t.Fatal("Expected a missing network not to be detected as missing image")
+	}
+	if isNoSuchImageError(errors.New("No such image: busybox:latest")) {
+		t.Fatal("Expected only not found errors to be detected")
+	}
+}
+
 func TestAccDockerContainer_private_image(t *testing.T) {
 	registry := "127.0.0.1:15000"
 	image := "127.0.0.1:15000/tftest-service:v1"
@@ -107,6 +915,81 @@ func TestAccDockerContainer_basic(t *testing.T) {
 	})
 }
 
+func TestAccDockerContainer_updateInPlace(t *testing.T) {
+	resourceName := "docker_container.foo"
+	var c types.ContainerJSON
+	var createdID string
+
+	testCheckSameContainer := func(*terraform.State) error {
+		if c.ID != createdID {
+			return fmt.Errorf("Container was recreated: expected ID %s, got %s", createdID, c.ID)
+		}
+		if c.HostConfig.Memory != 512*1024*1024 {
+			return fmt.Errorf("Container has wrong memory setting: %d", c.HostConfig.Memory)
+		}
+		if c.HostConfig.RestartPolicy.Name != "on-failure" {
+			return fmt.Errorf("Container has wrong restart policy: %s", c.HostConfig.RestartPolicy.Name)
+		}
+		return nil
+	}
+
+	testCheckMemoryWithUnits := func(*terraform.State) error {
+		if c.ID != createdID {
+			return fmt.Errorf("Container was recreated: expected ID %s, got %s", createdID, c.ID)
+		}
+		if c.HostConfig.Memory != 1024*1024*1024 || c.HostConfig.MemorySwap != 3*1024*1024*1024 {
+			return fmt.Errorf("Container has wrong memory settings: %d and swap %d", c.HostConfig.Memory, c.HostConfig.MemorySwap)
+		}
+		return nil
+	}
+
+	testCheckNewContainer := func(*terraform.State) error {
+		if c.ID == createdID {
+			return fmt.Errorf("Container was not recreated: %s", c.ID)
+		}
+		return nil
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: loadTestConfiguration(t, RESOURCE, "docker_container", "testAccDockerContainerConfig"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccContainerRunning(resourceName, &c),
+					func(*terraform.State) error {
+						createdID = c.ID
+						return nil
+					},
+				),
+			},
+			{
+				Config: loadTestConfiguration(t, RESOURCE, "docker_container", "testAccDockerContainerUpdateConfig"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccContainerRunning(resourceName, &c),
+					testCheckSameContainer,
+				),
+			},
+			{
+				Config: loadTestConfiguration(t, RESOURCE, "docker_container", "testAccDockerContainerUpdateMemoryUnitsConfig"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccContainerRunning(resourceName, &c),
+					testCheckMemoryWithUnits,
+					resource.TestCheckResourceAttr(resourceName, "memory", "1g"),
+				),
+			},
+			{
+				Config: loadTestConfiguration(t, RESOURCE, "docker_container", "testAccDockerContainerUpdateRecreateConfig"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccContainerRunning(resourceName, &c),
+					testCheckNewContainer,
+				),
+			},
+		},
+	})
+}
+
 func TestAccDockerContainer_init(t *testing.T) {
 	resourceName := "docker_container.fooinit"
 	var c types.ContainerJSON
@@ -281,6 +1164,42 @@ func TestAccDockerContainer_mounts(t *testing.T) {
 	})
 }
 
+func TestAccDockerContainer_sharedVolumeReadOnly(t *testing.T) {
+	var writer, reader types.ContainerJSON
+
+	testCheckMount := func(c *types.ContainerJSON, readWrite bool) resource.TestCheckFunc {
+		return func(*terraform.State) error {
+			if len(c.Mounts) != 1 {
+				return fmt.Errorf("Incorrect number of mounts: expected 1, got %d", len(c.Mounts))
+			}
+			if c.Mounts[0].RW != readWrite {
+				return fmt.Errorf("Bad mount of volume %s: expected RW %t, got %t", c.Mounts[0].Name, readWrite, c.Mounts[0].RW)
+			}
+			return nil
+		}
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: loadTestConfiguration(t, RESOURCE, "docker_container", "testAccDockerContainerSharedVolumeConfig"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccContainerRunning("docker_container.writer", &writer),
+					testAccContainerRunning("docker_container.reader", &reader),
+					testCheckMount(&writer, true),
+					testCheckMount(&reader, false),
+				),
+			},
+			{
+				Config:   loadTestConfiguration(t, RESOURCE, "docker_container", "testAccDockerContainerSharedVolumeConfig"),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
 func TestAccDockerContainer_tmpfs(t *testing.T) {
 	var c types.ContainerJSON
 
@@ -626,6 +1545,9 @@ func TestAccDockerContainer_customized(t *testing.T) {
 		if c.HostConfig.PidMode != "host" {
 			return fmt.Errorf("Container doesn't have a correct pid mode")
 		}
+		if c.HostConfig.UTSMode != "host" {
+			return fmt.Errorf("Container doesn't have a correct uts mode")
+		}
 		if c.HostConfig.UsernsMode != "testuser:231072:65536" {
 			return fmt.Errorf("Container doesn't have a correct userns mode")
 		}
@@ -657,6 +1579,34 @@ func TestAccDockerContainer_customized(t *testing.T) {
 					testAccContainerRunning("docker_container.foo", &c),
 					testCheck,
 					testCheckLabelMap("docker_container.foo", "labels", map[string]string{"env": "prod", "role": "test", "maintainer": "NGINX Docker Maintainers <docker-maint@nginx.com>"}),
+					resource.TestCheckResourceAttr("docker_container.foo", "all_labels.env", "prod"),
+					resource.TestCheckResourceAttr("docker_container.foo", "all_labels.maintainer", "NGINX Docker Maintainers <docker-maint@nginx.com>"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDockerContainer_shmSizeWithUnit(t *testing.T) {
+	var c types.ContainerJSON
+
+	testCheck := func(*terraform.State) error {
+		if c.HostConfig.ShmSize != (256 * 1024 * 1024) {
+			return fmt.Errorf("Container has wrong shared memory setting: %d", c.HostConfig.ShmSize)
+		}
+		return nil
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: loadTestConfiguration(t, RESOURCE, "docker_container", "testAccDockerContainerShmSizeUnitsConfig"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccContainerRunning("docker_container.foo", &c),
+					testCheck,
+					resource.TestCheckResourceAttr("docker_container.foo", "shm_size", "256m"),
 				),
 			},
 		},
@@ -1015,6 +1965,30 @@ func TestAccDockerContainer_noUploadContentsConfig(t *testing.T) {
 	})
 }
 
+func TestAccDockerContainer_emptyEntrypointValue(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				resource "docker_image" "foo" {
+					name         = "nginx:latest"
+					keep_locally = true
+				}
+
+				resource "docker_container" "foo" {
+					name       = "tf-test"
+					image      = docker_image.foo.image_id
+					entrypoint = ["", "sh"]
+				}
+				`,
+				ExpectError: regexp.MustCompile(`.*values for entrypoint may not be empty.*`),
+			},
+		},
+	})
+}
+
 func TestAccDockerContainer_device(t *testing.T) {
 	var c types.ContainerJSON
 	ctx := context.Background()
@@ -1083,6 +2057,27 @@ func TestAccDockerContainer_device(t *testing.T) {
 	})
 }
 
+func TestAccDockerContainer_waitForPort(t *testing.T) {
+	var c types.ContainerJSON
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: loadTestConfiguration(t, RESOURCE, "docker_container", "testAccDockerContainerWaitForPortConfig"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccContainerRunning("docker_container.foo", &c),
+					resource.TestCheckResourceAttr("docker_container.foo", "wait_for_port.#", "1"),
+					resource.TestCheckResourceAttr("docker_container.foo", "wait_for_port.0.port", "80"),
+					resource.TestCheckResourceAttr("docker_container.foo", "wait_for_port.0.protocol", "http"),
+					resource.TestCheckResourceAttrSet("docker_container.foo", "wait_for_port_elapsed_seconds"),
+				),
+			},
+		},
+	})
+}
+
 func TestAccDockerContainer_port_internal(t *testing.T) {
 	var c types.ContainerJSON
 
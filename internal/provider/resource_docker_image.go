@@ -12,6 +12,7 @@ func resourceDockerImage() *schema.Resource {
 		ReadContext:   resourceDockerImageRead,
 		UpdateContext: resourceDockerImageUpdate,
 		DeleteContext: resourceDockerImageDelete,
+		CustomizeDiff: resourceDockerImageCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -20,10 +21,11 @@ func resourceDockerImage() *schema.Resource {
 				Description: "Unique identifier for this resource. This is not the image ID, but the ID of the resource in the Terraform state. This is used to identify the resource in the Terraform state. To reference the correct image ID, use the `image_id` attribute.",
 			},
 			"name": {
-				Type:        schema.TypeString,
-				Description: "The name of the Docker image, including any tags or SHA256 repo digests.",
-				Required:    true,
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "The name of the Docker image, including any tags or SHA256 repo digests. An image with a digest, e.g. `alpine@sha256:...`, is pulled by the digest and never pulled again, as it can't change. The tag of a name with both a tag and a digest is ignored.",
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validateImageNameDigest(),
 			},
 
 			"override": overrideSchema,
@@ -46,6 +48,12 @@ func resourceDockerImage() *schema.Resource {
 				Computed:    true,
 			},
 
+			"context_hash": {
+				Type:        schema.TypeString,
+				Description: "The sha256 hash of the files of the build context which are not excluded by the `.dockerignore` file, and of the Dockerfile. The image is rebuilt when it changes.",
+				Computed:    true,
+			},
+
 			"keep_locally": {
 				Type:        schema.TypeBool,
 				Description: "If true, then the Docker image won't be deleted on destroy operation. If this is false, it will delete the image from the docker local storage on destroy operation.",
@@ -67,6 +75,14 @@ func resourceDockerImage() *schema.Resource {
 				Optional:    true,
 			},
 
+			"source_tar": {
+				Type:          schema.TypeString,
+				Description:   "Path to a tar archive of the image on the machine running terraform, e.g. written by the [docker_image_save](../data-sources/image_save.md) data source. The image is loaded from the archive instead of being pulled from a registry.",
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"build", "pull_triggers"},
+			},
+
 			"build": {
 				Type:          schema.TypeSet,
 				Description:   "Configuration to build an image. Please see [docker build command reference](https://docs.docker.com/engine/reference/commandline/build/#options) too.",
@@ -75,6 +91,23 @@ func resourceDockerImage() *schema.Resource {
 				ConflictsWith: []string{"pull_triggers"},
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
+						"builder": {
+							Type:        schema.TypeList,
+							Description: "Builds the image on another Docker host, e.g. a dedicated build node, instead of the Docker host of the resource. The config is applied on top of the provider config like `override`, so TLS and SSH work the same way. The built image is transferred to the Docker host of the resource with all its tags, like with `docker save` and `docker load`, and kept on the builder as cache.",
+							Optional:    true,
+							ForceNew:    true,
+							MaxItems:    1,
+							Elem:        overrideSchemaElem,
+						},
+						"triggers": {
+							Type:        schema.TypeMap,
+							Description: "A map of arbitrary strings that, when changed, will force the image to be rebuilt, e.g. when the build depends on something outside of the build context.",
+							Optional:    true,
+							ForceNew:    true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
 						"dockerfile": {
 							Type:        schema.TypeString,
 							Description: "Name of the Dockerfile. Defaults to `Dockerfile`.",
@@ -365,6 +398,29 @@ func resourceDockerImage() *schema.Resource {
 							Optional:    true,
 							ForceNew:    true,
 						},
+						"platforms": {
+							Type:        schema.TypeList,
+							Description: "Builds the image for all of these platforms with `docker buildx build`, e.g. `[\"linux/amd64\", \"linux/arm64\"]`. The `docker` CLI with the buildx plugin must be installed on the machine running terraform. As an image for multiple platforms can not be loaded into the Docker host, `push` must be `true` when more than one platform is given, and the builder must use the `docker-container` or `remote` driver, see `buildx_builder`. The CLI connects to the Docker host with the host and TLS settings of the provider, `ssh_opts` are not supported.",
+							Optional:    true,
+							ForceNew:    true,
+							Elem: &schema.Schema{
+								Type:             schema.TypeString,
+								ValidateDiagFunc: validateImagePlatform(),
+							},
+						},
+						"push": {
+							Type:        schema.TypeBool,
+							Description: "Pushes the image and its tags to the registry as a manifest list instead of loading it into the Docker host, only supported together with `platforms`. The registry credentials of the `docker` CLI are used. The image is not removed from the registry when the resource is destroyed. Defaults to `false`.",
+							Optional:    true,
+							Default:     false,
+							ForceNew:    true,
+						},
+						"buildx_builder": {
+							Type:        schema.TypeString,
+							Description: "The name of the buildx builder for `platforms`, e.g. created with `docker buildx create --driver docker-container`. Defaults to the current builder of the `docker` CLI.",
+							Optional:    true,
+							ForceNew:    true,
+						},
 						"version": {
 							Type:        schema.TypeString,
 							Description: "Version of the underlying builder to use",
@@ -387,11 +443,37 @@ func resourceDockerImage() *schema.Resource {
 				ForceNew:    true,
 			},
 			"platform": {
-				Type:        schema.TypeString,
-				Description: "The platform to use when pulling the image. Defaults to the platform of the current machine.",
-				Optional:    true,
-				Default:     "",
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Description:      "The platform to use when pulling the image in the `os[/arch[/variant]]` format, e.g. `linux/arm64`. A local image for another platform is replaced by pulling the image for this platform. The `image_id` is the ID of the image for this platform. Defaults to the platform of the current machine.",
+				Optional:         true,
+				Default:          "",
+				ForceNew:         true,
+				ValidateDiagFunc: validateImagePlatform(),
+			},
+			"verify_signature": {
+				Type:          schema.TypeList,
+				Description:   "Verifies the cosign signature of the pulled image in its registry. The apply fails if no signature of the image matches the public key. Only signatures made with a key pair are supported, keyless signatures and notation signatures can't be verified.",
+				Optional:      true,
+				ForceNew:      true,
+				MaxItems:      1,
+				ConflictsWith: []string{"build", "source_tar"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"public_key": {
+							Type:        schema.TypeString,
+							Description: "The PEM encoded public key of the signatures, e.g. the `cosign.pub` written by `cosign generate-key-pair`.",
+							Required:    true,
+							ForceNew:    true,
+						},
+						"insecure_skip_verify": {
+							Type:        schema.TypeBool,
+							Description: "If `true`, the verification of TLS certificates of the registry is disabled when the signatures are read. Defaults to `false`",
+							Optional:    true,
+							Default:     false,
+							ForceNew:    true,
+						},
+					},
+				},
 			},
 		},
 	}
@@ -3,23 +3,31 @@ package provider
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/docker/cli/cli/command/image/build"
+	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/versions"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/fileutils"
 	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	homedir "github.com/mitchellh/go-homedir"
@@ -40,22 +48,68 @@ func resourceDockerImageCreate(ctx context.Context, d *schema.ResourceData, meta
 		for _, rawBuild := range value.(*schema.Set).List() {
 			rawBuild := rawBuild.(map[string]interface{})
 
-			err := buildDockerImage(ctx, rawBuild, imageName, client)
+			if len(rawBuild["platforms"].([]interface{})) > 0 {
+				if err := buildxDockerImage(ctx, rawBuild, imageName, meta.(*ProviderConfig).getConfig(d)); err != nil {
+					return diag.FromErr(err)
+				}
+				if rawBuild["push"].(bool) {
+					return readPushedDockerImage(d, meta)
+				}
+				continue
+			}
+
+			builderClient := client
+			if builder, ok := rawBuild["builder"].([]interface{}); ok && len(builder) > 0 {
+				providerConfig := meta.(*ProviderConfig)
+				builderConfig := providerConfig.overrideConfig(newConfigFromOverride(builder))
+				builderClient, err = providerConfig.MakeClientForConfig(ctx, builderConfig)
+				if err != nil {
+					return diag.Errorf("Unable to create client for builder %s: %s", builderConfig.Host, err)
+				}
+			}
+
+			err := buildDockerImage(ctx, rawBuild, imageName, builderClient)
 			if err != nil {
 				return diag.FromErr(err)
 			}
+
+			if builderClient != client {
+				if err := transferImage(ctx, builderClient, client, imageTags(imageName, rawBuild)); err != nil {
+					return diag.Errorf("Unable to transfer image %s from the builder: %s", imageName, err)
+				}
+			}
+		}
+	}
+	if sourceTar, ok := d.GetOk("source_tar"); ok {
+		if err := loadImageFromArchive(ctx, client, sourceTar.(string)); err != nil {
+			return diag.Errorf("Unable to load Docker image from '%s': %s", sourceTar.(string), err)
 		}
 	}
-	apiImage, err := findImage(ctx, imageName, client, meta.(*ProviderConfig).AuthConfigs, d.Get("platform").(string))
+	apiImage, err := findImage(ctx, imageName, client, meta.(*ProviderConfig), d.Get("platform").(string))
 	if err != nil {
 		return diag.Errorf("Unable to read Docker image into resource: %s", err)
 	}
+	if ref, ok := imageDigestReference(imageName); ok && matchingRepoDigest(apiImage, ref) == "" {
+		return diag.Errorf("The local image %s does not have the digest %s of %s", apiImage.ID, ref.Digest(), imageName)
+	}
+
+	if value, ok := d.GetOk("verify_signature"); ok {
+		rawVerify := value.([]interface{})[0].(map[string]interface{})
+		repoDigest := determineRepoDigest(imageName, apiImage)
+		if err := verifyImageSignature(repoDigest, rawVerify["public_key"].(string), meta.(*ProviderConfig), rawVerify["insecure_skip_verify"].(bool)); err != nil {
+			return diag.Errorf("Unable to verify the signature of image %s: %s", imageName, err)
+		}
+	}
 
 	d.SetId(apiImage.ID + d.Get("name").(string))
 	return resourceDockerImageRead(ctx, d, meta)
 }
 
 func resourceDockerImageRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if isPushedBuild(d) {
+		return readPushedDockerImage(d, meta)
+	}
+
 	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
 	if err != nil {
 		return diag.Errorf(fmt.Sprint(err))
@@ -69,8 +123,13 @@ func resourceDockerImageRead(ctx context.Context, d *schema.ResourceData, meta i
 	}
 
 	imageName := d.Get("name").(string)
+	lookupName := imageName
+	digestRef, pinned := imageDigestReference(imageName)
+	if pinned {
+		lookupName = reference.FamiliarString(digestRef)
+	}
 
-	foundImage, err := searchLocalImages(ctx, client, data, imageName)
+	foundImage, err := searchLocalImages(ctx, client, data, lookupName)
 	if err != nil {
 		return diag.Errorf("resourceDockerImageRead: error looking up local image %q: %s", imageName, err)
 	}
@@ -81,6 +140,15 @@ func resourceDockerImageRead(ctx context.Context, d *schema.ResourceData, meta i
 	}
 
 	repoDigest := determineRepoDigest(imageName, foundImage)
+	if pinned {
+		// the image of a digest never changes, so it is pulled again if the local one differs
+		repoDigest = matchingRepoDigest(foundImage, digestRef)
+		if repoDigest == "" {
+			log.Printf("[WARN] Local image %s does not have the digest %s of %s anymore, removing it from state", foundImage.ID, digestRef.Digest(), imageName)
+			d.SetId("")
+			return nil
+		}
+	}
 
 	// TODO mavogel: remove the appended name from the ID
 	d.SetId(foundImage.ID + d.Get("name").(string))
@@ -90,6 +158,10 @@ func resourceDockerImageRead(ctx context.Context, d *schema.ResourceData, meta i
 }
 
 func resourceDockerImageUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if isPushedBuild(d) {
+		return readPushedDockerImage(d, meta)
+	}
+
 	// We need to re-read in case switching parameters affects
 	// the value of "latest" or others
 	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
@@ -97,7 +169,7 @@ func resourceDockerImageUpdate(ctx context.Context, d *schema.ResourceData, meta
 		return diag.Errorf(fmt.Sprint(err))
 	}
 	imageName := d.Get("name").(string)
-	_, err2 := findImage(ctx, imageName, client, meta.(*ProviderConfig).AuthConfigs, d.Get("platform").(string))
+	_, err2 := findImage(ctx, imageName, client, meta.(*ProviderConfig), d.Get("platform").(string))
 	if err2 != nil {
 		return diag.Errorf("Unable to read Docker image into resource: %s", err2)
 	}
@@ -106,6 +178,12 @@ func resourceDockerImageUpdate(ctx context.Context, d *schema.ResourceData, meta
 }
 
 func resourceDockerImageDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if isPushedBuild(d) {
+		// the image was only pushed to the registry, where it is kept
+		d.SetId("")
+		return nil
+	}
+
 	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
 	if err != nil {
 		return diag.Errorf(fmt.Sprint(err))
@@ -119,6 +197,76 @@ func resourceDockerImageDelete(ctx context.Context, d *schema.ResourceData, meta
 	return nil
 }
 
+// resourceDockerImageCustomizeDiff plans the context_hash of a built image and forces
+// a rebuild when the build context changed.
+func resourceDockerImageCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	builds := d.Get("build").(*schema.Set).List()
+	if len(builds) == 0 {
+		return nil
+	}
+	rawBuild := builds[0].(map[string]interface{})
+	if err := validateBuildxOptions(rawBuild); err != nil {
+		return err
+	}
+
+	buildContext, _ := rawBuild["context"].(string)
+	if buildContext == "" {
+		// the context is not known yet
+		return nil
+	}
+
+	hash, err := buildContextHash(buildContext, rawBuild["dockerfile"].(string))
+	if err != nil {
+		log.Printf("[WARN] Unable to hash the build context %s: %s", buildContext, err)
+		return nil
+	}
+
+	oldHash, _ := d.GetChange("context_hash")
+	if oldHash.(string) == hash {
+		return nil
+	}
+	if err := d.SetNew("context_hash", hash); err != nil {
+		return err
+	}
+	// images built before the hash was stored are not rebuilt just to store it
+	if d.Id() != "" && oldHash.(string) != "" {
+		return d.ForceNew("context_hash")
+	}
+	return nil
+}
+
+// readPushedDockerImage reads an image which was built for multiple platforms and
+// only pushed to the registry.
+func readPushedDockerImage(d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	imageName := d.Get("name").(string)
+	digest, err := getRegistryImageDigest(imageName, meta.(*ProviderConfig), false)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	ref, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return diag.Errorf("Unable to parse image name %s: %s", imageName, err)
+	}
+
+	d.SetId(digest + imageName)
+	d.Set("image_id", "")
+	d.Set("repo_digest", reference.FamiliarName(ref)+"@"+digest)
+	return nil
+}
+
+// isPushedBuild returns true if the image is built with buildx and pushed to
+// the registry instead of being loaded into the Docker host.
+func isPushedBuild(d *schema.ResourceData) bool {
+	for _, rawBuild := range d.Get("build").(*schema.Set).List() {
+		rawBuild := rawBuild.(map[string]interface{})
+		if len(rawBuild["platforms"].([]interface{})) > 0 && rawBuild["push"].(bool) {
+			return true
+		}
+	}
+	return false
+}
+
 // Helpers
 func searchLocalImages(ctx context.Context, client *client.Client, data Data, imageName string) (*types.ImageSummary, error) {
 	imageInspect, _, err := client.ImageInspectWithRaw(ctx, imageName)
@@ -204,11 +352,112 @@ func fetchLocalImages(ctx context.Context, data *Data, client *client.Client) er
 	return nil
 }
 
-func pullImage(ctx context.Context, data *Data, client *client.Client, authConfig *AuthConfigs, image string, platform string) error {
+// registryMirrorImage returns the name of the image on the healthy registry mirror,
+// or an empty string if the image is not on Docker Hub or no mirror is healthy.
+func (c *ProviderConfig) registryMirrorImage(ctx context.Context, image string) string {
+	if len(c.RegistryMirrors) == 0 {
+		return ""
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil || reference.Domain(named) != "docker.io" {
+		return ""
+	}
+	mirror := c.healthyRegistryMirror(ctx)
+	if mirror == "" {
+		return ""
+	}
+	return mirrorImageName(named, mirror)
+}
+
+// healthyRegistryMirror returns the first of the registry mirrors which is healthy.
+// The mirrors are only checked once, so all images of a run use the same mirror.
+func (c *ProviderConfig) healthyRegistryMirror(ctx context.Context) string {
+	c.healthyMirrorOnce.Do(func() {
+		for _, mirror := range c.RegistryMirrors {
+			if err := checkRegistryMirror(ctx, mirror, c.AuthConfigs, c.MirrorHealthTimeout); err != nil {
+				log.Printf("[WARN] Registry mirror %s is not healthy: %s", mirror, err)
+				continue
+			}
+			log.Printf("[DEBUG] Using registry mirror %s for the images of Docker Hub", mirror)
+			c.healthyMirror = mirror
+			return
+		}
+		log.Printf("[DEBUG] No registry mirror is healthy, pulling the images from Docker Hub")
+	})
+	return c.healthyMirror
+}
+
+// checkRegistryMirror checks that the /v2/ endpoint of the mirror responds with the
+// credentials of the mirror. Mirrors which require a token are healthy if they ask
+// for one, as the token is requested by the daemon when pulling.
+func checkRegistryMirror(ctx context.Context, mirror string, authConfigs *AuthConfigs, timeout time.Duration) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, NormalizeRegistryAddress(mirror)+"/v2/", nil)
+	if err != nil {
+		return err
+	}
+	if authConfig, ok := authConfigs.Get(mirror); ok && authConfig.Username != "" {
+		req.SetBasicAuth(authConfig.Username, authConfig.Password)
+	}
+
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized && strings.HasPrefix(resp.Header.Get("WWW-Authenticate"), "Bearer"):
+		return nil
+	}
+	return fmt.Errorf("unexpected status %s of %s", resp.Status, req.URL)
+}
+
+// mirrorImageName returns the name of an image of Docker Hub on the mirror, e.g.
+// mirror.example.com/library/nginx:latest for nginx. Images with a digest are
+// pulled from Docker Hub, as the pulled image can't be tagged with a digest.
+func mirrorImageName(named reference.Named, mirror string) string {
+	if _, ok := named.(reference.Digested); ok {
+		return ""
+	}
+	named = reference.TagNameOnly(named)
+	tag := named.(reference.Tagged).Tag()
+	address := NormalizeRegistryAddress(mirror)
+	// DevSkim: ignore DS137138
+	address = strings.TrimPrefix(strings.TrimPrefix(address, "http://"), "https://")
+	return address + "/" + reference.Path(named) + ":" + tag
+}
+
+func pullImage(ctx context.Context, data *Data, client *client.Client, providerConfig *ProviderConfig, image string, platform string) error {
+	if mirrorImage := providerConfig.registryMirrorImage(ctx, image); mirrorImage != "" {
+		err := pullImageFromRegistry(ctx, client, providerConfig.AuthConfigs, mirrorImage, platform)
+		if err == nil {
+			err = client.ImageTag(ctx, mirrorImage, image)
+		}
+		if err == nil {
+			log.Printf("[DEBUG] Pulled image %s from registry mirror as %s", image, mirrorImage)
+			// only the tag of the mirror is removed, the image keeps the name of Docker Hub
+			if _, err := client.ImageRemove(ctx, mirrorImage, types.ImageRemoveOptions{}); err != nil {
+				log.Printf("[WARN] Unable to remove the tag %s of the registry mirror: %s", mirrorImage, err)
+			}
+			return nil
+		}
+		log.Printf("[WARN] Unable to pull image %s from registry mirror, pulling it from Docker Hub: %s", image, err)
+	}
+	return pullImageFromRegistry(ctx, client, providerConfig.AuthConfigs, image, platform)
+}
+
+// pullImageFromRegistry pulls the image with the credentials of its registry.
+func pullImageFromRegistry(ctx context.Context, client *client.Client, authConfig *AuthConfigs, image string, platform string) error {
 	pullOpts := parseImageOptions(image)
 
 	auth := types.AuthConfig{}
-	if authConfig, ok := authConfig.Configs[pullOpts.Registry]; ok {
+	if authConfig, ok := authConfig.Get(pullOpts.Registry); ok {
 		auth = authConfig
 	}
 
@@ -226,16 +475,63 @@ func pullImage(ctx context.Context, data *Data, client *client.Client, authConfi
 	}
 	defer out.Close()
 
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(out); err != nil {
-		return err
+	progress := newPullProgress()
+	decoder := json.NewDecoder(out)
+	for {
+		var message jsonmessage.JSONMessage
+		if err := decoder.Decode(&message); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if message.Error != nil {
+			return fmt.Errorf("error pulling image %s: %s", image, message.Error.Message)
+		}
+		if message.ErrorMessage != "" {
+			return fmt.Errorf("error pulling image %s: %s", image, message.ErrorMessage)
+		}
+		if status, ok := progress.update(message); ok {
+			tflog.Info(ctx, "Pulling image", map[string]interface{}{"image": image, "layer": message.ID, "status": status})
+		}
 	}
-	s := buf.String()
-	log.Printf("[DEBUG] pulled image %v: %v", image, s)
+	log.Printf("[DEBUG] pulled image %v", image)
 
 	return nil
 }
 
+// pullProgressStep is the step of the download and extraction percentages of a
+// layer which are reported.
+const pullProgressStep = 25
+
+// pullProgress tracks the state of the layers of an image pull, so only changes
+// are reported instead of each message of the progress stream.
+type pullProgress struct {
+	layers map[string]string
+}
+
+func newPullProgress() *pullProgress {
+	return &pullProgress{layers: map[string]string{}}
+}
+
+// update returns the status of the layer of the message, e.g. 'Downloading 50%',
+// and true if it changed since the last message of the layer.
+func (p *pullProgress) update(message jsonmessage.JSONMessage) (string, bool) {
+	if message.Status == "" {
+		return "", false
+	}
+	status := message.Status
+	if message.Progress != nil && message.Progress.Total > 0 {
+		percent := message.Progress.Current * 100 / message.Progress.Total
+		status = fmt.Sprintf("%s %d%%", status, percent/pullProgressStep*pullProgressStep)
+	}
+	if p.layers[message.ID] == status {
+		return status, false
+	}
+	p.layers[message.ID] = status
+	return status, true
+}
+
 type internalPullImageOptions struct {
 	Repository string `qs:"fromImage"`
 	Tag        string
@@ -292,10 +588,48 @@ func parseImageOptions(image string) internalPullImageOptions {
 	return pullOpts
 }
 
-func findImage(ctx context.Context, imageName string, client *client.Client, authConfig *AuthConfigs, platform string) (*types.ImageSummary, error) {
+// imageDigestReference returns the reference of an image name which is pinned to a
+// digest, e.g. 'alpine@sha256:...'. The tag of a name like 'alpine:3.18@sha256:...'
+// is dropped, as the daemon pulls such images by the digest only and doesn't tag them.
+func imageDigestReference(imageName string) (reference.Canonical, bool) {
+	ref, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return nil, false
+	}
+	canonical, ok := ref.(reference.Canonical)
+	if !ok {
+		return nil, false
+	}
+	// a canonical reference without a tag
+	withoutTag, err := reference.WithDigest(reference.TrimNamed(canonical), canonical.Digest())
+	if err != nil {
+		return nil, false
+	}
+	return withoutTag, true
+}
+
+// matchingRepoDigest returns the repo digest of the image which matches the name and
+// digest of the reference, or an empty string if the image doesn't have the digest.
+func matchingRepoDigest(image *types.ImageSummary, ref reference.Canonical) string {
+	for _, repoDigest := range image.RepoDigests {
+		digested, err := reference.ParseNormalizedNamed(repoDigest)
+		if err != nil {
+			continue
+		}
+		if canonical, ok := digested.(reference.Canonical); ok && canonical.Name() == ref.Name() && canonical.Digest() == ref.Digest() {
+			return repoDigest
+		}
+	}
+	return ""
+}
+
+func findImage(ctx context.Context, imageName string, client *client.Client, providerConfig *ProviderConfig, platform string) (*types.ImageSummary, error) {
 	if imageName == "" {
 		return nil, fmt.Errorf("empty image name is not allowed")
 	}
+	if ref, ok := imageDigestReference(imageName); ok {
+		imageName = reference.FamiliarString(ref)
+	}
 
 	var data Data
 	// load local images into the data structure
@@ -307,9 +641,20 @@ func findImage(ctx context.Context, imageName string, client *client.Client, aut
 		return nil, fmt.Errorf("findImage1: error looking up local image %q: %w", imageName, err)
 	}
 	if foundImage != nil {
-		return foundImage, nil
+		if platform == "" {
+			return foundImage, nil
+		}
+		// the local image might have been pulled for another platform
+		imageInspect, _, err := client.ImageInspectWithRaw(ctx, foundImage.ID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to inspect image %s: %w", imageName, err)
+		}
+		if imageMatchesPlatform(imageInspect, platform) {
+			return foundImage, nil
+		}
+		log.Printf("[DEBUG] local image %s is for platform %s/%s, pulling it for platform %s", imageName, imageInspect.Os, imageInspect.Architecture, platform)
 	}
-	if err := pullImage(ctx, &data, client, authConfig, imageName, platform); err != nil {
+	if err := pullImage(ctx, &data, client, providerConfig, imageName, platform); err != nil {
 		return nil, fmt.Errorf("unable to pull image %s: %s", imageName, err)
 	}
 
@@ -329,6 +674,31 @@ func findImage(ctx context.Context, imageName string, client *client.Client, aut
 	return nil, fmt.Errorf("unable to find or pull image %s", imageName)
 }
 
+// imageMatchesPlatform returns true if the image is for the platform in the
+// 'os[/arch[/variant]]' format. Parts which are not given match any value.
+func imageMatchesPlatform(imageInspect types.ImageInspect, platform string) bool {
+	parts := strings.Split(strings.ToLower(platform), "/")
+	if parts[0] != strings.ToLower(imageInspect.Os) {
+		return false
+	}
+	if len(parts) > 1 && parts[1] != strings.ToLower(imageInspect.Architecture) {
+		return false
+	}
+	if len(parts) > 2 && parts[2] != strings.ToLower(imageInspect.Variant) {
+		return false
+	}
+	return true
+}
+
+// imageTags returns the name of the image together with the additional tags of the build.
+func imageTags(imageName string, rawBuild map[string]interface{}) []string {
+	tags := []string{imageName}
+	for _, t := range rawBuild["tag"].([]interface{}) {
+		tags = append(tags, t.(string))
+	}
+	return tags
+}
+
 func buildDockerImage(ctx context.Context, rawBuild map[string]interface{}, imageName string, client *client.Client) error {
 	var (
 		err error
@@ -337,11 +707,7 @@ func buildDockerImage(ctx context.Context, rawBuild map[string]interface{}, imag
 	log.Printf("[DEBUG] Building docker image")
 	buildOptions := createImageBuildOptions(rawBuild)
 
-	tags := []string{imageName}
-	for _, t := range rawBuild["tag"].([]interface{}) {
-		tags = append(tags, t.(string))
-	}
-	buildOptions.Tags = tags
+	buildOptions.Tags = imageTags(imageName, rawBuild)
 
 	buildContext := rawBuild["context"].(string)
 
@@ -428,6 +794,269 @@ func prepareBuildContext(specifiedContext string, specifiedDockerfile string) (i
 	return buildCtx, specifiedDockerfile, nil
 }
 
+// validateBuildxOptions checks the options of a build with buildx, which can not be
+// validated on the attributes themselves.
+func validateBuildxOptions(rawBuild map[string]interface{}) error {
+	platforms := rawBuild["platforms"].([]interface{})
+	if len(platforms) == 0 {
+		if rawBuild["push"].(bool) {
+			return fmt.Errorf("push is only supported together with platforms")
+		}
+		return nil
+	}
+	if len(platforms) > 1 && !rawBuild["push"].(bool) {
+		return fmt.Errorf("push must be true to build for multiple platforms, as an image for multiple platforms can not be loaded into the Docker host")
+	}
+	if rawBuild["platform"].(string) != "" {
+		return fmt.Errorf("platform and platforms can not be used together")
+	}
+	if builder, ok := rawBuild["builder"].([]interface{}); ok && len(builder) > 0 {
+		return fmt.Errorf("builder can not be used together with platforms, use buildx_builder instead")
+	}
+	return nil
+}
+
+// buildxDockerImage builds the image for multiple platforms with 'docker buildx build',
+// as the Docker API only builds for the platform of the daemon.
+func buildxDockerImage(ctx context.Context, rawBuild map[string]interface{}, imageName string, config *Config) error {
+	args := buildxArgs(createImageBuildOptions(rawBuild), rawBuild, imageTags(imageName, rawBuild))
+	log.Printf("[DEBUG] Building docker image with: docker %s", strings.Join(redactBuildxArgs(args), " "))
+
+	certDir := ""
+	if config.Cert != "" || config.Key != "" {
+		var err error
+		certDir, err = os.MkdirTemp("", "terraform-provider-docker-buildx-")
+		if err != nil {
+			return fmt.Errorf("unable to create the directory for the certificates of the build: %w", err)
+		}
+		defer os.RemoveAll(certDir)
+	}
+	env, err := buildxEnv(config, certDir)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Env = append(os.Environ(), env...)
+
+	output, err := cmd.CombinedOutput()
+	log.Printf("[DEBUG] docker buildx build output:\n%s", output)
+	if err != nil {
+		return fmt.Errorf("docker buildx build failed: %w\n\n%s", err, output)
+	}
+	return nil
+}
+
+// buildxEnv returns the environment of 'docker buildx build', so the docker cli connects
+// to the Docker host like the clients of the provider. The cert_material and
+// key_material are written to certDir, as the docker cli only reads them from files.
+func buildxEnv(config *Config, certDir string) ([]string, error) {
+	env := []string{}
+	if config.Host != "" {
+		env = append(env, "DOCKER_HOST="+config.Host)
+	}
+	if strings.HasPrefix(config.Host, "ssh://") {
+		if len(config.SSHOpts) > 0 {
+			return nil, fmt.Errorf("ssh_opts are not supported for builds with platforms, as 'docker buildx' runs ssh without them. Configure the options of the host in ~/.ssh/config instead")
+		}
+		return env, nil
+	}
+
+	certPath := config.CertPath
+	if config.Cert != "" || config.Key != "" {
+		if config.Cert == "" || config.Key == "" {
+			return nil, fmt.Errorf("cert_material, and key_material must be specified")
+		}
+		certPath = certDir
+		files := map[string]string{"ca.pem": config.Ca, "cert.pem": config.Cert, "key.pem": config.Key}
+		for name, content := range files {
+			if content == "" {
+				continue
+			}
+			if err := os.WriteFile(filepath.Join(certDir, name), []byte(content), 0o600); err != nil {
+				return nil, fmt.Errorf("unable to write the certificates of the build: %w", err)
+			}
+		}
+	}
+	if certPath != "" {
+		env = append(env, "DOCKER_CERT_PATH="+certPath)
+	}
+	switch {
+	case config.Insecure:
+		// TLS without the verification of the host, the client certificate is still presented
+		env = append(env, "DOCKER_TLS=1")
+	case certPath != "":
+		env = append(env, "DOCKER_TLS_VERIFY=1")
+	}
+	return env, nil
+}
+
+// redactBuildxArgs returns the arguments of 'docker buildx build' with the values of
+// the build args replaced, as they often contain tokens.
+func redactBuildxArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	for i := 1; i < len(redacted); i++ {
+		if redacted[i-1] == "--build-arg" {
+			name, _, _ := strings.Cut(redacted[i], "=")
+			redacted[i] = name + "=<redacted>"
+		}
+	}
+	return redacted
+}
+
+// buildxArgs returns the arguments of 'docker buildx build' for the options of a build.
+func buildxArgs(buildOptions types.ImageBuildOptions, rawBuild map[string]interface{}, tags []string) []string {
+	buildContext := rawBuild["context"].(string)
+	// like with the Docker API, the Dockerfile is relative to the context
+	dockerfile := buildOptions.Dockerfile
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(buildContext, dockerfile)
+	}
+
+	args := []string{"buildx", "build", "--file", dockerfile}
+	args = append(args, "--platform", strings.Join(stringListToStringSlice(rawBuild["platforms"].([]interface{})), ","))
+	if builder := rawBuild["buildx_builder"].(string); builder != "" {
+		args = append(args, "--builder", builder)
+	}
+	for _, tag := range tags {
+		args = append(args, "--tag", tag)
+	}
+
+	buildArgs := make([]string, 0, len(buildOptions.BuildArgs))
+	for k, v := range buildOptions.BuildArgs {
+		buildArgs = append(buildArgs, k+"="+*v)
+	}
+	sort.Strings(buildArgs)
+	for _, buildArg := range buildArgs {
+		args = append(args, "--build-arg", buildArg)
+	}
+	labels := make([]string, 0, len(buildOptions.Labels))
+	for k, v := range buildOptions.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		args = append(args, "--label", label)
+	}
+
+	if buildOptions.Target != "" {
+		args = append(args, "--target", buildOptions.Target)
+	}
+	if buildOptions.NoCache {
+		args = append(args, "--no-cache")
+	}
+	if buildOptions.PullParent {
+		args = append(args, "--pull")
+	}
+	for _, cacheFrom := range buildOptions.CacheFrom {
+		args = append(args, "--cache-from", cacheFrom)
+	}
+	for _, extraHost := range buildOptions.ExtraHosts {
+		args = append(args, "--add-host", extraHost)
+	}
+	if buildOptions.NetworkMode != "" {
+		args = append(args, "--network", buildOptions.NetworkMode)
+	}
+
+	if rawBuild["push"].(bool) {
+		args = append(args, "--push")
+	} else {
+		args = append(args, "--load")
+	}
+	return append(args, buildContext)
+}
+
+// buildContextHash returns the sha256 hash of the files in the build context which are
+// sent to the daemon, i.e. not excluded by the .dockerignore file, and of the Dockerfile.
+// The files are walked in lexical order, so the hash only changes if the content, the
+// mode or the path of a file changes.
+func buildContextHash(specifiedContext string, specifiedDockerfile string) (string, error) {
+	specifiedContext, err := homedir.Expand(specifiedContext)
+	if err != nil {
+		return "", err
+	}
+	// like prepareBuildContext, a Dockerfile in the context is resolved by the daemon
+	contextDir, relDockerfile, err := build.GetContextFromLocalDir(specifiedContext, specifiedDockerfile)
+	dockerfileOutsideContext := err == nil && strings.HasPrefix(relDockerfile, ".."+string(filepath.Separator))
+	if contextDir == "" {
+		return "", err
+	}
+	excludes, err := build.ReadDockerignore(contextDir)
+	if err != nil {
+		return "", err
+	}
+	excludes = build.TrimBuildFilesFromExcludes(excludes, archive.CanonicalTarNameForPath(specifiedDockerfile), false)
+	matcher, err := fileutils.NewPatternMatcher(excludes)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	err = filepath.Walk(contextDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(contextDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		excluded, err := matcher.Matches(relPath)
+		if err != nil {
+			return err
+		}
+		if excluded {
+			// a directory can only be skipped if none of its files can be included again
+			if info.IsDir() && !matcher.Exclusions() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return hashBuildContextFile(hash, filepath.ToSlash(relPath), path, info)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if dockerfileOutsideContext {
+		info, err := os.Stat(specifiedDockerfile)
+		if err != nil {
+			return "", err
+		}
+		if err := hashBuildContextFile(hash, relDockerfile, specifiedDockerfile, info); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+func hashBuildContextFile(hash io.Writer, name string, path string, info os.FileInfo) error {
+	fmt.Fprintf(hash, "%s\x00%o\x00", name, info.Mode())
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00", target)
+	case info.Mode().IsRegular():
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fmt.Fprintf(hash, "%d\x00", info.Size())
+		if _, err := io.Copy(hash, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func getBuildContext(filePath string, excludes []string) io.ReadCloser {
 	filePath, _ = homedir.Expand(filePath)
 	//TarWithOptions works only with absolute paths in Windows.
@@ -441,6 +1070,80 @@ func getBuildContext(filePath string, excludes []string) io.ReadCloser {
 	return ctx
 }
 
+// loadImageFromArchive loads the images of a tar archive as written by 'docker save'.
+// The archive is streamed to the daemon, so it is never held in memory.
+func loadImageFromArchive(ctx context.Context, client *client.Client, archivePath string) error {
+	archiveFile, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer archiveFile.Close()
+
+	return loadImage(ctx, client, archiveFile, archivePath)
+}
+
+// transferImage copies the image with the given tags from one Docker host to another.
+// The image is streamed from 'docker save' to 'docker load', so it is never held in memory.
+func transferImage(ctx context.Context, from *client.Client, to *client.Client, tags []string) error {
+	reader, err := from.ImageSave(ctx, tags)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return loadImage(ctx, to, reader, from.DaemonHost())
+}
+
+// loadImage loads the images of the tar archive, the source is only used for logging.
+func loadImage(ctx context.Context, client *client.Client, archive io.Reader, source string) error {
+	response, err := client.ImageLoad(ctx, archive, true)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	dec := json.NewDecoder(response.Body)
+	for dec.More() {
+		var m jsonmessage.JSONMessage
+		if err := dec.Decode(&m); err != nil {
+			return fmt.Errorf("problem decoding message from docker daemon: %s", err)
+		}
+		if m.Error != nil {
+			return m.Error
+		}
+		log.Printf("[DEBUG] Loading image from '%s': %s", source, m.Stream)
+	}
+	return nil
+}
+
+// saveImageToArchive writes the image as tar archive, like 'docker save' does, and
+// returns the size of the archive. The image is streamed to the file, so it is never
+// held in memory. The archive is written to a temporary file next to it first, so a
+// failed save does not leave a truncated archive which would be loaded later.
+func saveImageToArchive(ctx context.Context, client *client.Client, imageName string, archivePath string) (int64, error) {
+	reader, err := client.ImageSave(ctx, []string{imageName})
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	archiveFile, err := os.CreateTemp(filepath.Dir(archivePath), filepath.Base(archivePath)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(archiveFile.Name())
+
+	size, err := io.Copy(archiveFile, reader)
+	if err != nil {
+		archiveFile.Close()
+		return size, err
+	}
+	if err := archiveFile.Close(); err != nil {
+		return size, err
+	}
+	return size, os.Rename(archiveFile.Name(), archivePath)
+}
+
 func decodeBuildMessages(response types.ImageBuildResponse) (string, error) {
 	buf := new(bytes.Buffer)
 	buildErr := error(nil)
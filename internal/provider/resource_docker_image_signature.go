@@ -0,0 +1,177 @@
+package provider
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	b64 "encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// cosignSignatureAnnotation is the annotation of the layers of a cosign signature
+// manifest which holds the base64 encoded signature of the layer.
+const cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+type cosignSignatureManifest struct {
+	Layers []struct {
+		Digest      string            `json:"digest"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"layers"`
+}
+
+// cosignPayload is the simple signing payload cosign signs, which names the digest
+// of the signed manifest.
+type cosignPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// verifyImageSignature verifies that the image with the given repo digest, e.g.
+// 'registry.example.com/app@sha256:...', is signed with cosign by the private key
+// of the given public key. The signatures are read from the registry of the repo
+// digest, where cosign stores them in the tag 'sha256-<hash>.sig'.
+func verifyImageSignature(repoDigest string, publicKeyPEM string, providerConfig *ProviderConfig, insecureSkipVerify bool) error {
+	publicKey, err := parseSignaturePublicKey(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+
+	repository, digest, found := strings.Cut(repoDigest, "@")
+	if !found {
+		return fmt.Errorf("the image has no digest of a registry, only images pulled from a registry can be verified")
+	}
+	pullOpts := parseImageOptions(repository)
+
+	authConfig, err := getAuthConfigForRegistry(pullOpts.Registry, providerConfig)
+	if err != nil {
+		// The user did not provide a credential for this registry.
+		// But there are many registries where you can pull without a credential.
+		// We are setting default values for the authConfig here.
+		authConfig.Username = ""
+		authConfig.Password = ""
+		authConfig.ServerAddress = "https://" + pullOpts.Registry
+	}
+
+	resp, err := getImageManifest(pullOpts.Registry, authConfig.ServerAddress, pullOpts.Repository, cosignSignatureTag(digest), authConfig.Username, authConfig.Password, insecureSkipVerify)
+	if err != nil {
+		return fmt.Errorf("unable to read the cosign signatures of %s: %s", repoDigest, err)
+	}
+	defer resp.Body.Close()
+
+	manifest := cosignSignatureManifest{}
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return fmt.Errorf("unable to parse the cosign signature manifest of %s: %s", repoDigest, err)
+	}
+
+	client := buildHttpClientForRegistry(authConfig.ServerAddress, insecureSkipVerify)
+	var errs []string
+	for _, layer := range manifest.Layers {
+		signature, ok := layer.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		payload, err := getImageBlob(pullOpts.Registry, authConfig.ServerAddress, pullOpts.Repository, layer.Digest, authConfig.Username, authConfig.Password, client)
+		if err == nil {
+			err = verifyCosignSignature(publicKey, payload, signature, digest)
+		}
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err.Error())
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("%s has no cosign signatures", repoDigest)
+	}
+	return fmt.Errorf("no cosign signature of %s matches the public key: %s", repoDigest, strings.Join(errs, ", "))
+}
+
+// cosignSignatureTag returns the tag cosign stores the signatures of a manifest
+// digest under, e.g. 'sha256-abc.sig' for 'sha256:abc'.
+func cosignSignatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sig"
+}
+
+// getImageBlob reads a blob from the registry and checks it matches its digest.
+func getImageBlob(registry string, registryWithProtocol string, image, digest, username, password string, client *http.Client) ([]byte, error) {
+	req, err := http.NewRequest("GET", registryWithProtocol+"/v2/"+image+"/blobs/"+digest, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating registry request: %s", err)
+	}
+
+	resp, err := doRegistryRequest(req, registry, username, password, client)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	blob, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading registry response body: %s", err)
+	}
+	if fmt.Sprintf("sha256:%x", sha256.Sum256(blob)) != digest {
+		return nil, fmt.Errorf("the blob %s does not match its digest", digest)
+	}
+	return blob, nil
+}
+
+// verifyCosignSignature checks the base64 encoded signature of the payload, and
+// that the payload names the digest of the image.
+func verifyCosignSignature(publicKey crypto.PublicKey, payload []byte, signature string, digest string) error {
+	rawSignature, err := b64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("the signature is not base64 encoded: %s", err)
+	}
+
+	hash := sha256.Sum256(payload)
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, hash[:], rawSignature) {
+			return fmt.Errorf("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], rawSignature); err != nil {
+			return fmt.Errorf("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, payload, rawSignature) {
+			return fmt.Errorf("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key of type %T", publicKey)
+	}
+
+	signed := cosignPayload{}
+	if err := json.Unmarshal(payload, &signed); err != nil {
+		return fmt.Errorf("unable to parse the signed payload: %s", err)
+	}
+	// the signature is valid, but may be copied from another image
+	if signed.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("the signature is for %s", signed.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
+
+// parseSignaturePublicKey parses a PEM encoded public key like the cosign.pub
+// written by 'cosign generate-key-pair'.
+func parseSignaturePublicKey(publicKeyPEM string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("the public key is not PEM encoded")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the public key: %s", err)
+	}
+	return publicKey, nil
+}
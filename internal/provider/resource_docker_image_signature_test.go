@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	b64 "encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVerifyImageSignature(t *testing.T) {
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := func(key *ecdsa.PrivateKey) string {
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signedDigest := "sha256:" + strings.Repeat("a", 64)
+	copiedDigest := "sha256:" + strings.Repeat("b", 64)
+	// the signature of the first image is copied to the second one
+	payload := []byte(fmt.Sprintf(`{"critical": {"identity": {"docker-reference": "app"}, "image": {"docker-manifest-digest": "%s"}, "type": "cosign container image signature"}, "optional": null}`, signedDigest))
+	hash := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, signingKey, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	payloadDigest := fmt.Sprintf("sha256:%x", sha256.Sum256(payload))
+	manifest := fmt.Sprintf(`{"schemaVersion": 2, "mediaType": "application/vnd.oci.image.manifest.v1+json", "layers": [{"mediaType": "application/vnd.dev.cosign.simplesigning.v1+json", "digest": "%s", "annotations": {"%s": "%s"}}]}`,
+		payloadDigest, cosignSignatureAnnotation, b64.StdEncoding.EncodeToString(signature))
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/team/app/manifests/" + cosignSignatureTag(signedDigest), "/v2/team/app/manifests/" + cosignSignatureTag(copiedDigest):
+			w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			fmt.Fprint(w, manifest)
+		case "/v2/team/app/blobs/" + payloadDigest:
+			w.Write(payload)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "https://")
+	providerConfig := &ProviderConfig{AuthConfigs: &AuthConfigs{}}
+
+	if err := verifyImageSignature(registry+"/team/app@"+signedDigest, publicKey(signingKey), providerConfig, true); err != nil {
+		t.Fatalf("Expected the signature to be verified, got %s", err)
+	}
+	err = verifyImageSignature(registry+"/team/app@"+signedDigest, publicKey(otherKey), providerConfig, true)
+	if err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Fatalf("Expected the signature of another key to be rejected, got %v", err)
+	}
+	err = verifyImageSignature(registry+"/team/app@"+copiedDigest, publicKey(signingKey), providerConfig, true)
+	if err == nil || !strings.Contains(err.Error(), "the signature is for "+signedDigest) {
+		t.Fatalf("Expected the signature of another image to be rejected, got %v", err)
+	}
+	err = verifyImageSignature(registry+"/team/unsigned@"+signedDigest, publicKey(signingKey), providerConfig, true)
+	if err == nil || !strings.Contains(err.Error(), "unable to read the cosign signatures") {
+		t.Fatalf("Expected an error for an unsigned image, got %v", err)
+	}
+	err = verifyImageSignature("app:latest", publicKey(signingKey), providerConfig, true)
+	if err == nil || !strings.Contains(err.Error(), "only images pulled from a registry") {
+		t.Fatalf("Expected an error for a local image, got %v", err)
+	}
+	if err := verifyImageSignature(registry+"/team/app@"+signedDigest, "not a key", providerConfig, true); err == nil {
+		t.Fatal("Expected an error for an invalid public key")
+	}
+}
@@ -3,6 +3,8 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -10,9 +12,12 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/go-units"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -642,6 +647,45 @@ func testAccImageCreated(resourceName string, image *types.ImageInspect) resourc
 	}
 }
 
+func TestPullProgress(t *testing.T) {
+	progress := newPullProgress()
+	downloading := func(current int64) jsonmessage.JSONMessage {
+		return jsonmessage.JSONMessage{ID: "a1b2", Status: "Downloading", Progress: &jsonmessage.JSONProgress{Current: current, Total: 100}}
+	}
+
+	var reported []string
+	for _, message := range []jsonmessage.JSONMessage{
+		{Status: "Pulling from library/postgres", ID: "16"},
+		{ID: "a1b2", Status: "Pulling fs layer"},
+		downloading(5),
+		downloading(20),
+		downloading(30),
+		downloading(49),
+		downloading(100),
+		{ID: "c3d4", Status: "Pulling fs layer"},
+		{ID: "a1b2", Status: "Download complete"},
+		{ID: "a1b2", Status: "Download complete"},
+		{Status: ""},
+	} {
+		if status, ok := progress.update(message); ok {
+			reported = append(reported, message.ID+" "+status)
+		}
+	}
+
+	expected := []string{
+		"16 Pulling from library/postgres",
+		"a1b2 Pulling fs layer",
+		"a1b2 Downloading 0%",
+		"a1b2 Downloading 25%",
+		"a1b2 Downloading 100%",
+		"c3d4 Pulling fs layer",
+		"a1b2 Download complete",
+	}
+	if !reflect.DeepEqual(reported, expected) {
+		t.Fatalf("Expected the changes of the layers %v, got %v", expected, reported)
+	}
+}
+
 func TestParseImageOptions(t *testing.T) {
 	t.Run("Should parse image name with registry", func(t *testing.T) {
 		expected := internalPullImageOptions{Registry: "registry.com", Repository: "image", Tag: "tag"}
@@ -679,3 +723,276 @@ func TestParseImageOptions(t *testing.T) {
 		}
 	})
 }
+
+func TestImageDigestReference(t *testing.T) {
+	digest := "sha256:0d2ce2b8a6e4a3b0b2a5c1c8d0e9f8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1"
+	for name, expected := range map[string]string{
+		"alpine@" + digest:                          "alpine@" + digest,
+		"alpine:3.18@" + digest:                     "alpine@" + digest,
+		"registry.com:5000/app:1.0@" + digest:       "registry.com:5000/app@" + digest,
+		"alpine:3.18":                               "",
+		"registry.com:5000/app":                     "",
+		"Invalid/Name@" + digest:                    "",
+		"alpine@sha256:tooshort":                    "",
+		"docker.io/library/alpine:latest@" + digest: "alpine@" + digest,
+	} {
+		ref, ok := imageDigestReference(name)
+		if expected == "" {
+			if ok {
+				t.Errorf("Expected %s not to be pinned to a digest, got %s", name, ref)
+			}
+			continue
+		}
+		if !ok || reference.FamiliarString(ref) != expected {
+			t.Errorf("Expected %s to be pinned as %s, got %v", name, expected, ref)
+		}
+	}
+
+	ref, _ := imageDigestReference("alpine:3.18@" + digest)
+	image := &types.ImageSummary{RepoDigests: []string{"registry.com/alpine@" + digest, "alpine@sha256:9f8e7d6c5b4a39281706f5e4d3c2b1a09f8e7d6c5b4a39281706f5e4d3c2b1a0", "alpine@" + digest}}
+	if repoDigest := matchingRepoDigest(image, ref); repoDigest != "alpine@"+digest {
+		t.Fatalf("Expected the repo digest of the repository and digest, got %q", repoDigest)
+	}
+	image.RepoDigests = image.RepoDigests[:2]
+	if repoDigest := matchingRepoDigest(image, ref); repoDigest != "" {
+		t.Fatalf("Expected no repo digest for an image without the digest, got %q", repoDigest)
+	}
+}
+
+func TestImageMatchesPlatform(t *testing.T) {
+	imageInspect := types.ImageInspect{Os: "linux", Architecture: "arm64", Variant: "v8"}
+
+	for _, platform := range []string{"linux", "linux/arm64", "linux/arm64/v8", "Linux/ARM64"} {
+		if !imageMatchesPlatform(imageInspect, platform) {
+			t.Fatalf("Expected image to match platform %s", platform)
+		}
+	}
+	for _, platform := range []string{"windows", "linux/amd64", "linux/arm64/v7"} {
+		if imageMatchesPlatform(imageInspect, platform) {
+			t.Fatalf("Expected image not to match platform %s", platform)
+		}
+	}
+}
+
+func TestBuildContextHash(t *testing.T) {
+	contextDir := t.TempDir()
+	writeFile := func(name, content string) {
+		path := filepath.Join(contextDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func() string {
+		h, err := buildContextHash(contextDir, "Dockerfile")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	writeFile("Dockerfile", "FROM scratch\nCOPY app /app\n")
+	writeFile(".dockerignore", "logs\n**/*.tmp\n")
+	writeFile("app/main.go", "package main\n")
+	initial := hash()
+
+	if h := hash(); h != initial {
+		t.Fatalf("Expected the hash to be stable, got %s and %s", initial, h)
+	}
+
+	writeFile("logs/build.log", "ignored")
+	writeFile("app/cache.tmp", "ignored")
+	if h := hash(); h != initial {
+		t.Fatal("Expected the hash not to change for ignored files")
+	}
+
+	writeFile("app/main.go", "package main\n\nfunc main() {}\n")
+	changed := hash()
+	if changed == initial {
+		t.Fatal("Expected the hash to change when a file changes")
+	}
+
+	if err := os.Rename(filepath.Join(contextDir, "app", "main.go"), filepath.Join(contextDir, "app", "app.go")); err != nil {
+		t.Fatal(err)
+	}
+	if h := hash(); h == changed {
+		t.Fatal("Expected the hash to change when a file is renamed")
+	}
+}
+
+func testImageBuild(t *testing.T, build map[string]interface{}) map[string]interface{} {
+	d := schema.TestResourceDataRaw(t, resourceDockerImage().Schema, map[string]interface{}{
+		"name":  "registry.example.com/foo:1.0",
+		"build": []interface{}{build},
+	})
+	return d.Get("build").(*schema.Set).List()[0].(map[string]interface{})
+}
+
+func TestValidateBuildxOptions(t *testing.T) {
+	valid := []map[string]interface{}{
+		{"context": "."},
+		{"context": ".", "platforms": []interface{}{"linux/arm64"}},
+		{"context": ".", "platforms": []interface{}{"linux/amd64", "linux/arm64"}, "push": true},
+	}
+	for _, build := range valid {
+		if err := validateBuildxOptions(testImageBuild(t, build)); err != nil {
+			t.Fatalf("Expected build %v to be valid, got %s", build, err)
+		}
+	}
+
+	invalid := []map[string]interface{}{
+		{"context": ".", "push": true},
+		{"context": ".", "platforms": []interface{}{"linux/amd64", "linux/arm64"}},
+		{"context": ".", "platforms": []interface{}{"linux/arm64"}, "platform": "linux/arm64"},
+	}
+	for _, build := range invalid {
+		if err := validateBuildxOptions(testImageBuild(t, build)); err == nil {
+			t.Fatalf("Expected build %v to be invalid", build)
+		}
+	}
+}
+
+func TestBuildxArgs(t *testing.T) {
+	rawBuild := testImageBuild(t, map[string]interface{}{
+		"context":        "app",
+		"tag":            []interface{}{"registry.example.com/foo:latest"},
+		"build_args":     map[string]interface{}{"B": "2", "A": "1"},
+		"platforms":      []interface{}{"linux/amd64", "linux/arm64"},
+		"push":           true,
+		"buildx_builder": "multiarch",
+	})
+
+	args := buildxArgs(createImageBuildOptions(rawBuild), rawBuild, imageTags("registry.example.com/foo:1.0", rawBuild))
+	expected := []string{
+		"buildx", "build", "--file", filepath.Join("app", "Dockerfile"),
+		"--platform", "linux/amd64,linux/arm64",
+		"--builder", "multiarch",
+		"--tag", "registry.example.com/foo:1.0",
+		"--tag", "registry.example.com/foo:latest",
+		"--build-arg", "A=1",
+		"--build-arg", "B=2",
+		"--push",
+		"app",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Expected args %v, got %v", expected, args)
+	}
+}
+
+func TestRedactBuildxArgs(t *testing.T) {
+	args := []string{"buildx", "build", "--build-arg", "TOKEN=s3cr3t", "--build-arg", "EMPTY", "--label", "a=b", "app"}
+	expected := []string{"buildx", "build", "--build-arg", "TOKEN=<redacted>", "--build-arg", "EMPTY=<redacted>", "--label", "a=b", "app"}
+	if redacted := redactBuildxArgs(args); !reflect.DeepEqual(redacted, expected) {
+		t.Fatalf("Expected args %v, got %v", expected, redacted)
+	}
+	if args[3] != "TOKEN=s3cr3t" {
+		t.Fatal("Expected the given args not to be changed")
+	}
+}
+
+func TestBuildxEnv(t *testing.T) {
+	certDir := t.TempDir()
+	for _, tc := range []struct {
+		name        string
+		config      *Config
+		expected    []string
+		expectError string
+	}{
+		{name: "plain", config: &Config{Host: "tcp://docker:2375"}, expected: []string{"DOCKER_HOST=tcp://docker:2375"}},
+		{name: "cert path", config: &Config{Host: "tcp://docker:2376", CertPath: "/certs"}, expected: []string{"DOCKER_HOST=tcp://docker:2376", "DOCKER_CERT_PATH=/certs", "DOCKER_TLS_VERIFY=1"}},
+		{name: "insecure", config: &Config{Host: "tcp://docker:2376", CertPath: "/certs", Insecure: true}, expected: []string{"DOCKER_HOST=tcp://docker:2376", "DOCKER_CERT_PATH=/certs", "DOCKER_TLS=1"}},
+		{name: "material", config: &Config{Host: "tcp://docker:2376", Ca: "ca", Cert: "cert", Key: "key"}, expected: []string{"DOCKER_HOST=tcp://docker:2376", "DOCKER_CERT_PATH=" + certDir, "DOCKER_TLS_VERIFY=1"}},
+		{name: "ssh", config: &Config{Host: "ssh://admin@docker"}, expected: []string{"DOCKER_HOST=ssh://admin@docker"}},
+		{name: "ssh opts", config: &Config{Host: "ssh://admin@docker", SSHOpts: []string{"-p", "2222"}}, expectError: "ssh_opts are not supported"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			env, err := buildxEnv(tc.config, certDir)
+			if tc.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectError) {
+					t.Fatalf("Expected error %q, got %v", tc.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %s", err)
+			}
+			if !reflect.DeepEqual(env, tc.expected) {
+				t.Fatalf("Expected env %v, got %v", tc.expected, env)
+			}
+		})
+	}
+
+	for name, content := range map[string]string{"ca.pem": "ca", "cert.pem": "cert", "key.pem": "key"} {
+		if written, err := os.ReadFile(filepath.Join(certDir, name)); err != nil || string(written) != content {
+			t.Fatalf("Expected %s to contain %q, got %q and %v", name, content, written, err)
+		}
+	}
+}
+
+func TestCheckRegistryMirror(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/":
+			if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="mirror"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		case "/token/v2/":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="https://auth.example.com/token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	authConfigs := &AuthConfigs{Configs: map[string]types.AuthConfig{}}
+	if err := checkRegistryMirror(context.Background(), server.URL, authConfigs, time.Second); err == nil {
+		t.Fatal("Expected the mirror to be unhealthy without credentials")
+	}
+	authConfigs.Configs[registryAuthKey(server.URL)] = types.AuthConfig{Username: "user", Password: "secret"}
+	if err := checkRegistryMirror(context.Background(), server.URL, authConfigs, time.Second); err != nil {
+		t.Fatalf("Expected the mirror to be healthy with credentials, got %s", err)
+	}
+	if err := checkRegistryMirror(context.Background(), server.URL+"/token", authConfigs, time.Second); err != nil {
+		t.Fatalf("Expected the mirror asking for a token to be healthy, got %s", err)
+	}
+	if err := checkRegistryMirror(context.Background(), server.URL+"/broken", authConfigs, time.Second); err == nil {
+		t.Fatal("Expected the mirror with an internal error to be unhealthy")
+	}
+}
+
+func TestRegistryMirrorImage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	mirror := strings.TrimPrefix(server.URL, "http://")
+
+	providerConfig := &ProviderConfig{
+		AuthConfigs:         &AuthConfigs{},
+		RegistryMirrors:     []string{"http://127.0.0.1:1", server.URL},
+		MirrorHealthTimeout: time.Second,
+	}
+	for image, expected := range map[string]string{
+		"nginx":                           mirror + "/library/nginx:latest",
+		"nginx:1.25":                      mirror + "/library/nginx:1.25",
+		"docker.io/grafana/grafana:10.0":  mirror + "/grafana/grafana:10.0",
+		"registry.example.com/nginx:1.25": "",
+		"nginx@sha256:0d17b565c37bcbd895e9d92315a05c1c3c9a29f762b011a10c54a66cd53c9b31": "",
+	} {
+		if actual := providerConfig.registryMirrorImage(context.Background(), image); actual != expected {
+			t.Errorf("Expected mirror image of %s to be %q, got %q", image, expected, actual)
+		}
+	}
+
+	providerConfig = &ProviderConfig{
+		AuthConfigs:         &AuthConfigs{},
+		RegistryMirrors:     []string{"http://127.0.0.1:1"},
+		MirrorHealthTimeout: time.Second,
+	}
+	if actual := providerConfig.registryMirrorImage(context.Background(), "nginx"); actual != "" {
+		t.Errorf("Expected no mirror image without a healthy mirror, got %q", actual)
+	}
+}
@@ -17,6 +17,7 @@ func resourceDockerNetwork() *schema.Resource {
 		CreateContext: resourceDockerNetworkCreate,
 		ReadContext:   resourceDockerNetworkRead,
 		DeleteContext: resourceDockerNetworkDelete,
+		CustomizeDiff: resourceDockerNetworkCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -55,7 +56,7 @@ func resourceDockerNetwork() *schema.Resource {
 
 			"driver": {
 				Type:        schema.TypeString,
-				Description: "The driver of the Docker network. Possible values are `bridge`, `host`, `overlay`, `macvlan`. See [network docs](https://docs.docker.com/network/#network-drivers) for more details.",
+				Description: "The driver of the Docker network. Possible values are `bridge`, `host`, `overlay`, `macvlan`, `ipvlan`. See [network docs](https://docs.docker.com/network/#network-drivers) for more details.",
 				Optional:    true,
 				ForceNew:    true,
 				Computed:    true,
@@ -63,7 +64,7 @@ func resourceDockerNetwork() *schema.Resource {
 
 			"options": {
 				Type:        schema.TypeMap,
-				Description: "Only available with bridge networks. See [bridge options docs](https://docs.docker.com/engine/reference/commandline/network_create/#bridge-driver-options) for more details.",
+				Description: "The options of the driver. See [bridge options docs](https://docs.docker.com/engine/reference/commandline/network_create/#bridge-driver-options) for more details. The `macvlan` driver supports `parent` and `macvlan_mode`, the `ipvlan` driver `parent`, `ipvlan_mode` and `ipvlan_flag`. The `parent` interface of the Docker host is required unless the network is `internal`.",
 				Optional:    true,
 				ForceNew:    true,
 				Computed:    true,
@@ -114,7 +115,7 @@ func resourceDockerNetwork() *schema.Resource {
 
 			"ipam_config": {
 				Type:        schema.TypeSet,
-				Description: "The IPAM configuration options",
+				Description: "The IPAM configuration options. Subnets which overlap each other, or the subnet of another network of the `default` IPAM driver on the Docker host, are rejected at plan time.",
 				Optional:    true,
 				Computed:    true,
 				ForceNew:    true,
@@ -144,7 +145,7 @@ func resourceDockerNetwork() *schema.Resource {
 
 						"aux_address": {
 							Type:        schema.TypeMap,
-							Description: "Auxiliary IPv4 or IPv6 addresses used by Network driver",
+							Description: "Auxiliary IPv4 or IPv6 addresses used by Network driver, e.g. to reserve the address of a DHCP server. The addresses are excluded from the pool and must be within the `subnet`.",
 							Optional:    true,
 							ForceNew:    true,
 						},
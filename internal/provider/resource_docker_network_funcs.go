@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"log"
+	"net"
+	"sort"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/network"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -62,7 +65,11 @@ func resourceDockerNetworkCreate(ctx context.Context, d *schema.ResourceData, me
 		ipamOptsSet = true
 	}
 	if v, ok := d.GetOk("ipam_config"); ok {
-		ipamOpts.Config = ipamConfigSetToIpamConfigs(v.(*schema.Set))
+		ipamConfigs, err := ipamConfigSetToIpamConfigs(v.(*schema.Set))
+		if err != nil {
+			return diag.Errorf("Invalid ipam_config: %s", err)
+		}
+		ipamOpts.Config = ipamConfigs
 		ipamOptsSet = true
 	}
 	if v, ok := d.GetOk("ipam_options"); ok {
@@ -80,8 +87,9 @@ func resourceDockerNetworkCreate(ctx context.Context, d *schema.ResourceData, me
 	}
 
 	d.SetId(retNetwork.ID)
+	diags := meta.(*ProviderConfig).daemonWarnings(ctx, d, fmt.Sprintf("creating network %s", d.Get("name").(string)), []string{retNetwork.Warning})
 	// d.Set("check_duplicate") TODO mavogel
-	return resourceDockerNetworkRead(ctx, d, meta)
+	return append(diags, resourceDockerNetworkRead(ctx, d, meta)...)
 }
 
 func resourceDockerNetworkRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -127,7 +135,208 @@ func resourceDockerNetworkDelete(ctx context.Context, d *schema.ResourceData, me
 	return nil
 }
 
-func ipamConfigSetToIpamConfigs(ipamConfigSet *schema.Set) []network.IPAMConfig {
+// vlanNetworkOptions are the options of the macvlan and ipvlan drivers with their
+// valid values. Any value is valid for options without values.
+var vlanNetworkOptions = map[string]map[string][]string{
+	"macvlan": {
+		"parent":       nil,
+		"macvlan_mode": {"bridge", "vepa", "passthru", "private"},
+	},
+	"ipvlan": {
+		"parent":      nil,
+		"ipvlan_mode": {"l2", "l3", "l3s"},
+		"ipvlan_flag": {"bridge", "private", "vepa"},
+	},
+}
+
+// resourceDockerNetworkCustomizeDiff rejects invalid options of the macvlan and ipvlan
+// drivers and overlapping subnets of a new network at plan time, as the daemon only
+// rejects them on create. Networks of the same config which are not created yet
+// can't be taken into account.
+func resourceDockerNetworkCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	rawConfig := d.GetRawConfig()
+	if d.Id() != "" || rawConfig.IsNull() {
+		return nil
+	}
+	// the driver, options and internal are computed, so they are unknown at plan time
+	// if they are not set. They are taken from the configuration instead.
+	driver, driverKnown := rawConfigString(rawConfig, "driver")
+	options, optionsKnown := rawConfigStringMap(rawConfig, "options")
+	internal, internalKnown := rawConfigBool(rawConfig, "internal")
+	if driverKnown && optionsKnown && internalKnown {
+		if err := checkVlanNetworkOptions(driver, options, internal); err != nil {
+			return err
+		}
+	}
+
+	if !d.NewValueKnown("ipam_config") {
+		return nil
+	}
+	subnets := []string{}
+	for _, rawIpamConfig := range d.Get("ipam_config").(*schema.Set).List() {
+		if subnet := rawIpamConfig.(map[string]interface{})["subnet"].(string); subnet != "" {
+			subnets = append(subnets, subnet)
+		}
+	}
+	if len(subnets) == 0 {
+		return nil
+	}
+	if err := checkSubnetOverlaps(subnets, nil); err != nil {
+		return err
+	}
+
+	// other IPAM drivers may manage separate address spaces
+	if d.Get("ipam_driver").(string) != "default" || !d.NewValueKnown("override") || !driverKnown {
+		return nil
+	}
+	providerConfig := meta.(*ProviderConfig)
+	client, err := providerConfig.MakeClientForConfig(ctx, providerConfig.overrideConfig(newConfigFromOverride(d.Get("override").([]interface{}))))
+	if err != nil {
+		log.Printf("[WARN] Unable to check the subnets of the existing networks: %s", err)
+		return nil
+	}
+	networks, err := client.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		log.Printf("[WARN] Unable to check the subnets of the existing networks: %s", err)
+		return nil
+	}
+
+	// networks without a driver are bridge networks, which are local
+	scope := "local"
+	if driver == "overlay" {
+		scope = "swarm"
+	}
+	sameAddressSpace := []types.NetworkResource{}
+	for _, existing := range networks {
+		if existing.Scope == scope && (existing.IPAM.Driver == "" || existing.IPAM.Driver == "default") {
+			sameAddressSpace = append(sameAddressSpace, existing)
+		}
+	}
+	return checkSubnetOverlaps(subnets, sameAddressSpace)
+}
+
+// rawConfigString returns the configured value of a string attribute, which is
+// empty if it is not set. It returns false if the value is unknown.
+func rawConfigString(rawConfig cty.Value, name string) (string, bool) {
+	value := rawConfig.GetAttr(name)
+	if !value.IsKnown() {
+		return "", false
+	}
+	if value.IsNull() {
+		return "", true
+	}
+	return value.AsString(), true
+}
+
+// rawConfigStringMap returns the configured value of a map attribute with string
+// values, which is empty if it is not set. It returns false if the map or one of
+// its values is unknown.
+func rawConfigStringMap(rawConfig cty.Value, name string) (map[string]interface{}, bool) {
+	value := rawConfig.GetAttr(name)
+	if !value.IsWhollyKnown() {
+		return nil, false
+	}
+	values := map[string]interface{}{}
+	if value.IsNull() {
+		return values, true
+	}
+	for k, v := range value.AsValueMap() {
+		if !v.IsNull() {
+			values[k] = v.AsString()
+		}
+	}
+	return values, true
+}
+
+// rawConfigBool returns the configured value of a bool attribute, which is false
+// if it is not set. It returns false as second value if the value is unknown.
+func rawConfigBool(rawConfig cty.Value, name string) (bool, bool) {
+	value := rawConfig.GetAttr(name)
+	if !value.IsKnown() {
+		return false, false
+	}
+	return !value.IsNull() && value.True(), true
+}
+
+// checkVlanNetworkOptions checks the options of a macvlan or ipvlan network. Without
+// a parent interface the daemon creates the network on a dummy interface, which is
+// only useful for internal networks.
+func checkVlanNetworkOptions(driver string, options map[string]interface{}, internal bool) error {
+	validOptions, ok := vlanNetworkOptions[driver]
+	if !ok {
+		return nil
+	}
+
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		values, ok := validOptions[key]
+		if !ok {
+			validKeys := make([]string, 0, len(validOptions))
+			for validKey := range validOptions {
+				validKeys = append(validKeys, validKey)
+			}
+			sort.Strings(validKeys)
+			return fmt.Errorf("option '%s' is not supported by the %s driver, use one of %v", key, driver, validKeys)
+		}
+		value := options[key].(string)
+		valid := len(values) == 0
+		for _, validValue := range values {
+			valid = valid || value == validValue
+		}
+		if !valid {
+			return fmt.Errorf("'%s' is not a valid %s of the %s driver, use one of %v", value, key, driver, values)
+		}
+	}
+
+	if _, ok := options["parent"]; !ok && !internal {
+		return fmt.Errorf("the %s driver requires the 'parent' option with the interface of the Docker host, e.g. 'eth0' or 'eth0.10' for VLAN 10. Set internal to true to create the network on a dummy interface instead", driver)
+	}
+	return nil
+}
+
+// checkSubnetOverlaps checks that the subnets in CIDR notation don't overlap each
+// other or the subnets of the existing networks.
+func checkSubnetOverlaps(subnets []string, networks []types.NetworkResource) error {
+	parsed := make([]*net.IPNet, len(subnets))
+	for i, subnet := range subnets {
+		_, ipNet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return fmt.Errorf("subnet '%s' is not in CIDR notation: %s", subnet, err)
+		}
+		for j := 0; j < i; j++ {
+			if subnetsOverlap(ipNet, parsed[j]) {
+				return fmt.Errorf("the subnets '%s' and '%s' of ipam_config overlap", subnets[j], subnet)
+			}
+		}
+		parsed[i] = ipNet
+	}
+
+	for _, network := range networks {
+		for _, ipamConfig := range network.IPAM.Config {
+			_, existing, err := net.ParseCIDR(ipamConfig.Subnet)
+			if err != nil {
+				continue
+			}
+			for i, ipNet := range parsed {
+				if subnetsOverlap(ipNet, existing) {
+					return fmt.Errorf("the subnet '%s' overlaps the subnet '%s' of the network '%s'", subnets[i], ipamConfig.Subnet, network.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// subnetsOverlap returns true if one of the subnets contains the other one.
+func subnetsOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+func ipamConfigSetToIpamConfigs(ipamConfigSet *schema.Set) ([]network.IPAMConfig, error) {
 	ipamConfigs := make([]network.IPAMConfig, ipamConfigSet.Len())
 
 	for i, ipamConfigInt := range ipamConfigSet.List() {
@@ -144,10 +353,47 @@ func ipamConfigSetToIpamConfigs(ipamConfigSet *schema.Set) []network.IPAMConfig
 			ipamConfig.AuxAddress[k] = v.(string)
 		}
 
+		if err := checkIpamAuxAddresses(ipamConfig); err != nil {
+			return nil, err
+		}
+
 		ipamConfigs[i] = ipamConfig
 	}
 
-	return ipamConfigs
+	return ipamConfigs, nil
+}
+
+// checkIpamAuxAddresses checks that the auxiliary addresses are IP addresses within the
+// subnet of the IPAM config, before the network is created.
+func checkIpamAuxAddresses(ipamConfig network.IPAMConfig) error {
+	if len(ipamConfig.AuxAddress) == 0 {
+		return nil
+	}
+	if ipamConfig.Subnet == "" {
+		return fmt.Errorf("aux_address requires a subnet")
+	}
+
+	_, subnet, err := net.ParseCIDR(ipamConfig.Subnet)
+	if err != nil {
+		return fmt.Errorf("subnet '%s' is not in CIDR notation: %s", ipamConfig.Subnet, err)
+	}
+
+	hosts := make([]string, 0, len(ipamConfig.AuxAddress))
+	for host := range ipamConfig.AuxAddress {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		address := net.ParseIP(ipamConfig.AuxAddress[host])
+		if address == nil {
+			return fmt.Errorf("aux_address '%s' of host '%s' is not an IP address", ipamConfig.AuxAddress[host], host)
+		}
+		if !subnet.Contains(address) {
+			return fmt.Errorf("aux_address '%s' of host '%s' is not within the subnet '%s'", address, host, ipamConfig.Subnet)
+		}
+	}
+	return nil
 }
 
 func resourceDockerNetworkReadRefreshFunc(ctx context.Context,
@@ -3,13 +3,191 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
+func TestCheckIpamAuxAddresses(t *testing.T) {
+	valid := []network.IPAMConfig{
+		{Subnet: "10.0.1.0/24"},
+		{Subnet: "10.0.1.0/24", AuxAddress: map[string]string{"router": "10.0.1.2", "dhcp": "10.0.1.3"}},
+		{Subnet: "fd00::/64", AuxAddress: map[string]string{"router": "fd00::2"}},
+	}
+	for _, ipamConfig := range valid {
+		if err := checkIpamAuxAddresses(ipamConfig); err != nil {
+			t.Fatalf("Expected %v to be valid, got: %s", ipamConfig, err)
+		}
+	}
+
+	invalid := []network.IPAMConfig{
+		{AuxAddress: map[string]string{"router": "10.0.1.2"}},
+		{Subnet: "10.0.1.0", AuxAddress: map[string]string{"router": "10.0.1.2"}},
+		{Subnet: "10.0.1.0/24", AuxAddress: map[string]string{"router": "router.local"}},
+		{Subnet: "10.0.1.0/24", AuxAddress: map[string]string{"router": "10.0.2.2"}},
+	}
+	for _, ipamConfig := range invalid {
+		if err := checkIpamAuxAddresses(ipamConfig); err == nil {
+			t.Fatalf("Expected %v to be invalid", ipamConfig)
+		}
+	}
+}
+
+func TestCheckSubnetOverlaps(t *testing.T) {
+	existing := []types.NetworkResource{
+		{Name: "backend", IPAM: network.IPAM{Config: []network.IPAMConfig{{Subnet: "10.0.1.0/24"}, {Subnet: "fd00:1::/64"}}}},
+		{Name: "host"},
+	}
+
+	valid := [][]string{
+		{"10.0.2.0/24", "10.0.3.0/24"},
+		{"10.0.0.0/24", "fd00:2::/64"},
+	}
+	for _, subnets := range valid {
+		if err := checkSubnetOverlaps(subnets, existing); err != nil {
+			t.Fatalf("Expected %v not to overlap, got: %s", subnets, err)
+		}
+	}
+
+	invalid := map[string][]string{
+		"the subnets '10.0.2.0/24' and '10.0.2.128/25' of ipam_config overlap":                {"10.0.2.0/24", "10.0.2.128/25"},
+		"the subnet '10.0.0.0/16' overlaps the subnet '10.0.1.0/24' of the network 'backend'": {"10.0.0.0/16"},
+		"the subnet 'fd00:1::/96' overlaps the subnet 'fd00:1::/64' of the network 'backend'": {"fd00:1::/96"},
+		"subnet '10.0.2.0' is not in CIDR notation":                                           {"10.0.2.0"},
+	}
+	for expected, subnets := range invalid {
+		if err := checkSubnetOverlaps(subnets, existing); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Expected %v to be rejected with %q, got: %v", subnets, expected, err)
+		}
+	}
+}
+
+func TestCheckVlanNetworkOptions(t *testing.T) {
+	valid := []struct {
+		driver   string
+		options  map[string]interface{}
+		internal bool
+	}{
+		{"macvlan", map[string]interface{}{"parent": "eth0.10", "macvlan_mode": "bridge"}, false},
+		{"macvlan", map[string]interface{}{}, true},
+		{"ipvlan", map[string]interface{}{"parent": "eth0", "ipvlan_mode": "l3", "ipvlan_flag": "private"}, false},
+		{"bridge", map[string]interface{}{"com.docker.network.bridge.name": "br0"}, false},
+	}
+	for _, v := range valid {
+		if err := checkVlanNetworkOptions(v.driver, v.options, v.internal); err != nil {
+			t.Fatalf("Expected %s options %v to be valid, got: %s", v.driver, v.options, err)
+		}
+	}
+
+	invalid := map[string]struct {
+		driver  string
+		options map[string]interface{}
+	}{
+		"requires the 'parent' option":                  {"macvlan", map[string]interface{}{"macvlan_mode": "bridge"}},
+		"option 'mode' is not supported":                {"macvlan", map[string]interface{}{"parent": "eth0", "mode": "bridge"}},
+		"'l2' is not a valid macvlan_mode":              {"macvlan", map[string]interface{}{"parent": "eth0", "macvlan_mode": "l2"}},
+		"'bridge' is not a valid ipvlan_mode":           {"ipvlan", map[string]interface{}{"parent": "eth0", "ipvlan_mode": "bridge"}},
+		"option 'macvlan_mode' is not supported by the": {"ipvlan", map[string]interface{}{"parent": "eth0", "macvlan_mode": "bridge"}},
+	}
+	for expected, v := range invalid {
+		if err := checkVlanNetworkOptions(v.driver, v.options, false); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Expected %s options %v to be rejected with %q, got: %v", v.driver, v.options, expected, err)
+		}
+	}
+}
+
+// testNetworkDiff plans a new network like Terraform does, where the attributes
+// which are not set are null in the raw configuration.
+func testNetworkDiff(attributes map[string]cty.Value, meta interface{}) error {
+	r := resourceDockerNetwork()
+	rawConfig := testConfigObject(r.CoreConfigSchema().ImpliedType(), attributes)
+	_, err := r.Diff(context.Background(), &terraform.InstanceState{RawConfig: rawConfig}, terraform.NewResourceConfigShimmed(rawConfig, r.CoreConfigSchema()), meta)
+	return err
+}
+
+// testConfigObject returns an object of the type with the given attributes, the
+// other attributes are null.
+func testConfigObject(ty cty.Type, attributes map[string]cty.Value) cty.Value {
+	values := map[string]cty.Value{}
+	for name, attributeType := range ty.AttributeTypes() {
+		if value, ok := attributes[name]; ok {
+			values[name] = value
+		} else {
+			values[name] = cty.NullVal(attributeType)
+		}
+	}
+	return cty.ObjectVal(values)
+}
+
+func TestResourceDockerNetworkDiffVlanOptions(t *testing.T) {
+	macvlan := cty.StringVal("macvlan")
+	for _, tc := range []struct {
+		attributes  map[string]cty.Value
+		expectError string
+	}{
+		{map[string]cty.Value{"driver": macvlan}, "requires the 'parent' option"},
+		{map[string]cty.Value{"driver": macvlan, "options": cty.MapVal(map[string]cty.Value{"macvlan_mode": cty.StringVal("bridge")})}, "requires the 'parent' option"},
+		{map[string]cty.Value{"driver": macvlan, "options": cty.MapVal(map[string]cty.Value{"parent": cty.StringVal("eth0"), "mode": cty.StringVal("bridge")})}, "option 'mode' is not supported"},
+		{map[string]cty.Value{"driver": macvlan, "options": cty.MapVal(map[string]cty.Value{"parent": cty.StringVal("eth0")})}, ""},
+		{map[string]cty.Value{"driver": macvlan, "internal": cty.True}, ""},
+		{map[string]cty.Value{"driver": macvlan, "options": cty.UnknownVal(cty.Map(cty.String))}, ""},
+		{map[string]cty.Value{}, ""},
+	} {
+		err := testNetworkDiff(tc.attributes, &ProviderConfig{})
+		if tc.expectError == "" && err != nil {
+			t.Fatalf("Expected %v to be valid, got: %s", tc.attributes, err)
+		}
+		if tc.expectError != "" && (err == nil || !strings.Contains(err.Error(), tc.expectError)) {
+			t.Fatalf("Expected %v to be rejected with %q, got: %v", tc.attributes, tc.expectError, err)
+		}
+	}
+}
+
+func TestResourceDockerNetworkDiffSubnetOverlaps(t *testing.T) {
+	listed := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/networks"):
+			listed++
+			fmt.Fprint(w, `[{"Name": "backend", "Scope": "local", "IPAM": {"Driver": "default", "Config": [{"Subnet": "10.10.0.0/16"}]}}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+	ipamConfigType := resourceDockerNetwork().CoreConfigSchema().ImpliedType().AttributeType("ipam_config").ElementType()
+	ipamConfig := cty.SetVal([]cty.Value{testConfigObject(ipamConfigType, map[string]cty.Value{"subnet": cty.StringVal("10.10.1.0/24")})})
+
+	// a network without a driver is a bridge network
+	err := testNetworkDiff(map[string]cty.Value{"name": cty.StringVal("frontend"), "ipam_config": ipamConfig}, providerConfig)
+	if err == nil || !strings.Contains(err.Error(), "overlaps the subnet '10.10.0.0/16' of the network 'backend'") {
+		t.Fatalf("Expected the subnet to be rejected, got: %v", err)
+	}
+	if listed != 1 {
+		t.Fatalf("Expected the networks to be listed once, got %d", listed)
+	}
+
+	err = testNetworkDiff(map[string]cty.Value{"name": cty.StringVal("frontend"), "driver": cty.StringVal("overlay"), "ipam_config": ipamConfig}, providerConfig)
+	if err != nil {
+		t.Fatalf("Expected the subnet of an overlay network not to overlap local networks, got: %s", err)
+	}
+}
+
 func TestAccDockerNetwork_basic(t *testing.T) {
 	var n types.NetworkResource
 	resourceName := "docker_network.foo"
@@ -182,6 +360,31 @@ func TestAccDockerNetwork_internal(t *testing.T) {
 	})
 }
 
+func TestAccDockerNetwork_macvlan(t *testing.T) {
+	var n types.NetworkResource
+	resourceName := "docker_network.foo"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: loadTestConfiguration(t, RESOURCE, "docker_network", "testAccDockerNetworkMacvlanConfig"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccNetwork(resourceName, &n),
+					resource.TestCheckResourceAttr(resourceName, "driver", "macvlan"),
+					resource.TestCheckResourceAttr(resourceName, "options.macvlan_mode", "bridge"),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
 func testAccNetworkInternal(network *types.NetworkResource, internal bool) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		if network.Internal != internal {
@@ -44,6 +44,14 @@ func resourceDockerRegistryImage() *schema.Resource {
 				ForceNew:    true,
 			},
 
+			"additional_tags": {
+				Type:        schema.TypeList,
+				Description: "Additional names the image is tagged with locally and pushed to, e.g. `registry.example.com/app:1.2.3`. They may refer to other repositories and registries, the credentials of each registry are taken from the `registry_auth` of the provider.",
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+
 			"sha256_digest": {
 				Type:        schema.TypeString,
 				Description: "The sha256 digest of the image.",
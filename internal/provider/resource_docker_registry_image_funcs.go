@@ -15,6 +15,7 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/go-units"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -40,6 +41,12 @@ func resourceDockerRegistryImageCreate(ctx context.Context, d *schema.ResourceDa
 		return diag.Errorf("Error pushing docker image: %s", err)
 	}
 
+	for _, additionalTag := range d.Get("additional_tags").([]interface{}) {
+		if err := tagAndPushDockerRegistryImage(ctx, client, providerConfig, pushOpts.FqName, additionalTag.(string)); err != nil {
+			return diag.Errorf("Error pushing docker image with tag %s: %s", additionalTag.(string), err)
+		}
+	}
+
 	insecureSkipVerify := d.Get("insecure_skip_verify").(bool)
 	digest, err := getImageDigestWithFallback(pushOpts, authConfig.ServerAddress, authConfig.Username, authConfig.Password, insecureSkipVerify)
 	if err != nil {
@@ -90,6 +97,22 @@ func resourceDockerRegistryImageDelete(ctx context.Context, d *schema.ResourceDa
 			return diag.Errorf("Got error deleting registry image: %s", err)
 		}
 	}
+
+	// additional tags in the same repository are already gone with the manifest
+	for _, additionalTag := range d.Get("additional_tags").([]interface{}) {
+		additionalPushOpts := createPushImageOptions(additionalTag.(string))
+		if additionalPushOpts.Registry == pushOpts.Registry && additionalPushOpts.Repository == pushOpts.Repository {
+			continue
+		}
+		additionalAuthConfig, err := getAuthConfigForRegistry(additionalPushOpts.Registry, providerConfig)
+		if err != nil {
+			log.Printf("[WARN] Not deleting registry image %s: %s", additionalPushOpts.FqName, err)
+			continue
+		}
+		if err := deleteDockerRegistryImage(additionalPushOpts, additionalAuthConfig.ServerAddress, digest, additionalAuthConfig.Username, additionalAuthConfig.Password, true, false); err != nil {
+			log.Printf("[WARN] Got error deleting registry image %s: %s", additionalPushOpts.FqName, err)
+		}
+	}
 	return nil
 }
 
@@ -220,34 +243,53 @@ func pushDockerRegistryImage(ctx context.Context, client *client.Client, pushOpt
 	}
 	defer out.Close()
 
-	type ErrorMessage struct {
-		Error string
-	}
-	var errorMessage ErrorMessage
 	buffIOReader := bufio.NewReader(out)
 	for {
 		streamBytes, err := buffIOReader.ReadBytes('\n')
 		if err == io.EOF {
 			break
 		}
-		if err := json.Unmarshal(streamBytes, &errorMessage); err != nil {
+		var message jsonmessage.JSONMessage
+		if err := json.Unmarshal(streamBytes, &message); err != nil {
 			return err
 		}
-		if errorMessage.Error != "" {
-			return fmt.Errorf("Error pushing image: %s", errorMessage.Error)
+		if message.Error != nil {
+			return fmt.Errorf("Error pushing image: %s", message.Error.Message)
+		}
+		if message.ErrorMessage != "" {
+			return fmt.Errorf("Error pushing image: %s", message.ErrorMessage)
+		}
+		if message.Status != "" {
+			log.Printf("[DEBUG] Pushing image %s: %s %s", pushOpts.FqName, message.ID, message.Status)
 		}
 	}
 	log.Printf("[DEBUG] Pushed image: %s", pushOpts.FqName)
 	return nil
 }
 
+// tagAndPushDockerRegistryImage tags the local image with the additional name and pushes
+// it with the credentials of the registry of the additional name.
+func tagAndPushDockerRegistryImage(ctx context.Context, client *client.Client, providerConfig *ProviderConfig, source string, name string) error {
+	pushOpts := createPushImageOptions(name)
+	authConfig, err := getAuthConfigForRegistry(pushOpts.Registry, providerConfig)
+	if err != nil {
+		return err
+	}
+
+	if err := client.ImageTag(ctx, source, pushOpts.FqName); err != nil {
+		return fmt.Errorf("Error tagging image: %s", err)
+	}
+	return pushDockerRegistryImage(ctx, client, pushOpts, authConfig.Username, authConfig.Password)
+}
+
 func getAuthConfigForRegistry(
 	registryWithoutProtocol string,
 	providerConfig *ProviderConfig) (types.AuthConfig, error) {
-	if authConfig, ok := providerConfig.AuthConfigs.Configs[registryWithoutProtocol]; ok {
+	if authConfig, ok := providerConfig.AuthConfigs.Get(registryWithoutProtocol); ok {
 		return authConfig, nil
 	}
-	return types.AuthConfig{}, fmt.Errorf("no auth config found for registry %s in auth configs: %#v", registryWithoutProtocol, providerConfig.AuthConfigs.Configs)
+	// only the registries are listed, as the error ends up in the logs and the output
+	return types.AuthConfig{}, fmt.Errorf("no auth config found for registry %s in auth configs of the registries %v", registryWithoutProtocol, providerConfig.AuthConfigs.Registries())
 }
 
 func buildHttpClientForRegistry(registryAddressWithProtocol string, insecureSkipVerify bool) *http.Client {
@@ -339,7 +381,7 @@ func createPushImageOptions(image string) internalPushImageOptions {
 	pushOpts := internalPushImageOptions{
 		Name:               image,
 		Registry:           pullOpts.Registry,
-		NormalizedRegistry: normalizeRegistryAddress(pullOpts.Registry),
+		NormalizedRegistry: NormalizeRegistryAddress(pullOpts.Registry),
 		Repository:         pullOpts.Repository,
 		Tag:                pullOpts.Tag,
 		FqName:             fmt.Sprintf("%s/%s:%s", pullOpts.Registry, pullOpts.Repository, pullOpts.Tag),
@@ -70,7 +70,7 @@ func testDockerRegistryImageNotInRegistry(pushOpts internalPushImageOptions) res
 	return func(s *terraform.State) error {
 		providerConfig := testAccProvider.Meta().(*ProviderConfig)
 		authConfig, _ := getAuthConfigForRegistry(pushOpts.Registry, providerConfig)
-		digest, _ := getImageDigestWithFallback(pushOpts, normalizeRegistryAddress(pushOpts.Registry), authConfig.Username, authConfig.Password, true)
+		digest, _ := getImageDigestWithFallback(pushOpts, NormalizeRegistryAddress(pushOpts.Registry), authConfig.Username, authConfig.Password, true)
 		if digest != "" {
 			return fmt.Errorf("image found")
 		}
@@ -80,12 +80,12 @@ func testDockerRegistryImageNotInRegistry(pushOpts internalPushImageOptions) res
 
 func testDockerRegistryImageInRegistry(username, password string, pushOpts internalPushImageOptions, cleanup bool) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
-		digest, err := getImageDigestWithFallback(pushOpts, normalizeRegistryAddress(pushOpts.Registry), username, password, true)
+		digest, err := getImageDigestWithFallback(pushOpts, NormalizeRegistryAddress(pushOpts.Registry), username, password, true)
 		if err != nil || len(digest) < 1 {
 			return fmt.Errorf("image '%s' with credentials('%s' - '%s') not found: %w", pushOpts.Name, username, password, err)
 		}
 		if cleanup {
-			err := deleteDockerRegistryImage(pushOpts, normalizeRegistryAddress(pushOpts.Registry), digest, username, password, true, false)
+			err := deleteDockerRegistryImage(pushOpts, NormalizeRegistryAddress(pushOpts.Registry), digest, username, password, true, false)
 			if err != nil {
 				return fmt.Errorf("Unable to remove test image '%s': %w", pushOpts.Name, err)
 			}
@@ -50,6 +50,7 @@ func resourceDockerServiceCreate(ctx context.Context, d *schema.ResourceData, me
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	diags := meta.(*ProviderConfig).daemonWarnings(ctx, d, fmt.Sprintf("creating service %s", serviceSpec.Name), service.Warnings)
 	if v, ok := d.GetOk("converge_config"); ok {
 		convergeConfig := createConvergeConfig(v.([]interface{}))
 		log.Printf("[INFO] Waiting for Service '%s' to be created with timeout: %v", service.ID, convergeConfig.timeoutRaw)
@@ -68,17 +69,17 @@ func resourceDockerServiceCreate(ctx context.Context, d *schema.ResourceData, me
 		if err != nil {
 			// the service will be deleted in case it cannot be converged
 			if deleteErr := deleteService(ctx, service.ID, d, client); deleteErr != nil {
-				return diag.FromErr(deleteErr)
+				return append(diags, diag.FromErr(deleteErr)...)
 			}
 			if containsIgnorableErrorMessage(err.Error(), "timeout while waiting for state") {
-				return diag.FromErr(&DidNotConvergeError{ServiceID: service.ID, Timeout: convergeConfig.timeout})
+				return append(diags, diag.FromErr(&DidNotConvergeError{ServiceID: service.ID, Timeout: convergeConfig.timeout})...)
 			}
-			return diag.FromErr(err)
+			return append(diags, diag.FromErr(err)...)
 		}
 	}
 
 	d.SetId(service.ID)
-	return resourceDockerServiceRead(ctx, d, meta)
+	return append(diags, resourceDockerServiceRead(ctx, d, meta)...)
 }
 
 func resourceDockerServiceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -193,9 +194,7 @@ func resourceDockerServiceUpdate(ctx context.Context, d *schema.ResourceData, me
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	if len(updateResponse.Warnings) > 0 {
-		log.Printf("[INFO] Warninig while updating Service '%s': %v", service.ID, updateResponse.Warnings)
-	}
+	diags := meta.(*ProviderConfig).daemonWarnings(ctx, d, fmt.Sprintf("updating service %s", serviceSpec.Name), updateResponse.Warnings)
 
 	if v, ok := d.GetOk("converge_config"); ok {
 		convergeConfig := createConvergeConfig(v.([]interface{}))
@@ -215,13 +214,13 @@ func resourceDockerServiceUpdate(ctx context.Context, d *schema.ResourceData, me
 		log.Printf("[INFO] State awaited: %v with error: %v", state, err)
 		if err != nil {
 			if containsIgnorableErrorMessage(err.Error(), "timeout while waiting for state") {
-				return diag.FromErr(&DidNotConvergeError{ServiceID: service.ID, Timeout: convergeConfig.timeout})
+				return append(diags, diag.FromErr(&DidNotConvergeError{ServiceID: service.ID, Timeout: convergeConfig.timeout})...)
 			}
-			return diag.FromErr(err)
+			return append(diags, diag.FromErr(err)...)
 		}
 	}
 
-	return resourceDockerServiceRead(ctx, d, meta)
+	return append(diags, resourceDockerServiceRead(ctx, d, meta)...)
 }
 
 func resourceDockerServiceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -638,7 +637,7 @@ func fromRegistryAuth(image string, authConfigs map[string]types.AuthConfig) typ
 	// No auth given and image name has no slash like 'alpine:3.1'
 	if lastBin != -1 {
 		serverAddress := image[0:lastBin]
-		if fromRegistryAuth, ok := authConfigs[serverAddress]; ok {
+		if fromRegistryAuth, ok := authConfigs[registryAuthKey(serverAddress)]; ok {
 			return fromRegistryAuth
 		}
 	}
@@ -655,7 +654,7 @@ func retrieveAndMarshalAuth(d *schema.ResourceData, meta interface{}, stageType
 		log.Printf("[DEBUG] Getting configs from service auth '%v'", rawAuth)
 		auth = authToServiceAuth(rawAuth.([]interface{}))
 	} else {
-		authConfigs := meta.(*ProviderConfig).AuthConfigs.Configs
+		authConfigs := meta.(*ProviderConfig).AuthConfigs.All()
 		log.Printf("[DEBUG] Getting configs from provider auth '%v'", authConfigs)
 		auth = fromRegistryAuth(d.Get("task_spec.0.container_spec.0.image").(string), authConfigs)
 	}
@@ -0,0 +1,208 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceDockerSwarm() *schema.Resource {
+	return &schema.Resource{
+		Description: "Initializes a swarm on the Docker host, like `docker swarm init` does, so `docker_secret`, `docker_config` and `docker_service` resources can be managed on it. If the host is already a manager of a swarm, the swarm is taken over into the state instead. Destroying the resource makes the host leave a swarm it initialized, a swarm which was taken over is only removed from the state.",
+
+		CreateContext: resourceDockerSwarmCreate,
+		ReadContext:   resourceDockerSwarmRead,
+		UpdateContext: resourceDockerSwarmUpdate,
+		DeleteContext: resourceDockerSwarmDelete,
+
+		Schema: map[string]*schema.Schema{
+			"override": {
+				Type:        schema.TypeList,
+				Description: "Override Provider config",
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Elem:        overrideSchemaElem,
+			},
+			"listen_addr": {
+				Type:        schema.TypeString,
+				Description: "The address the manager listens on for the traffic of the swarm, in the form `<ip|interface>:<port>`. Defaults to `0.0.0.0:2377`.",
+				Optional:    true,
+				Default:     "0.0.0.0:2377",
+				ForceNew:    true,
+			},
+			"advertise_addr": {
+				Type:        schema.TypeString,
+				Description: "The address which is advertised to the other nodes of the swarm, in the form `<ip|interface>[:port]`. Defaults to the address the Docker host chooses.",
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+			},
+			"force_leave": {
+				Type:        schema.TypeBool,
+				Description: "If `true`, the host leaves the swarm it initialized on destroy even if it is the last manager, which removes the swarm with all of its services, secrets and configs. A swarm which was taken over is never left. Defaults to `false`.",
+				Optional:    true,
+				Default:     false,
+			},
+			"initialized": {
+				Type:        schema.TypeBool,
+				Description: "`true` if the swarm was initialized by the resource, `false` if the Docker host already was a manager of the swarm and it was taken over.",
+				Computed:    true,
+			},
+			"node_id": {
+				Type:        schema.TypeString,
+				Description: "The ID of the node of the Docker host in the swarm.",
+				Computed:    true,
+			},
+			"worker_join_token": {
+				Type:        schema.TypeString,
+				Description: "The token to join the swarm as worker.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"manager_join_token": {
+				Type:        schema.TypeString,
+				Description: "The token to join the swarm as manager.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func resourceDockerSwarmCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	ctx = logResourceContext(ctx, "docker_swarm", d)
+	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if err != nil {
+		return diag.Errorf("failed to create Docker client: %v", err)
+	}
+
+	info, err := client.Info(ctx)
+	if err != nil {
+		return diag.Errorf("Unable to get the swarm state of the Docker host: %s", err)
+	}
+
+	initialized := false
+	if info.Swarm.LocalNodeState == swarm.LocalNodeStateInactive {
+		initRequest := swarm.InitRequest{
+			ListenAddr:    d.Get("listen_addr").(string),
+			AdvertiseAddr: d.Get("advertise_addr").(string),
+		}
+		nodeID, err := client.SwarmInit(ctx, initRequest)
+		switch {
+		case err == nil:
+			initialized = true
+			tflog.Info(ctx, "Initialized swarm", map[string]interface{}{"node_id": nodeID})
+		case containsIgnorableErrorMessage(err.Error(), "already part of a swarm"):
+			// the swarm was initialized by someone else in the meantime
+			tflog.Info(ctx, "Docker host joined a swarm in the meantime, taking it over")
+		default:
+			return diag.Errorf("Unable to initialize swarm: %s", err)
+		}
+	} else if err := swarmManagerError(info.Swarm); err != nil {
+		return diag.FromErr(err)
+	} else {
+		tflog.Info(ctx, "Docker host is already a manager of a swarm, taking it over", map[string]interface{}{"node_id": info.Swarm.NodeID})
+	}
+
+	swarmInspect, err := client.SwarmInspect(ctx)
+	if err != nil {
+		return diag.Errorf("Unable to inspect swarm: %s", err)
+	}
+	d.SetId(swarmInspect.ID)
+	d.Set("initialized", initialized)
+
+	return resourceDockerSwarmRead(ctx, d, meta)
+}
+
+// swarmManagerError returns an error if the node of the Docker host can't be used
+// to manage its swarm, e.g. because it only is a worker.
+func swarmManagerError(info swarm.Info) error {
+	if info.LocalNodeState != swarm.LocalNodeStateActive {
+		return fmt.Errorf("the Docker host is in the swarm state '%s', it must be 'inactive' or 'active': %s", info.LocalNodeState, info.Error)
+	}
+	if !info.ControlAvailable {
+		return fmt.Errorf("the Docker host is a worker of the swarm with the node ID %s, only managers can be managed", info.NodeID)
+	}
+	return nil
+}
+
+func resourceDockerSwarmRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	ctx = logResourceContext(ctx, "docker_swarm", d)
+	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if err != nil {
+		return diag.Errorf("failed to create Docker client: %v", err)
+	}
+
+	info, err := client.Info(ctx)
+	if err != nil {
+		return diag.Errorf("Unable to get the swarm state of the Docker host: %s", err)
+	}
+	if info.Swarm.LocalNodeState == swarm.LocalNodeStateInactive {
+		tflog.Warn(ctx, "Docker host is not part of a swarm anymore, removing it from state")
+		d.SetId("")
+		return nil
+	}
+	if err := swarmManagerError(info.Swarm); err != nil {
+		return diag.FromErr(err)
+	}
+
+	swarmInspect, err := client.SwarmInspect(ctx)
+	if err != nil {
+		return diag.Errorf("Unable to inspect swarm: %s", err)
+	}
+	if swarmInspect.ID != d.Id() {
+		tflog.Warn(ctx, "Docker host is part of another swarm, removing it from state", map[string]interface{}{"swarm_id": swarmInspect.ID})
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("advertise_addr", info.Swarm.NodeAddr)
+	d.Set("node_id", info.Swarm.NodeID)
+	d.Set("worker_join_token", swarmInspect.JoinTokens.Worker)
+	d.Set("manager_join_token", swarmInspect.JoinTokens.Manager)
+
+	return nil
+}
+
+func resourceDockerSwarmUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// only 'force_leave' can change without forcing a new swarm
+	return resourceDockerSwarmRead(ctx, d, meta)
+}
+
+func resourceDockerSwarmDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	ctx = logResourceContext(ctx, "docker_swarm", d)
+	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if err != nil {
+		return diag.Errorf("failed to create Docker client: %v", err)
+	}
+
+	// a swarm which was taken over may run services which are not managed by terraform
+	if !d.Get("initialized").(bool) {
+		tflog.Info(ctx, "Swarm was taken over, keeping the Docker host in the swarm")
+		d.SetId("")
+		return nil
+	}
+
+	if err := leaveSwarm(ctx, client, d.Get("force_leave").(bool)); err != nil {
+		return diag.Errorf("Unable to leave swarm: %s", err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// leaveSwarm makes the Docker host leave its swarm. Hosts which already left the
+// swarm are fine.
+func leaveSwarm(ctx context.Context, client *client.Client, force bool) error {
+	err := client.SwarmLeave(ctx, force)
+	if err != nil && containsIgnorableErrorMessage(err.Error(), "not part of a swarm") {
+		return nil
+	}
+	return err
+}
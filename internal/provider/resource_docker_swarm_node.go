@@ -0,0 +1,206 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// swarmNodeUpdateAttempts is how often an update of a node is tried, as it fails
+// if the node was changed by someone else since it was inspected.
+const swarmNodeUpdateAttempts = 3
+
+// nodeLabelSchema is the labelSchema without ForceNew, as the labels of a node
+// are updated in place.
+var nodeLabelSchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"label": {
+			Type:             schema.TypeString,
+			Description:      "Name of the label",
+			Required:         true,
+			DiffSuppressFunc: suppressLabelWhitespaceDiff,
+		},
+		"value": {
+			Type:             schema.TypeString,
+			Description:      "Value of the label",
+			Required:         true,
+			DiffSuppressFunc: suppressLabelWhitespaceDiff,
+		},
+	},
+}
+
+func resourceDockerSwarmNode() *schema.Resource {
+	return &schema.Resource{
+		Description: "Manages the availability, role and labels of a node of a swarm, e.g. to drain a node for maintenance or to set the labels which are used in the placement constraints of services. The Docker host must be a manager of the swarm. Nodes can't be created, and destroying the resource only removes it from the state, the node keeps its settings.",
+
+		CreateContext: resourceDockerSwarmNodeCreate,
+		ReadContext:   resourceDockerSwarmNodeRead,
+		UpdateContext: resourceDockerSwarmNodeUpdate,
+		DeleteContext: resourceDockerSwarmNodeDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceDockerSwarmNodeImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"override": {
+				Type:        schema.TypeList,
+				Description: "Override Provider config",
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Elem:        overrideSchemaElem,
+			},
+			"node": {
+				Type:        schema.TypeString,
+				Description: "The ID or hostname of the node.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"availability": {
+				Type:             schema.TypeString,
+				Description:      "The availability of the node, one of `active`, `pause` or `drain`. Defaults to the current availability of the node.",
+				Optional:         true,
+				Computed:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{"active", "pause", "drain"}, false)),
+			},
+			"role": {
+				Type:             schema.TypeString,
+				Description:      "The role of the node, either `worker` or `manager`. Defaults to the current role of the node.",
+				Optional:         true,
+				Computed:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{"worker", "manager"}, false)),
+			},
+			"labels": {
+				Type:        schema.TypeSet,
+				Description: "The labels of the node, which replace all of its labels. Defaults to the current labels of the node.",
+				Optional:    true,
+				Computed:    true,
+				Elem:        nodeLabelSchema,
+			},
+			"hostname": {
+				Type:        schema.TypeString,
+				Description: "The hostname of the node.",
+				Computed:    true,
+			},
+			"state": {
+				Type:        schema.TypeString,
+				Description: "The state of the node, e.g. `ready` or `down`.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceDockerSwarmNodeCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	ctx = logResourceContext(ctx, "docker_swarm_node", d)
+	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if err != nil {
+		return diag.Errorf("failed to create Docker client: %v", err)
+	}
+
+	node, _, err := client.NodeInspectWithRaw(ctx, d.Get("node").(string))
+	if err != nil {
+		return diag.Errorf("Unable to inspect node '%s': %s", d.Get("node").(string), err)
+	}
+	d.SetId(node.ID)
+
+	if err := updateSwarmNode(ctx, client, d); err != nil {
+		return diag.Errorf("Unable to update node '%s': %s", d.Get("node").(string), err)
+	}
+
+	return resourceDockerSwarmNodeRead(ctx, d, meta)
+}
+
+func resourceDockerSwarmNodeRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	ctx = logResourceContext(ctx, "docker_swarm_node", d)
+	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if err != nil {
+		return diag.Errorf("failed to create Docker client: %v", err)
+	}
+
+	node, _, err := client.NodeInspectWithRaw(ctx, d.Id())
+	if err != nil {
+		if containsIgnorableErrorMessage(err.Error(), "No such node", "not found") {
+			tflog.Warn(ctx, "Node is not part of the swarm anymore, removing it from state", map[string]interface{}{"node_id": d.Id()})
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("Unable to inspect node '%s': %s", d.Id(), err)
+	}
+
+	d.Set("availability", string(node.Spec.Availability))
+	d.Set("role", string(node.Spec.Role))
+	d.Set("labels", mapToLabelSet(node.Spec.Labels))
+	d.Set("hostname", node.Description.Hostname)
+	d.Set("state", string(node.Status.State))
+
+	return nil
+}
+
+func resourceDockerSwarmNodeUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	ctx = logResourceContext(ctx, "docker_swarm_node", d)
+	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if err != nil {
+		return diag.Errorf("failed to create Docker client: %v", err)
+	}
+
+	if err := updateSwarmNode(ctx, client, d); err != nil {
+		return diag.Errorf("Unable to update node '%s': %s", d.Id(), err)
+	}
+
+	return resourceDockerSwarmNodeRead(ctx, d, meta)
+}
+
+func resourceDockerSwarmNodeDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// nodes leave the swarm on their own, so the node keeps its settings
+	d.SetId("")
+	return nil
+}
+
+func resourceDockerSwarmNodeImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	d.Set("node", d.Id())
+	return []*schema.ResourceData{d}, nil
+}
+
+// updateSwarmNode applies the configured settings to the spec of the node. The
+// update is tried again with the new version of the node if the node was changed
+// in the meantime.
+func updateSwarmNode(ctx context.Context, client *client.Client, d *schema.ResourceData) error {
+	var err error
+	for attempt := 1; attempt <= swarmNodeUpdateAttempts; attempt++ {
+		var node swarm.Node
+		node, _, err = client.NodeInspectWithRaw(ctx, d.Id())
+		if err != nil {
+			return err
+		}
+
+		spec := swarmNodeSpec(node.Spec, d)
+		err = client.NodeUpdate(ctx, node.ID, node.Version, spec)
+		if err == nil || !containsIgnorableErrorMessage(err.Error(), "update out of sequence") {
+			return err
+		}
+		tflog.Debug(ctx, "Node was changed in the meantime, updating it again", map[string]interface{}{"node_id": node.ID, "attempt": attempt})
+	}
+	return fmt.Errorf("node was changed by someone else during %d attempts: %w", swarmNodeUpdateAttempts, err)
+}
+
+// swarmNodeSpec returns the spec of the node with the configured settings. The
+// settings which are not configured are kept.
+func swarmNodeSpec(spec swarm.NodeSpec, d *schema.ResourceData) swarm.NodeSpec {
+	if v, ok := d.GetOk("availability"); ok {
+		spec.Availability = swarm.NodeAvailability(v.(string))
+	}
+	if v, ok := d.GetOk("role"); ok {
+		spec.Role = swarm.NodeRole(v.(string))
+	}
+	if v, ok := d.GetOk("labels"); ok {
+		spec.Labels = labelSetToMap(v.(*schema.Set))
+	}
+	return spec
+}
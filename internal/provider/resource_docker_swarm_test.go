@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// testSwarmDaemon is a Docker host which only knows the endpoints of the swarm
+// and whose node is in the given swarm state. The inits and leaves are recorded in calls.
+func testSwarmDaemon(t *testing.T, nodeState string, controlAvailable bool, calls *[]string) *ProviderConfig {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/info"):
+			fmt.Fprintf(w, `{"Swarm": {"NodeID": "node-1", "NodeAddr": "10.0.0.2", "LocalNodeState": %q, "ControlAvailable": %t}}`, nodeState, controlAvailable)
+		case strings.HasSuffix(r.URL.Path, "/swarm/init"):
+			*calls = append(*calls, "init")
+			nodeState, controlAvailable = "active", true
+			fmt.Fprint(w, `"node-1"`)
+		case strings.HasSuffix(r.URL.Path, "/swarm/leave"):
+			*calls = append(*calls, "leave force="+r.URL.Query().Get("force"))
+			nodeState, controlAvailable = "inactive", false
+		case strings.HasSuffix(r.URL.Path, "/swarm"):
+			fmt.Fprint(w, `{"ID": "swarm-1", "JoinTokens": {"Worker": "SWMTKN-1-worker", "Manager": "SWMTKN-1-manager"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+}
+
+func TestResourceDockerSwarmCreate(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		nodeState         string
+		controlAvailable  bool
+		expectInitialized bool
+		expectError       string
+	}{
+		{name: "initializes inactive host", nodeState: "inactive", expectInitialized: true},
+		{name: "takes over swarm of manager", nodeState: "active", controlAvailable: true},
+		{name: "rejects worker", nodeState: "active", expectError: "worker of the swarm"},
+		{name: "rejects pending host", nodeState: "pending", expectError: "swarm state 'pending'"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := []string{}
+			providerConfig := testSwarmDaemon(t, tc.nodeState, tc.controlAvailable, &calls)
+			d := schema.TestResourceDataRaw(t, resourceDockerSwarm().Schema, map[string]interface{}{})
+
+			diags := resourceDockerSwarmCreate(context.Background(), d, providerConfig)
+			if tc.expectError != "" {
+				if !diags.HasError() || !strings.Contains(diags[0].Summary, tc.expectError) {
+					t.Fatalf("Expected error %q, got %#v", tc.expectError, diags)
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("Expected the swarm to be created, got %#v", diags)
+			}
+			if initialized := len(calls) == 1 && calls[0] == "init"; initialized != tc.expectInitialized || d.Get("initialized") != tc.expectInitialized {
+				t.Fatalf("Expected the swarm to be initialized: %t, got the calls %v and %#v", tc.expectInitialized, calls, d.State())
+			}
+			if d.Id() != "swarm-1" || d.Get("node_id") != "node-1" || d.Get("advertise_addr") != "10.0.0.2" {
+				t.Fatalf("Expected the swarm to be read, got %#v", d.State())
+			}
+			if d.Get("worker_join_token") != "SWMTKN-1-worker" || d.Get("manager_join_token") != "SWMTKN-1-manager" {
+				t.Fatalf("Expected the join tokens to be read, got %#v", d.State())
+			}
+		})
+	}
+}
+
+func TestResourceDockerSwarmDelete(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		nodeState  string
+		forceLeave bool
+		expected   []string
+	}{
+		{name: "leaves initialized swarm", nodeState: "inactive", expected: []string{"init", "leave force="}},
+		{name: "force leaves initialized swarm", nodeState: "inactive", forceLeave: true, expected: []string{"init", "leave force=1"}},
+		{name: "keeps swarm which was taken over", nodeState: "active", forceLeave: true, expected: []string{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := []string{}
+			providerConfig := testSwarmDaemon(t, tc.nodeState, true, &calls)
+			d := schema.TestResourceDataRaw(t, resourceDockerSwarm().Schema, map[string]interface{}{
+				"force_leave": tc.forceLeave,
+			})
+			if diags := resourceDockerSwarmCreate(context.Background(), d, providerConfig); diags.HasError() {
+				t.Fatalf("Expected the swarm to be created, got %#v", diags)
+			}
+			if diags := resourceDockerSwarmDelete(context.Background(), d, providerConfig); diags.HasError() {
+				t.Fatalf("Expected the swarm to be deleted, got %#v", diags)
+			}
+			if !reflect.DeepEqual(calls, tc.expected) || d.Id() != "" {
+				t.Fatalf("Expected the calls %v, got %v and ID %q", tc.expected, calls, d.Id())
+			}
+		})
+	}
+}
+
+func TestResourceDockerSwarmNodeCreate(t *testing.T) {
+	var mu sync.Mutex
+	version := 10
+	spec := swarm.NodeSpec{Role: swarm.NodeRoleWorker, Availability: swarm.NodeAvailabilityActive, Annotations: swarm.Annotations{Labels: map[string]string{"zone": "a"}}}
+	conflicts := 1
+	// a manager which knows the node 'worker-1', whose first update conflicts with another update
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/nodes/node-1/update"):
+			if conflicts > 0 {
+				conflicts--
+				version++
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `{"message": "rpc error: code = Unknown desc = update out of sequence"}`)
+				return
+			}
+			if r.URL.Query().Get("version") != strconv.Itoa(version) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewDecoder(r.Body).Decode(&spec)
+			version++
+		case strings.HasSuffix(r.URL.Path, "/nodes/worker-1"), strings.HasSuffix(r.URL.Path, "/nodes/node-1"):
+			json.NewEncoder(w).Encode(swarm.Node{
+				ID:          "node-1",
+				Meta:        swarm.Meta{Version: swarm.Version{Index: uint64(version)}},
+				Spec:        spec,
+				Description: swarm.NodeDescription{Hostname: "worker-1"},
+				Status:      swarm.NodeStatus{State: swarm.NodeStateReady},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+	d := schema.TestResourceDataRaw(t, resourceDockerSwarmNode().Schema, map[string]interface{}{
+		"node":         "worker-1",
+		"availability": "drain",
+	})
+	if diags := resourceDockerSwarmNodeCreate(context.Background(), d, providerConfig); diags.HasError() {
+		t.Fatalf("Expected the node to be updated, got %#v", diags)
+	}
+
+	if d.Id() != "node-1" || d.Get("availability") != "drain" || d.Get("role") != "worker" || d.Get("hostname") != "worker-1" {
+		t.Fatalf("Expected the node to be drained, got %#v", d.State())
+	}
+	if labels := labelSetToMap(d.Get("labels").(*schema.Set)); !reflect.DeepEqual(labels, map[string]string{"zone": "a"}) {
+		t.Fatalf("Expected the labels of the node to be kept, got %v", labels)
+	}
+}
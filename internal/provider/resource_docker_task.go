@@ -0,0 +1,265 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// taskRemoveTimeout is how long the removal of the container of a task may take,
+// also if the apply got cancelled.
+const taskRemoveTimeout = 30 * time.Second
+
+func resourceDockerTask() *schema.Resource {
+	return &schema.Resource{
+		Description: "Runs a one-off container to completion when the resource is created, e.g. for database migrations or init jobs, and captures its output. The container is removed afterwards, also if it fails. A non-zero exit code fails the apply with the output of the container. Change the `triggers` to run the task again. Deleting the resource only removes it from the state.",
+
+		CreateContext: resourceDockerTaskCreate,
+		ReadContext:   resourceDockerTaskRead,
+		DeleteContext: resourceDockerTaskDelete,
+
+		Schema: map[string]*schema.Schema{
+			"override": {
+				Type:        schema.TypeList,
+				Description: "Override Provider config",
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Elem:        overrideSchemaElem,
+			},
+			"image": {
+				Type:        schema.TypeString,
+				Description: "The image of the container. It is pulled if it does not exist.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"command": {
+				Type:        schema.TypeList,
+				Description: "The command of the container, e.g. `[\"migrate\", \"up\"]`. Defaults to the command of the image.",
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"env": {
+				Type:        schema.TypeSet,
+				Description: "Environment variables of the container in the form of `KEY=VALUE`.",
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"mounts": {
+				Type:        schema.TypeSet,
+				Description: "Mounts of the container.",
+				Optional:    true,
+				ForceNew:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"target": {
+							Type:        schema.TypeString,
+							Description: "Container path",
+							Required:    true,
+						},
+						"source": {
+							Type:        schema.TypeString,
+							Description: "Mount source (e.g. a volume name, a host path). A managed volume can be mounted with `docker_volume.foo.name`.",
+							Optional:    true,
+						},
+						"type": {
+							Type:             schema.TypeString,
+							Description:      "The mount type",
+							Required:         true,
+							ValidateDiagFunc: validateStringMatchesPattern(`^(bind|volume|tmpfs)$`),
+						},
+						"read_only": {
+							Type:        schema.TypeBool,
+							Description: "Whether the mount should be read-only.",
+							Optional:    true,
+						},
+					},
+				},
+			},
+			"timeout": {
+				Type:             schema.TypeInt,
+				Description:      "How long to wait for the container to exit in seconds. The container is removed and the apply fails if it runs longer. Defaults to `600`.",
+				Optional:         true,
+				Default:          600,
+				ForceNew:         true,
+				ValidateDiagFunc: validateIntegerGeqThan(1),
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Description: "A map of arbitrary strings that, when changed, will force the task to be run again.",
+				Optional:    true,
+				ForceNew:    true,
+			},
+			"exit_code": {
+				Type:        schema.TypeInt,
+				Description: "The exit code of the container.",
+				Computed:    true,
+			},
+			"stdout": {
+				Type:        schema.TypeString,
+				Description: "The standard output of the container.",
+				Computed:    true,
+			},
+			"stderr": {
+				Type:        schema.TypeString,
+				Description: "The standard error of the container.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceDockerTaskCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	ctx = logResourceContext(ctx, "docker_task", d)
+	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if err != nil {
+		return diag.Errorf("failed to create Docker client: %v", err)
+	}
+
+	image := d.Get("image").(string)
+	if _, err := findImage(ctx, image, client, meta.(*ProviderConfig), ""); err != nil {
+		return diag.Errorf("Unable to find or pull image %s: %s", image, err)
+	}
+
+	config := &container.Config{
+		Image:        image,
+		Env:          stringSetToStringSlice(d.Get("env").(*schema.Set)),
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+	if v, ok := d.GetOk("command"); ok {
+		config.Cmd = stringListToStringSlice(v.([]interface{}))
+	}
+	hostConfig := &container.HostConfig{
+		Mounts: taskMounts(d.Get("mounts").(*schema.Set)),
+	}
+	task, err := client.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
+	if err != nil {
+		return diag.Errorf("Unable to create the container of the task: %s", err)
+	}
+	defer removeTaskContainer(ctx, client, task.ID)
+
+	timeout := time.Duration(d.Get("timeout").(int)) * time.Second
+	var exitCode int64
+	err = withoutHostSlot(ctx, func() error {
+		var err error
+		exitCode, err = runTaskContainer(ctx, client, task.ID, timeout)
+		return err
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	stdout, stderr, err := taskContainerLogs(ctx, client, task.ID)
+	if err != nil {
+		return diag.Errorf("Unable to read the output of the task: %s", err)
+	}
+	tflog.Info(ctx, "Task exited", map[string]interface{}{"exit_code": exitCode})
+
+	if exitCode != 0 {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("The task exited with code %d", exitCode),
+			Detail:   fmt.Sprintf("stdout:\n%s\nstderr:\n%s", stdout, stderr),
+		}}
+	}
+
+	d.SetId(id.PrefixedUniqueId("task-"))
+	d.Set("exit_code", int(exitCode))
+	d.Set("stdout", stdout)
+	d.Set("stderr", stderr)
+
+	return nil
+}
+
+func resourceDockerTaskRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// the container of the task is removed after it ran, there is nothing to refresh
+	return nil
+}
+
+func resourceDockerTaskDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
+
+// runTaskContainer starts the container and waits for it to exit, returning its exit
+// code. The wait is set up before the start, so an exit right after the start is not missed.
+func runTaskContainer(ctx context.Context, client *client.Client, containerID string, timeout time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	waitOkC, errorC := client.ContainerWait(ctx, containerID, container.WaitConditionNextExit)
+	if err := client.ContainerStart(ctx, containerID, types.ContainerStartOptions{}); err != nil {
+		return 0, fmt.Errorf("Unable to start the container of the task: %s", err)
+	}
+
+	select {
+	case waitOk := <-waitOkC:
+		if waitOk.Error != nil {
+			return 0, fmt.Errorf("Unable to wait for the container of the task: %s", waitOk.Error.Message)
+		}
+		return waitOk.StatusCode, nil
+	case err := <-errorC:
+		if ctx.Err() == context.DeadlineExceeded {
+			return 0, fmt.Errorf("The task did not exit within %s", timeout)
+		}
+		return 0, fmt.Errorf("Unable to wait for the container of the task: %s", err)
+	}
+}
+
+// taskContainerLogs returns the standard output and error of the exited container.
+func taskContainerLogs(ctx context.Context, client *client.Client, containerID string) (string, string, error) {
+	reader, err := client.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	defer reader.Close()
+
+	// the container has no tty, so the output is multiplexed
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, reader); err != nil {
+		return "", "", err
+	}
+	return stdout.String(), stderr.String(), nil
+}
+
+func taskMounts(rawMounts *schema.Set) []mount.Mount {
+	mounts := []mount.Mount{}
+	for _, rawMount := range rawMounts.List() {
+		rawMount := rawMount.(map[string]interface{})
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.Type(rawMount["type"].(string)),
+			Source:   rawMount["source"].(string),
+			Target:   rawMount["target"].(string),
+			ReadOnly: rawMount["read_only"].(bool),
+		})
+	}
+	return mounts
+}
+
+// removeTaskContainer removes the container of the task, which also kills it if it
+// is still running, e.g. after a timeout or if the context got cancelled.
+func removeTaskContainer(ctx context.Context, client *client.Client, containerID string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), taskRemoveTimeout)
+	defer cancel()
+
+	if err := client.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+		tflog.Warn(ctx, "Unable to remove the container of the task", map[string]interface{}{"container_id": containerID, "error": err.Error()})
+	}
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestResourceDockerTaskCreate(t *testing.T) {
+	imageID := "sha256:5a1b7c2d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b"
+	var created string
+	var started, removed bool
+	exitCode := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/images/json"):
+			fmt.Fprintf(w, `[{"Id": %q, "RepoTags": ["alpine:latest"]}]`, imageID)
+		case strings.HasSuffix(r.URL.Path, "/images/alpine:latest/json"):
+			fmt.Fprintf(w, `{"Id": %q}`, imageID)
+		case strings.HasSuffix(r.URL.Path, "/containers/create"):
+			body, _ := io.ReadAll(r.Body)
+			created = string(body)
+			fmt.Fprint(w, `{"Id": "task1"}`)
+		case strings.HasSuffix(r.URL.Path, "/containers/task1/start"):
+			started = true
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasSuffix(r.URL.Path, "/containers/task1/wait"):
+			fmt.Fprintf(w, `{"StatusCode": %d}`, exitCode)
+		case strings.HasSuffix(r.URL.Path, "/containers/task1/logs"):
+			w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+			fmt.Fprint(stdcopy.NewStdWriter(w, stdcopy.Stdout), "migrated\n")
+			fmt.Fprint(stdcopy.NewStdWriter(w, stdcopy.Stderr), "1 warning\n")
+		case r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/containers/task1"):
+			removed = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+	newResourceData := func() *schema.ResourceData {
+		return schema.TestResourceDataRaw(t, resourceDockerTask().Schema, map[string]interface{}{
+			"image":   "alpine:latest",
+			"command": []interface{}{"migrate", "up"},
+			"env":     []interface{}{"DB=postgres"},
+			"mounts": []interface{}{map[string]interface{}{
+				"type":   "volume",
+				"source": "data",
+				"target": "/data",
+			}},
+		})
+	}
+
+	d := newResourceData()
+	if diags := resourceDockerTaskCreate(context.Background(), d, providerConfig); diags.HasError() {
+		t.Fatalf("Expected the task to succeed, got %v", diags)
+	}
+	for _, expected := range []string{`"Cmd":["migrate","up"]`, `"Env":["DB=postgres"]`, `"Target":"/data"`} {
+		if !strings.Contains(created, expected) {
+			t.Fatalf("Expected the container to be created with %s, got %s", expected, created)
+		}
+	}
+	if !started || !removed {
+		t.Fatalf("Expected the container to be started and removed, got started %v and removed %v", started, removed)
+	}
+	if d.Id() == "" || d.Get("exit_code") != 0 || d.Get("stdout") != "migrated\n" || d.Get("stderr") != "1 warning\n" {
+		t.Fatalf("Unexpected state %#v", d.State())
+	}
+
+	exitCode = 3
+	removed = false
+	d = newResourceData()
+	diags := resourceDockerTaskCreate(context.Background(), d, providerConfig)
+	if !diags.HasError() || diags[0].Summary != "The task exited with code 3" || !strings.Contains(diags[0].Detail, "migrated") || !strings.Contains(diags[0].Detail, "1 warning") {
+		t.Fatalf("Expected the task to fail with its output, got %v", diags)
+	}
+	if d.Id() != "" || !removed {
+		t.Fatalf("Expected the failed task not to be stored and its container to be removed, got ID %q and removed %v", d.Id(), removed)
+	}
+}
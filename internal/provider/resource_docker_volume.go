@@ -5,12 +5,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
-	"log"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-units"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -18,17 +27,43 @@ const (
 	volumeReadRefreshTimeout             = 30 * time.Second
 	volumeReadRefreshWaitBeforeRefreshes = 5 * time.Second
 	volumeReadRefreshDelay               = 2 * time.Second
+	// clustered volume drivers might not know a volume right after its creation
+	volumeCreateInspectTimeout = 10 * time.Second
 )
 
+// volumeLabelSchema is the labelSchema with a warning for labels in the namespaces
+// reserved for Docker, as they are prone to perpetual diffs.
+var volumeLabelSchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"label": {
+			Type:             schema.TypeString,
+			Description:      "Name of the label",
+			Required:         true,
+			ForceNew:         true,
+			ValidateDiagFunc: validateLabelIsNotReserved(),
+			DiffSuppressFunc: suppressLabelWhitespaceDiff,
+		},
+		"value": {
+			Type:             schema.TypeString,
+			Description:      "Value of the label",
+			Required:         true,
+			ForceNew:         true,
+			DiffSuppressFunc: suppressLabelWhitespaceDiff,
+		},
+	},
+}
+
 func resourceDockerVolume() *schema.Resource {
 	return &schema.Resource{
 		Description: "Creates and destroys a volume in Docker. This can be used alongside [docker_container](container.md) to prepare volumes that can be shared across containers.",
 
 		CreateContext: resourceDockerVolumeCreate,
+		CustomizeDiff: resourceDockerVolumeCustomizeDiff,
 		ReadContext:   resourceDockerVolumeRead,
+		UpdateContext: resourceDockerVolumeUpdate,
 		DeleteContext: resourceDockerVolumeDelete,
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: resourceDockerVolumeImport,
 		},
 
 		Schema: map[string]*schema.Schema{
@@ -41,37 +76,79 @@ func resourceDockerVolume() *schema.Resource {
 				Elem:        overrideSchemaElem,
 			},
 			"name": {
-				Type:        schema.TypeString,
-				Description: "The name of the Docker volume (will be generated if not provided).",
-				Optional:    true,
-				Computed:    true,
-				ForceNew:    true,
+				Type:          schema.TypeString,
+				Description:   "The name of the Docker volume (will be generated if not provided). Conflicts with `name_prefix`.",
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"name_prefix"},
+			},
+			"name_prefix": {
+				Type:             schema.TypeString,
+				Description:      "Creates a volume with a unique name beginning with the given prefix, in the form `<prefix>-<suffix>`. The generated name is stored in `name`. Conflicts with `name`.",
+				Optional:         true,
+				ForceNew:         true,
+				ConflictsWith:    []string{"name"},
+				ValidateDiagFunc: validateStringMatchesPattern(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`),
 			},
 			"labels": {
 				Type:        schema.TypeSet,
 				Description: "User-defined key/value metadata",
 				Optional:    true,
 				ForceNew:    true,
-				Elem:        labelSchema,
+				Elem:        volumeLabelSchema,
+			},
+			"all_labels": {
+				Type:        schema.TypeMap,
+				Description: "All labels of the volume, including the `default_labels` of the provider.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"default_labels": {
+				Type:        schema.TypeMap,
+				Description: "The labels of the volume which were added by the `default_labels` of the provider. They are not shown in `labels`, also after they are removed from the `default_labels`.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
 			"driver": {
 				Type:        schema.TypeString,
-				Description: "Driver type for the volume. Defaults to `local`.",
+				Description: "Driver type for the volume. Defaults to the `default_volume_driver` of the provider, or `local` if it is not set.",
 				Optional:    true,
 				Computed:    true,
 				ForceNew:    true,
 			},
 			"driver_opts": {
 				Type:        schema.TypeMap,
-				Description: "Options specific to the driver.",
+				Description: "Options specific to the driver. Only the configured options are tracked, so options which the driver adds on its own don't cause a diff.",
 				Optional:    true,
 				ForceNew:    true,
 			},
+			"quota_bytes": {
+				Type:        schema.TypeInt,
+				Description: "The size quota of a volume of the `local` driver in bytes, which is set with the `size` driver option, e.g. `10G`. The quota is enforced with project quotas, so the data root of Docker must be on `xfs` mounted with `pquota`. `0` if the volume has no quota.",
+				Computed:    true,
+			},
 			"mountpoint": {
 				Type:        schema.TypeString,
 				Description: "The mountpoint of the volume.",
 				Computed:    true,
 			},
+			"created_at": {
+				Type:        schema.TypeString,
+				Description: "The time the volume was created in RFC3339 format.",
+				Computed:    true,
+			},
+			"scope": {
+				Type:        schema.TypeString,
+				Description: "The scope of the volume, either `local` for a single host or `global` for the whole cluster.",
+				Computed:    true,
+			},
+			"adopt_existing": {
+				Type:        schema.TypeBool,
+				Description: "If `true`, an already existing volume with the given `name` is taken over into the state instead of failing the creation. The driver, labels and driver options of the existing volume must match the configuration. Defaults to `false`.",
+				Optional:    true,
+				Default:     false,
+			},
 		},
 		SchemaVersion: 1,
 		StateUpgraders: []schema.StateUpgrader{
@@ -87,7 +164,9 @@ func resourceDockerVolume() *schema.Resource {
 }
 
 func resourceDockerVolumeCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client, errC := meta.(*ProviderConfig).MakeClient(ctx, d)
+	ctx = logResourceContext(ctx, "docker_volume", d)
+	providerConfig := meta.(*ProviderConfig)
+	client, errC := providerConfig.MakeClient(ctx, d)
 	if errC != nil {
 		return diag.Errorf(fmt.Sprint(errC))
 	}
@@ -96,55 +175,438 @@ func resourceDockerVolumeCreate(ctx context.Context, d *schema.ResourceData, met
 
 	if v, ok := d.GetOk("name"); ok {
 		createOpts.Name = v.(string)
+	} else if v, ok := d.GetOk("name_prefix"); ok {
+		name, err := generateVolumeName(ctx, providerConfig, client, v.(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		createOpts.Name = name
 	}
 	if v, ok := d.GetOk("labels"); ok {
 		createOpts.Labels = labelSetToMap(v.(*schema.Set))
 	}
 	if v, ok := d.GetOk("driver"); ok {
 		createOpts.Driver = v.(string)
+	} else {
+		createOpts.Driver = meta.(*ProviderConfig).DefaultVolumeDriver
 	}
 	if v, ok := d.GetOk("driver_opts"); ok {
 		createOpts.DriverOpts = mapTypeMapValsToString(v.(map[string]interface{}))
 	}
 
+	// Docker returns an already existing volume with the same name and driver
+	// instead of failing, so it would be taken over without adopt_existing.
+	if createOpts.Name != "" {
+		err := providerConfig.withRetry(ctx, "inspect volume", func() error {
+			_, err := client.VolumeInspect(ctx, createOpts.Name)
+			return err
+		})
+		if err == nil {
+			if d.Get("adopt_existing").(bool) {
+				return resourceDockerVolumeAdopt(ctx, d, meta, client, createOpts)
+			}
+			return diag.Errorf("Unable to create volume: a volume with the name '%s' already exists. Set 'adopt_existing' to manage the existing volume or choose a different name", createOpts.Name)
+		} else if !errdefs.IsNotFound(err) {
+			return diag.Errorf("Unable to inspect volume '%s': %s", createOpts.Name, err)
+		}
+	}
+
 	var err error
 	var retVolume types.Volume
-	retVolume, err = client.VolumeCreate(ctx, createOpts)
+	createOptsWithDefaults := createOpts
+	createOptsWithDefaults.Labels = mergeDefaultLabels(providerConfig.DefaultLabels, createOpts.Labels)
+	createVolume := func() error {
+		var err error
+		retVolume, err = client.VolumeCreate(ctx, createOptsWithDefaults)
+		return err
+	}
+	if createOpts.Name != "" {
+		// creating a named volume again returns the same volume, but an unnamed
+		// volume would be created twice if only the response got lost
+		err = providerConfig.withRetry(ctx, "create volume", createVolume)
+	} else {
+		err = createVolume()
+	}
+	defer func() {
+		// The volume was created but the apply got cancelled before it was
+		// added to the state, so remove it instead of leaking it.
+		if err == nil && d.Id() == "" && ctx.Err() != nil {
+			removeVolumeAfterCancelledCreate(ctx, client, retVolume.Name)
+		}
+	}()
 
 	if err != nil {
+		if createOpts.Name != "" && containsIgnorableErrorMessage(err.Error(), "volume name must be unique", "already exists") {
+			if d.Get("adopt_existing").(bool) {
+				return resourceDockerVolumeAdopt(ctx, d, meta, client, createOpts)
+			}
+			return diag.Errorf("Unable to create volume: a volume with the name '%s' already exists. Set 'adopt_existing' to manage the existing volume or choose a different name: %s", createOpts.Name, err)
+		}
+		if containsIgnorableErrorMessage(err.Error(), "no quota support") {
+			return diag.Errorf("Unable to create volume: the size driver option of the local driver needs the data root of Docker on xfs mounted with pquota: %s", err)
+		}
 		return diag.Errorf("Unable to create volume: %s", err)
 	}
 
+	if ctx.Err() != nil {
+		return diag.Errorf("Creation of volume '%s' was cancelled: %s", retVolume.Name, ctx.Err())
+	}
+
 	d.SetId(retVolume.Name)
 	return resourceDockerVolumeRead(ctx, d, meta)
 }
 
+// resourceDockerVolumeCustomizeDiff validates the size driver option of the local
+// driver, as the daemon only rejects an invalid size on create.
+func resourceDockerVolumeCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	rawConfig := d.GetRawConfig()
+	if rawConfig.IsNull() || !d.NewValueKnown("driver_opts") {
+		return nil
+	}
+	// an unset driver is computed, so the default driver of the provider is used
+	driver := meta.(*ProviderConfig).DefaultVolumeDriver
+	if configured := rawConfig.GetAttr("driver"); !configured.IsKnown() {
+		return nil
+	} else if !configured.IsNull() {
+		driver = configured.AsString()
+	}
+	_, err := volumeSizeQuota(driver, mapTypeMapValsToString(d.Get("driver_opts").(map[string]interface{})))
+	return err
+}
+
+// volumeSizeQuota returns the size quota in bytes of a volume of the local driver,
+// which parses the size driver option like the memory limits of containers.
+// Other drivers have their own units for a size, so their quota is 0.
+func volumeSizeQuota(driver string, options map[string]string) (int64, error) {
+	size, ok := options["size"]
+	if !ok || (driver != "" && driver != "local") {
+		return 0, nil
+	}
+	quota, err := units.RAMInBytes(size)
+	if err != nil || quota <= 0 {
+		return 0, fmt.Errorf("invalid size driver option '%s' of the local driver, it must be a positive size like 10G", size)
+	}
+	return quota, nil
+}
+
+// volumeNameAttempts is the number of names generateVolumeName tries before it gives up.
+const volumeNameAttempts = 5
+
+// generateVolumeName returns a name with the given prefix which is not used by
+// any volume of the Docker host. The suffix is unique within the provider, but
+// the volumes created by other processes have to be checked.
+func generateVolumeName(ctx context.Context, providerConfig *ProviderConfig, client *client.Client, prefix string) (string, error) {
+	for i := 0; i < volumeNameAttempts; i++ {
+		name := id.PrefixedUniqueId(prefix + "-")
+		err := providerConfig.withRetry(ctx, "inspect volume", func() error {
+			_, err := client.VolumeInspect(ctx, name)
+			return err
+		})
+		if errdefs.IsNotFound(err) {
+			return name, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("Unable to inspect volume '%s': %s", name, err)
+		}
+		tflog.Debug(ctx, "Generated volume name is already used", map[string]interface{}{"volume": name})
+	}
+	return "", fmt.Errorf("Unable to generate an unused volume name with the prefix '%s' after %d attempts", prefix, volumeNameAttempts)
+}
+
+// removeVolumeAfterCancelledCreate removes a volume detached from the cancellation
+// of the given context, as the context of the create operation is already cancelled.
+func removeVolumeAfterCancelledCreate(ctx context.Context, client *client.Client, volumeName string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), volumeReadRefreshTimeout)
+	defer cancel()
+
+	tflog.Warn(ctx, "Creation of volume was cancelled, removing it", map[string]interface{}{"volume": volumeName})
+	if err := client.VolumeRemove(ctx, volumeName, false); err != nil {
+		tflog.Warn(ctx, "Unable to remove volume after cancelled creation", map[string]interface{}{"volume": volumeName, "error": err.Error()})
+	}
+}
+
+// resourceDockerVolumeAdopt takes over an already existing volume into the state
+// if it matches the configuration.
+func resourceDockerVolumeAdopt(ctx context.Context, d *schema.ResourceData, meta interface{}, client *client.Client, createOpts volume.VolumeCreateBody) diag.Diagnostics {
+	var existing types.Volume
+	err := meta.(*ProviderConfig).withRetry(ctx, "inspect volume", func() error {
+		var err error
+		existing, err = client.VolumeInspect(ctx, createOpts.Name)
+		return err
+	})
+	if err != nil {
+		return diag.Errorf("Unable to inspect volume '%s': %s", createOpts.Name, err)
+	}
+
+	// the default labels of the provider are not required on adopted volumes
+	existing.Labels = removeDefaultLabels(existing.Labels, meta.(*ProviderConfig).DefaultLabels, createOpts.Labels)
+	if mismatches := volumeAdoptionMismatches(existing, createOpts); len(mismatches) > 0 {
+		return diag.Errorf("Unable to adopt existing volume '%s', it does not match the configuration: %s", createOpts.Name, strings.Join(mismatches, "; "))
+	}
+
+	tflog.Info(ctx, "Volume already exists, adopting it", map[string]interface{}{"volume": existing.Name})
+	d.SetId(existing.Name)
+	return resourceDockerVolumeRead(ctx, d, meta)
+}
+
+// volumeAdoptionMismatches describes the differences between an existing volume
+// and the options it would have been created with.
+func volumeAdoptionMismatches(existing types.Volume, createOpts volume.VolumeCreateBody) []string {
+	mismatches := []string{}
+
+	if createOpts.Driver != "" && createOpts.Driver != existing.Driver {
+		mismatches = append(mismatches, fmt.Sprintf("driver is '%s' but '%s' is configured", existing.Driver, createOpts.Driver))
+	}
+
+	mismatches = append(mismatches, stringMapMismatches("label", existing.Labels, createOpts.Labels)...)
+	mismatches = append(mismatches, stringMapMismatches("driver option", existing.Options, createOpts.DriverOpts)...)
+
+	return mismatches
+}
+
+func stringMapMismatches(kind string, existing, configured map[string]string) []string {
+	mismatches := []string{}
+
+	keys := make([]string, 0, len(existing)+len(configured))
+	for k := range configured {
+		keys = append(keys, k)
+	}
+	for k := range existing {
+		if _, ok := configured[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		existingValue, inExisting := existing[k]
+		configuredValue, inConfigured := configured[k]
+		switch {
+		case !inExisting:
+			mismatches = append(mismatches, fmt.Sprintf("%s '%s' is missing", kind, k))
+		case !inConfigured:
+			mismatches = append(mismatches, fmt.Sprintf("%s '%s' is not configured", kind, k))
+		case existingValue != configuredValue:
+			mismatches = append(mismatches, fmt.Sprintf("%s '%s' is '%s' but '%s' is configured", kind, k, existingValue, configuredValue))
+		}
+	}
+
+	return mismatches
+}
+
+func resourceDockerVolumeUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// only 'adopt_existing' can change without forcing a new volume
+	return resourceDockerVolumeRead(ctx, d, meta)
+}
+
 func resourceDockerVolumeRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	ctx = logResourceContext(ctx, "docker_volume", d)
 	client, errC := meta.(*ProviderConfig).MakeClient(ctx, d)
 	if errC != nil {
 		return diag.Errorf(fmt.Sprint(errC))
 	}
 
-	volume, err := client.VolumeInspect(ctx, d.Id())
+	var volume types.Volume
+	err := meta.(*ProviderConfig).withRetry(ctx, "inspect volume", func() error {
+		var err error
+		volume, err = client.VolumeInspect(ctx, d.Id())
+		return err
+	})
+	if errdefs.IsNotFound(err) && d.IsNewResource() {
+		volume, err = waitForCreatedVolume(ctx, meta.(*ProviderConfig), client, d.Id(), volumeCreateInspectTimeout)
+	}
 
 	if err != nil {
 		return diag.Errorf("Unable to inspect volume: %s", err)
 	}
 
 	jsonObj, _ := json.MarshalIndent(volume, "", "\t")
-	log.Printf("[DEBUG] Docker volume inspect from readFunc: %s", jsonObj)
+	tflog.Debug(ctx, "Docker volume inspect from readFunc", map[string]interface{}{"inspect": string(jsonObj)})
 
-	d.Set("name", volume.Name)
-	d.Set("labels", mapToLabelSet(volume.Labels))
+	// the default labels are remembered, as the labels are ForceNew and a default
+	// label which is removed from the provider would replace the volume otherwise
+	declaredLabels := labelSetToMap(d.Get("labels").(*schema.Set))
+	defaultLabels := mergeDefaultLabels(mapTypeMapValsToString(d.Get("default_labels").(map[string]interface{})), meta.(*ProviderConfig).DefaultLabels)
+	labels := removeDefaultLabels(volume.Labels, defaultLabels, declaredLabels)
+	addedDefaultLabels := map[string]string{}
+	for k, v := range volume.Labels {
+		if _, ok := labels[k]; !ok {
+			addedDefaultLabels[k] = v
+		}
+	}
+	d.Set("labels", mapToLabelSet(labels))
+	d.Set("default_labels", addedDefaultLabels)
+	d.Set("all_labels", volume.Labels)
 	d.Set("driver", volume.Driver)
-	d.Set("driver_opts", volume.Options)
+	// the volume is imported if it has not been read before and is not new
+	if d.Get("name").(string) == "" && !d.IsNewResource() {
+		d.Set("driver_opts", volume.Options)
+	} else {
+		declaredDriverOpts := mapTypeMapValsToString(d.Get("driver_opts").(map[string]interface{}))
+		d.Set("driver_opts", flattenVolumeDriverOpts(volume.Options, declaredDriverOpts))
+	}
+	d.Set("name", volume.Name)
+	quotaBytes, err := volumeSizeQuota(volume.Driver, volume.Options)
+	if err != nil {
+		tflog.Warn(ctx, "Unable to parse the size of the volume", map[string]interface{}{"error": err.Error()})
+	}
+	d.Set("quota_bytes", quotaBytes)
 	d.Set("mountpoint", volume.Mountpoint)
+	d.Set("created_at", formatVolumeCreatedAt(ctx, volume.CreatedAt))
+	d.Set("scope", volume.Scope)
 
 	return nil
 }
 
+// waitForCreatedVolume inspects a volume which was just created until the driver
+// knows it, as clustered drivers are only eventually consistent.
+func waitForCreatedVolume(ctx context.Context, providerConfig *ProviderConfig, client *client.Client, volumeName string, timeout time.Duration) (types.Volume, error) {
+	tflog.Info(ctx, "Created volume is not found yet, waiting for it", map[string]interface{}{"volume": volumeName, "timeout": timeout.String()})
+
+	var volume types.Volume
+	err := retry.RetryContext(ctx, timeout, func() *retry.RetryError {
+		err := providerConfig.withRetry(ctx, "inspect volume", func() error {
+			var err error
+			volume, err = client.VolumeInspect(ctx, volumeName)
+			return err
+		})
+		if errdefs.IsNotFound(err) {
+			return retry.RetryableError(err)
+		}
+		if err != nil {
+			return retry.NonRetryableError(err)
+		}
+		return nil
+	})
+	return volume, err
+}
+
+// resourceDockerVolumeImport resolves the given import ID to the name of the volume.
+// Besides the plain volume name, the mountpoint of the volume on the host and the
+// '<driver>/<name>' form are accepted.
+func resourceDockerVolumeImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	importID := d.Id()
+	ctx = logResourceContext(ctx, "docker_volume", nil)
+	if !isVolumeMountpoint(importID) && !strings.Contains(importID, "/") {
+		return []*schema.ResourceData{d}, nil
+	}
+
+	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*types.Volume
+	if isVolumeMountpoint(importID) {
+		volumes, err := client.VolumeList(ctx, filters.NewArgs())
+		if err != nil {
+			return nil, fmt.Errorf("unable to list volumes: %s", err)
+		}
+		matches = filterVolumesByMountpoint(volumes.Volumes, importID)
+	} else {
+		// driver names of plugins can contain slashes, volume names can't
+		idx := strings.LastIndex(importID, "/")
+		driver, name := importID[:idx], importID[idx+1:]
+		volumes, err := client.VolumeList(ctx, buildFilters(map[string][]string{
+			"driver": {driver},
+			"name":   {name},
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("unable to list volumes: %s", err)
+		}
+		// the name filter matches substrings
+		for _, v := range volumes.Volumes {
+			if v.Name == name && v.Driver == driver {
+				matches = append(matches, v)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no volume found for '%s'", importID)
+	case 1:
+		tflog.Debug(ctx, "Resolved volume import ID", map[string]interface{}{"import_id": importID, "volume": matches[0].Name})
+		d.SetId(matches[0].Name)
+		return []*schema.ResourceData{d}, nil
+	default:
+		names := make([]string, 0, len(matches))
+		for _, v := range matches {
+			names = append(names, v.Name)
+		}
+		return nil, fmt.Errorf("'%s' matches multiple volumes: %s", importID, strings.Join(names, ", "))
+	}
+}
+
+// isVolumeMountpoint returns true if the given import ID is a path on the host
+// instead of a volume name.
+func isVolumeMountpoint(importID string) bool {
+	return strings.HasPrefix(importID, "/") || strings.Contains(importID, "\\")
+}
+
+// filterVolumesByMountpoint returns the volumes which are mounted at the given host path.
+func filterVolumesByMountpoint(volumes []*types.Volume, mountpoint string) []*types.Volume {
+	var matches []*types.Volume
+	for _, v := range volumes {
+		if v.Mountpoint == "" {
+			continue
+		}
+		if v.Mountpoint == mountpoint || path.Clean(v.Mountpoint) == path.Clean(mountpoint) {
+			matches = append(matches, v)
+		}
+	}
+	return matches
+}
+
+// flattenVolumeDriverOpts returns the driver options of a volume for the state.
+// Drivers can add options of their own or omit the configured ones, so only the
+// declared options are tracked. The declared value is kept if the driver omits
+// the option or only reorders the mount options of 'o'.
+func flattenVolumeDriverOpts(options, declared map[string]string) map[string]string {
+	driverOpts := make(map[string]string, len(declared))
+	for k, declaredValue := range declared {
+		value, ok := options[k]
+		if !ok || value == declaredValue || (k == "o" && sameMountOptions(value, declaredValue)) {
+			driverOpts[k] = declaredValue
+			continue
+		}
+		driverOpts[k] = value
+	}
+	return driverOpts
+}
+
+// sameMountOptions returns true if the comma-separated mount options are the same
+// regardless of their order and surrounding whitespace, e.g. 'addr=10.0.0.1,rw'
+// and 'rw, addr=10.0.0.1'.
+func sameMountOptions(a, b string) bool {
+	split := func(options string) []string {
+		parts := strings.Split(options, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		sort.Strings(parts)
+		return parts
+	}
+	return reflect.DeepEqual(split(a), split(b))
+}
+
+// formatVolumeCreatedAt normalizes the creation time reported by the daemon to
+// RFC3339 in UTC, so it does not depend on the timezone of the daemon.
+func formatVolumeCreatedAt(ctx context.Context, createdAt string) string {
+	if createdAt == "" {
+		return ""
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		tflog.Warn(ctx, "Unable to parse volume creation time", map[string]interface{}{"created_at": createdAt, "error": err.Error()})
+		return createdAt
+	}
+	return parsed.UTC().Format(time.RFC3339)
+}
+
 func resourceDockerVolumeDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	log.Printf("[INFO] Waiting for volume: '%s' to get removed: max '%v seconds'", d.Id(), volumeReadRefreshTimeout)
+	ctx = logResourceContext(ctx, "docker_volume", d)
+	tflog.Info(ctx, "Waiting for volume to get removed", map[string]interface{}{"timeout": volumeReadRefreshTimeout.String()})
 
 	stateConf := &retry.StateChangeConf{
 		Pending:    []string{"in_use"},
@@ -175,15 +637,23 @@ func resourceDockerVolumeRemoveRefreshFunc(ctx context.Context, d *schema.Resour
 
 		forceDelete := true
 
-		if err := client.VolumeRemove(ctx, volumeID, forceDelete); err != nil {
+		err := meta.(*ProviderConfig).withRetry(ctx, "remove volume", func() error {
+			return client.VolumeRemove(ctx, volumeID, forceDelete)
+		})
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				// e.g. the volume was removed, but the response got lost and the removal was retried
+				tflog.Info(ctx, "Volume is already removed")
+				return volumeID, "removed", nil
+			}
 			if containsIgnorableErrorMessage(err.Error(), "volume is in use") {
-				log.Printf("[INFO] Volume with id '%v' is still in use", volumeID)
+				tflog.Info(ctx, "Volume is still in use")
 				return volumeID, "in_use", nil
 			}
-			log.Printf("[INFO] Removing volume with id '%v' caused an error: %v", volumeID, err)
+			tflog.Info(ctx, "Removing volume caused an error", map[string]interface{}{"error": err.Error()})
 			return nil, "", err
 		}
-		log.Printf("[INFO] Removing volume with id '%v' got removed", volumeID)
+		tflog.Info(ctx, "Volume got removed")
 		return volumeID, "removed", nil
 	}
 }
@@ -0,0 +1,281 @@
+package provider
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	volumeBackupDirectionBackup  = "backup"
+	volumeBackupDirectionRestore = "restore"
+	// volumeBackupMountPath is the path the volume is mounted at in the helper container.
+	// It is also the top-level directory of the tar archive.
+	volumeBackupMountPath = "/volume"
+)
+
+func resourceDockerVolumeBackup() *schema.Resource {
+	return &schema.Resource{
+		Description: "Copies the content of a volume into a tar archive on the machine running terraform, or restores the content of a volume from such an archive. The data is transferred through a helper container with the volume mounted, which is never started and removed afterwards. The content of the volume is stored in the top-level directory `volume` of the archive. Deleting the resource neither deletes the archive nor the volume.",
+
+		CreateContext: resourceDockerVolumeBackupCreate,
+		ReadContext:   resourceDockerVolumeBackupRead,
+		DeleteContext: resourceDockerVolumeBackupDelete,
+
+		Schema: map[string]*schema.Schema{
+			"override": {
+				Type:        schema.TypeList,
+				Description: "Override Provider config",
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Elem:        overrideSchemaElem,
+			},
+			"volume": {
+				Type:        schema.TypeString,
+				Description: "The name of the volume.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"path": {
+				Type:        schema.TypeString,
+				Description: "The path of the tar archive on the machine running terraform. To restore an archive which was not created by a backup, all of its entries must be in the top-level directory `volume`, otherwise the restore fails.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"direction": {
+				Type:             schema.TypeString,
+				Description:      "Either `backup` to write the content of the volume into the archive or `restore` to extract the archive into the volume. Defaults to `backup`.",
+				Optional:         true,
+				Default:          volumeBackupDirectionBackup,
+				ForceNew:         true,
+				ValidateDiagFunc: validateStringMatchesPattern(`^(backup|restore)$`),
+			},
+			"image": {
+				Type:        schema.TypeString,
+				Description: "The image of the helper container. It is pulled if it does not exist. Defaults to `alpine:latest`.",
+				Optional:    true,
+				Default:     "alpine:latest",
+				ForceNew:    true,
+			},
+			"size": {
+				Type:        schema.TypeInt,
+				Description: "The number of bytes of the tar archive which were transferred.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceDockerVolumeBackupCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	ctx = logResourceContext(ctx, "docker_volume_backup", d)
+	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if err != nil {
+		return diag.Errorf("failed to create Docker client: %v", err)
+	}
+
+	volumeName := d.Get("volume").(string)
+	direction := d.Get("direction").(string)
+	archivePath := d.Get("path").(string)
+
+	if _, err := client.VolumeInspect(ctx, volumeName); err != nil {
+		return diag.Errorf("Unable to inspect volume '%s': %s", volumeName, err)
+	}
+
+	image := d.Get("image").(string)
+	if _, err := findImage(ctx, image, client, meta.(*ProviderConfig), ""); err != nil {
+		return diag.Errorf("Unable to find or pull image %s: %s", image, err)
+	}
+
+	helper, err := client.ContainerCreate(ctx, &container.Config{Image: image}, &container.HostConfig{
+		Mounts: []mount.Mount{{
+			Type:     mount.TypeVolume,
+			Source:   volumeName,
+			Target:   volumeBackupMountPath,
+			ReadOnly: direction == volumeBackupDirectionBackup,
+		}},
+	}, nil, nil, "")
+	if err != nil {
+		return diag.Errorf("Unable to create helper container for volume '%s': %s", volumeName, err)
+	}
+	defer removeVolumeBackupHelper(ctx, client, helper.ID)
+
+	var size int64
+	if direction == volumeBackupDirectionRestore {
+		size, err = restoreVolumeFromArchive(ctx, client, helper.ID, archivePath)
+	} else {
+		size, err = backupVolumeToArchive(ctx, client, helper.ID, archivePath)
+	}
+	if err != nil {
+		return diag.Errorf("Unable to %s volume '%s' with archive '%s': %s", direction, volumeName, archivePath, err)
+	}
+	tflog.Info(ctx, "Transferred the archive of the volume", map[string]interface{}{
+		"volume":    volumeName,
+		"direction": direction,
+		"path":      archivePath,
+		"size":      size,
+	})
+
+	d.SetId(fmt.Sprintf("%s:%s", volumeName, direction))
+	d.Set("size", size)
+
+	return resourceDockerVolumeBackupRead(ctx, d, meta)
+}
+
+func resourceDockerVolumeBackupRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	ctx = logResourceContext(ctx, "docker_volume_backup", d)
+	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if err != nil {
+		return diag.Errorf("failed to create Docker client: %v", err)
+	}
+
+	volumeName := d.Get("volume").(string)
+	if _, err := client.VolumeInspect(ctx, volumeName); err != nil {
+		if errdefs.IsNotFound(err) {
+			tflog.Warn(ctx, "Volume does not exist anymore, removing it from state", map[string]interface{}{"volume": volumeName})
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("Unable to inspect volume '%s': %s", volumeName, err)
+	}
+
+	// a backup is taken again if its archive got deleted
+	if d.Get("direction").(string) == volumeBackupDirectionBackup {
+		if _, err := os.Stat(d.Get("path").(string)); os.IsNotExist(err) {
+			tflog.Warn(ctx, "Archive of the volume does not exist anymore, removing the backup from state", map[string]interface{}{
+				"volume": volumeName,
+				"path":   d.Get("path").(string),
+			})
+			d.SetId("")
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func resourceDockerVolumeBackupDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// neither the archive nor the volume are deleted
+	d.SetId("")
+	return nil
+}
+
+// backupVolumeToArchive writes the content of the volume mounted in the helper container
+// into the archive and returns the size of the archive. The archive is written to a
+// temporary file next to it first, so a failed backup does not leave a partial archive
+// which would be taken for a valid one.
+func backupVolumeToArchive(ctx context.Context, client *client.Client, helperID string, archivePath string) (int64, error) {
+	reader, _, err := client.CopyFromContainer(ctx, helperID, volumeBackupMountPath)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	archive, err := os.CreateTemp(filepath.Dir(archivePath), filepath.Base(archivePath)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(archive.Name())
+
+	size, err := io.Copy(archive, reader)
+	if err != nil {
+		archive.Close()
+		return size, err
+	}
+	if err := archive.Close(); err != nil {
+		return size, err
+	}
+	return size, os.Rename(archive.Name(), archivePath)
+}
+
+// restoreVolumeFromArchive extracts the archive into the volume mounted in the helper
+// container and returns the size of the archive.
+func restoreVolumeFromArchive(ctx context.Context, client *client.Client, helperID string, archivePath string) (int64, error) {
+	archiveFile, err := os.Open(archivePath)
+	if err != nil {
+		return 0, err
+	}
+	defer archiveFile.Close()
+
+	info, err := archiveFile.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	// the archive contains the top-level directory of the mount path, entries outside
+	// of it would be extracted into the helper container and silently discarded
+	if err := checkVolumeArchive(archiveFile); err != nil {
+		return 0, err
+	}
+	if _, err := archiveFile.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	if err := client.CopyToContainer(ctx, helperID, "/", archiveFile, types.CopyToContainerOptions{}); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// checkVolumeArchive checks that all entries of the, possibly compressed, tar archive
+// are in the top-level directory of the mount path, like in the archives of a backup.
+func checkVolumeArchive(reader io.Reader) error {
+	decompressed, err := archive.DecompressStream(reader)
+	if err != nil {
+		return fmt.Errorf("unable to read archive: %s", err)
+	}
+	defer decompressed.Close()
+
+	topLevelDir := strings.TrimPrefix(volumeBackupMountPath, "/")
+	tarReader := tar.NewReader(decompressed)
+	entries := 0
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read archive: %s", err)
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if name == "." {
+			continue
+		}
+		if name != topLevelDir && !strings.HasPrefix(name, topLevelDir+"/") {
+			return fmt.Errorf("entry '%s' of the archive is not in the top-level directory '%s', the archive must be created by a backup or contain the content of the volume in '%s'", header.Name, topLevelDir, topLevelDir)
+		}
+		entries++
+	}
+	if entries == 0 {
+		return fmt.Errorf("the archive is empty")
+	}
+	return nil
+}
+
+// removeVolumeBackupHelper removes the helper container, also if the context got cancelled.
+func removeVolumeBackupHelper(ctx context.Context, client *client.Client, helperID string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), volumeReadRefreshTimeout)
+	defer cancel()
+
+	if err := client.ContainerRemove(ctx, helperID, types.ContainerRemoveOptions{Force: true}); err != nil {
+		tflog.Warn(ctx, "Unable to remove helper container", map[string]interface{}{
+			"container_id": helperID,
+			"error":        err.Error(),
+		})
+	}
+}
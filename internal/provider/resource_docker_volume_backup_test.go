@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccDockerVolumeBackup_backupAndRestore(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "volume.tar")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccDockerVolumeBackupConfig, archivePath),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("docker_volume_backup.backup", "id", "testAccDockerVolumeBackup_source:backup"),
+					testValueHigherEqualThan("docker_volume_backup.backup", "size", 1),
+					resource.TestCheckResourceAttr("docker_volume_backup.restore", "id", "testAccDockerVolumeBackup_target:restore"),
+					resource.TestCheckResourceAttrPair("docker_volume_backup.restore", "size", "docker_volume_backup.backup", "size"),
+					testCheckVolumeArchiveExists(archivePath),
+				),
+			},
+		},
+	})
+}
+
+func TestBackupVolumeToArchive(t *testing.T) {
+	// a Docker host whose archive of the helper container 'broken' is cut off
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/archive"):
+			w.Header().Set("X-Docker-Container-Path-Stat", base64.StdEncoding.EncodeToString([]byte(`{"name": "volume", "mode": 2147484141}`)))
+			if strings.Contains(r.URL.Path, "/containers/broken/") {
+				w.Header().Set("Content-Length", "100")
+			}
+			fmt.Fprint(w, "archive")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+	client, err := providerConfig.MakeClient(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "volume.tar")
+	if _, err := backupVolumeToArchive(context.Background(), client, "broken", archivePath); err == nil {
+		t.Fatal("Expected the cut off archive to fail the backup")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("Expected no partial archive to be left, got %v", entries)
+	}
+
+	size, err := backupVolumeToArchive(context.Background(), client, "helper", archivePath)
+	if err != nil {
+		t.Fatalf("Expected the backup to succeed, got %s", err)
+	}
+	if content, _ := os.ReadFile(archivePath); string(content) != "archive" || size != 7 {
+		t.Fatalf("Expected the archive to be written, got %q with size %d", content, size)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("Expected only the archive to be left, got %v", entries)
+	}
+}
+
+func TestCheckVolumeArchive(t *testing.T) {
+	tarball := func(names ...string) *bytes.Buffer {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, name := range names {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: 0}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return &buf
+	}
+
+	for _, names := range [][]string{
+		{"volume/", "volume/data.txt"},
+		{"./", "./volume/", "./volume/data.txt"},
+	} {
+		if err := checkVolumeArchive(tarball(names...)); err != nil {
+			t.Errorf("Expected the archive with %v to be valid, got %s", names, err)
+		}
+	}
+	for _, names := range [][]string{
+		{},
+		{"./", "./data.txt"},
+		{"volume/", "data.txt"},
+		{"volume/../etc/passwd"},
+		{"volumes/data.txt"},
+	} {
+		if err := checkVolumeArchive(tarball(names...)); err == nil {
+			t.Errorf("Expected the archive with %v to be rejected", names)
+		}
+	}
+}
+
+func testCheckVolumeArchiveExists(archivePath string) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		if _, err := os.Stat(archivePath); err != nil {
+			return fmt.Errorf("Archive of the volume does not exist: %s", err)
+		}
+		return nil
+	}
+}
+
+const testAccDockerVolumeBackupConfig = `
+resource "docker_volume" "source" {
+  name = "testAccDockerVolumeBackup_source"
+}
+
+resource "docker_volume" "target" {
+  name = "testAccDockerVolumeBackup_target"
+}
+
+resource "docker_volume_backup" "backup" {
+  volume = docker_volume.source.name
+  path   = "%[1]s"
+}
+
+resource "docker_volume_backup" "restore" {
+  volume    = docker_volume.target.name
+  path      = "%[1]s"
+  direction = "restore"
+
+  depends_on = [docker_volume_backup.backup]
+}
+`
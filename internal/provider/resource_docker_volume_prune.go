@@ -0,0 +1,209 @@
+package provider
+
+import (
+	"context"
+	"sort"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/versions"
+	"github.com/docker/docker/client"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// anonymousVolumeLabel is the label the daemon sets on anonymous volumes since API version 1.42.
+const anonymousVolumeLabel = "com.docker.volume.anonymous"
+
+func resourceDockerVolumePrune() *schema.Resource {
+	return &schema.Resource{
+		Description: "Removes the volumes which are not used by any container, like `docker volume prune` does. The volumes are pruned when the resource is created, change the `triggers` to prune again. Volumes in use are never removed by the daemon, they are reported in `volumes_in_use`. With API version 1.42 and newer, the daemon only prunes anonymous volumes unless `all` is set. Deleting the resource only removes it from the state.",
+
+		CreateContext: resourceDockerVolumePruneCreate,
+		ReadContext:   resourceDockerVolumePruneRead,
+		DeleteContext: resourceDockerVolumePruneDelete,
+
+		Schema: map[string]*schema.Schema{
+			"override": {
+				Type:        schema.TypeList,
+				Description: "Override Provider config",
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Elem:        overrideSchemaElem,
+			},
+			"label_filters": {
+				Type:        schema.TypeList,
+				Description: "Only prune the volumes with all of these labels, either in the form `key` to match any value or `key=value`.",
+				Optional:    true,
+				ForceNew:    true,
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validateLabelFilter(),
+				},
+			},
+			"all": {
+				Type:        schema.TypeBool,
+				Description: "If `true`, unused named volumes are pruned as well, which includes `docker_volume` resources not mounted by any container at the moment. Otherwise only anonymous volumes are pruned. Only has an effect with API version 1.42 and newer, older daemons always prune all unused volumes. Defaults to `false`.",
+				Optional:    true,
+				Default:     false,
+				ForceNew:    true,
+			},
+			"dry_run": {
+				Type:        schema.TypeBool,
+				Description: "If `true`, the volumes which would be pruned are only reported in `removed_volumes` but not removed. Defaults to `false`.",
+				Optional:    true,
+				Default:     false,
+				ForceNew:    true,
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Description: "A map of arbitrary strings that, when changed, will force the volumes to be pruned again.",
+				Optional:    true,
+				ForceNew:    true,
+			},
+			"removed_volumes": {
+				Type:        schema.TypeList,
+				Description: "The names of the pruned volumes, or of the volumes which would be pruned with `dry_run`.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"volumes_in_use": {
+				Type:        schema.TypeList,
+				Description: "The names of the volumes matching the `label_filters` which were skipped, because they are used by a container.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"space_reclaimed": {
+				Type:        schema.TypeInt,
+				Description: "The disk space reclaimed in bytes, or the disk space which would be reclaimed with `dry_run`.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceDockerVolumePruneCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	ctx = logResourceContext(ctx, "docker_volume_prune", d)
+	client, err := meta.(*ProviderConfig).MakeClient(ctx, d)
+	if err != nil {
+		return diag.Errorf("failed to create Docker client: %v", err)
+	}
+
+	pruneFilters := buildFilters(map[string][]string{"label": stringListToStringSlice(d.Get("label_filters").([]interface{}))})
+	all := d.Get("all").(bool)
+
+	volumesInUse, err := listVolumeNames(ctx, client, pruneFilters, "false")
+	if err != nil {
+		return diag.Errorf("Unable to list the volumes in use: %s", err)
+	}
+
+	var removedVolumes []string
+	var spaceReclaimed int64
+	if d.Get("dry_run").(bool) {
+		removedVolumes, err = listVolumeNames(ctx, client, unusedVolumeFilters(pruneFilters, client.ClientVersion(), all), "true")
+		if err != nil {
+			return diag.Errorf("Unable to list the unused volumes: %s", err)
+		}
+		spaceReclaimed, err = volumesSize(ctx, client, removedVolumes)
+		if err != nil {
+			return diag.Errorf("Unable to get the size of the unused volumes: %s", err)
+		}
+		tflog.Info(ctx, "Volumes would be pruned", map[string]interface{}{"volumes": removedVolumes, "space_reclaimed": spaceReclaimed})
+	} else {
+		report, err := client.VolumesPrune(ctx, volumePruneFilters(pruneFilters, client.ClientVersion(), all))
+		if err != nil {
+			return diag.Errorf("Unable to prune volumes: %s", err)
+		}
+		removedVolumes = report.VolumesDeleted
+		sort.Strings(removedVolumes)
+		spaceReclaimed = int64(report.SpaceReclaimed)
+		tflog.Info(ctx, "Pruned volumes", map[string]interface{}{"volumes": removedVolumes, "space_reclaimed": spaceReclaimed})
+	}
+
+	d.SetId(id.PrefixedUniqueId("volume-prune-"))
+	d.Set("removed_volumes", removedVolumes)
+	d.Set("volumes_in_use", volumesInUse)
+	d.Set("space_reclaimed", spaceReclaimed)
+
+	return nil
+}
+
+func resourceDockerVolumePruneRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// the prune is a one-off operation, there is nothing to refresh
+	return nil
+}
+
+func resourceDockerVolumePruneDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
+
+// volumePruneFilters returns the filters of the prune for the API version. Since API
+// version 1.42 the daemon only prunes anonymous volumes unless the all filter is set.
+func volumePruneFilters(args filters.Args, apiVersion string, all bool) filters.Args {
+	if !all || versions.LessThan(apiVersion, "1.42") {
+		return args
+	}
+	args = args.Clone()
+	args.Add("all", "true")
+	return args
+}
+
+// unusedVolumeFilters returns the filters to list the volumes the prune would remove
+// for dry_run. Without all, the daemon of API version 1.42 and newer only prunes the
+// volumes it labeled as anonymous.
+func unusedVolumeFilters(args filters.Args, apiVersion string, all bool) filters.Args {
+	if all || versions.LessThan(apiVersion, "1.42") {
+		return args
+	}
+	args = args.Clone()
+	args.Add("label", anonymousVolumeLabel)
+	return args
+}
+
+// listVolumeNames returns the sorted names of the volumes matching the filters, which are
+// in use if dangling is 'false' and unused if dangling is 'true'.
+func listVolumeNames(ctx context.Context, client *client.Client, args filters.Args, dangling string) ([]string, error) {
+	args = args.Clone()
+	args.Add("dangling", dangling)
+
+	volumes, err := client.VolumeList(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(volumes.Volumes))
+	for _, v := range volumes.Volumes {
+		names = append(names, v.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// volumesSize sums up the disk usage of the given volumes. Volumes whose size the
+// daemon does not know, e.g. of remote drivers, are not counted.
+func volumesSize(ctx context.Context, client *client.Client, names []string) (int64, error) {
+	if len(names) == 0 {
+		return 0, nil
+	}
+
+	usage, err := client.DiskUsage(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	var size int64
+	for _, v := range usage.Volumes {
+		if wanted[v.Name] && v.UsageData != nil && v.UsageData.Size > 0 {
+			size += v.UsageData.Size
+		}
+	}
+	return size, nil
+}
@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccDockerVolumePrune_basic(t *testing.T) {
+	unusedVolume := "tf-test-volume-prune-unused"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			// the volume is not managed by terraform, as it would be recreated after the prune
+			if err := exec.Command("docker", "volume", "create", "--label", "com.example.tf-test=volume-prune", unusedVolume).Run(); err != nil {
+				t.Fatal(err)
+			}
+		},
+		ProviderFactories: providerFactories,
+		CheckDestroy: func(*terraform.State) error {
+			_ = exec.Command("docker", "volume", "rm", "-f", unusedVolume).Run()
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccDockerVolumePruneConfig, true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("docker_volume_prune.foo", "removed_volumes.#", "1"),
+					resource.TestCheckResourceAttr("docker_volume_prune.foo", "removed_volumes.0", unusedVolume),
+					resource.TestCheckResourceAttr("docker_volume_prune.foo", "volumes_in_use.#", "1"),
+					resource.TestCheckResourceAttr("docker_volume_prune.foo", "volumes_in_use.0", "tf-test-volume-prune-in-use"),
+					testCheckVolumeExists(unusedVolume, true),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccDockerVolumePruneConfig, false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("docker_volume_prune.foo", "removed_volumes.#", "1"),
+					resource.TestCheckResourceAttr("docker_volume_prune.foo", "removed_volumes.0", unusedVolume),
+					resource.TestCheckResourceAttr("docker_volume_prune.foo", "volumes_in_use.0", "tf-test-volume-prune-in-use"),
+					testCheckVolumeExists(unusedVolume, false),
+					testCheckVolumeExists("tf-test-volume-prune-in-use", true),
+				),
+			},
+		},
+	})
+}
+
+func TestVolumePruneFilters(t *testing.T) {
+	args := filters.NewArgs(filters.Arg("label", "env=dev"))
+	if pruneFilters := volumePruneFilters(args, "1.41", true); pruneFilters.Contains("all") {
+		t.Fatalf("Expected no all filter for API version 1.41, got %v", pruneFilters)
+	}
+	if pruneFilters := volumePruneFilters(args, "1.42", false); pruneFilters.Contains("all") {
+		t.Fatalf("Expected no all filter unless all is set, got %v", pruneFilters)
+	}
+	pruneFilters := volumePruneFilters(args, "1.42", true)
+	if !pruneFilters.ExactMatch("all", "true") || !pruneFilters.ExactMatch("label", "env=dev") {
+		t.Fatalf("Expected the all and label filters for API version 1.42, got %v", pruneFilters)
+	}
+	if args.Contains("all") {
+		t.Fatal("Expected the given filters not to be changed")
+	}
+}
+
+func TestUnusedVolumeFilters(t *testing.T) {
+	args := filters.NewArgs(filters.Arg("label", "env=dev"))
+	if listFilters := unusedVolumeFilters(args, "1.41", false); listFilters.Len() != 1 {
+		t.Fatalf("Expected only the label filter for API version 1.41, got %v", listFilters)
+	}
+	if listFilters := unusedVolumeFilters(args, "1.42", true); listFilters.Len() != 1 {
+		t.Fatalf("Expected only the label filter with all, got %v", listFilters)
+	}
+	listFilters := unusedVolumeFilters(args, "1.42", false)
+	if !listFilters.ExactMatch("label", anonymousVolumeLabel) || !listFilters.ExactMatch("label", "env=dev") {
+		t.Fatalf("Expected the anonymous volumes to be listed for API version 1.42, got %v", listFilters)
+	}
+	if args.ExactMatch("label", anonymousVolumeLabel) {
+		t.Fatal("Expected the given filters not to be changed")
+	}
+}
+
+func testCheckVolumeExists(name string, exists bool) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		err := exec.Command("docker", "volume", "inspect", name).Run()
+		if exists && err != nil {
+			return fmt.Errorf("Volume %s should exist: %s", name, err)
+		}
+		if !exists && err == nil {
+			return fmt.Errorf("Volume %s should have been pruned", name)
+		}
+		return nil
+	}
+}
+
+const testAccDockerVolumePruneConfig = `
+resource "docker_image" "busybox" {
+  name = "busybox:latest"
+}
+
+resource "docker_volume" "in_use" {
+  name = "tf-test-volume-prune-in-use"
+  labels {
+    label = "com.example.tf-test"
+    value = "volume-prune"
+  }
+}
+
+resource "docker_container" "foo" {
+  name     = "tf-test-volume-prune"
+  image    = docker_image.busybox.image_id
+  command  = ["sleep", "600"]
+  must_run = true
+
+  volumes {
+    volume_name    = docker_volume.in_use.name
+    container_path = "/data"
+  }
+}
+
+resource "docker_volume_prune" "foo" {
+  label_filters = ["com.example.tf-test=volume-prune"]
+  all           = true
+  dry_run       = %t
+
+  depends_on = [docker_container.foo]
+}
+`
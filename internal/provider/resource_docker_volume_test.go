@@ -2,11 +2,19 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -27,17 +35,180 @@ func TestAccDockerVolume_basic(t *testing.T) {
 					checkDockerVolumeCreated("docker_volume.foo", &v),
 					resource.TestCheckResourceAttr("docker_volume.foo", "id", "testAccDockerVolume_basic"),
 					resource.TestCheckResourceAttr("docker_volume.foo", "name", "testAccDockerVolume_basic"),
+					resource.TestCheckResourceAttr("docker_volume.foo", "scope", "local"),
+					resource.TestCheckResourceAttrSet("docker_volume.foo", "created_at"),
 				),
 			},
 			{
 				ResourceName:      "docker_volume.foo",
 				ImportState:       true,
 				ImportStateVerify: true,
+				ImportStateVerifyIgnore: []string{
+					"adopt_existing",
+				},
+			},
+			{
+				ResourceName:      "docker_volume.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateVerifyIgnore: []string{
+					"adopt_existing",
+				},
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					return s.RootModule().Resources["docker_volume.foo"].Primary.Attributes["mountpoint"], nil
+				},
+			},
+			{
+				ResourceName:      "docker_volume.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateVerifyIgnore: []string{
+					"adopt_existing",
+				},
+				ImportStateId: "local/testAccDockerVolume_basic",
+			},
+			{
+				ResourceName:  "docker_volume.foo",
+				ImportState:   true,
+				ImportStateId: "/does/not/exist",
+				ExpectError:   regexp.MustCompile(`no volume found for '/does/not/exist'`),
+			},
+		},
+	})
+}
+
+func TestFilterVolumesByMountpoint(t *testing.T) {
+	volumes := []*types.Volume{
+		{Name: "foo", Mountpoint: "/var/lib/docker/volumes/foo/_data"},
+		{Name: "bar", Mountpoint: "/var/lib/docker/volumes/bar/_data"},
+		{Name: "remote", Mountpoint: ""},
+	}
+
+	matches := filterVolumesByMountpoint(volumes, "/var/lib/docker/volumes/foo/_data/")
+	if len(matches) != 1 || matches[0].Name != "foo" {
+		t.Fatalf("expected to match volume 'foo', got %v", matches)
+	}
+
+	matches = filterVolumesByMountpoint(volumes, "/var/lib/docker/volumes/baz/_data")
+	if len(matches) != 0 {
+		t.Fatalf("expected no matches, got %v", matches)
+	}
+}
+
+func TestFlattenVolumeDriverOpts(t *testing.T) {
+	declared := map[string]string{
+		"type":   "nfs",
+		"o":      "addr=10.0.0.1,rw",
+		"device": ":/exports/data",
+		"size":   "10G",
+	}
+	options := map[string]string{
+		"type":      "nfs",
+		"o":         "rw, addr=10.0.0.1",
+		"device":    ":/exports/other",
+		"iopsLimit": "100",
+	}
+
+	expected := map[string]string{
+		"type":   "nfs",
+		"o":      "addr=10.0.0.1,rw",
+		"device": ":/exports/other",
+		"size":   "10G",
+	}
+	if driverOpts := flattenVolumeDriverOpts(options, declared); !reflect.DeepEqual(driverOpts, expected) {
+		t.Fatalf("Expected the driver options %v, got %v", expected, driverOpts)
+	}
+	if driverOpts := flattenVolumeDriverOpts(options, nil); len(driverOpts) != 0 {
+		t.Fatalf("Expected no driver options if none are declared, got %v", driverOpts)
+	}
+}
+
+func TestAccDockerVolume_adoptExisting(t *testing.T) {
+	var v types.Volume
+	ctx := context.Background()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				PreConfig: func() {
+					client, err := testAccProvider.Meta().(*ProviderConfig).MakeClient(ctx, nil)
+					if err != nil {
+						t.Fatalf("failed to create client: %s", err)
+					}
+					_, err = client.VolumeCreate(ctx, volume.VolumeCreateBody{
+						Name:   "testAccDockerVolume_adoptExisting",
+						Labels: map[string]string{"env": "prod"},
+					})
+					if err != nil {
+						t.Fatalf("failed to create volume: %s", err)
+					}
+				},
+				Config:      fmt.Sprintf(testAccDockerVolumeAdoptExistingConfig, "dev"),
+				ExpectError: regexp.MustCompile(`label 'env' is 'prod' but 'dev' is configured`),
+			},
+			{
+				Config: fmt.Sprintf(testAccDockerVolumeAdoptExistingConfig, "prod"),
+				Check: resource.ComposeTestCheckFunc(
+					checkDockerVolumeCreated("docker_volume.foo", &v),
+					resource.TestCheckResourceAttr("docker_volume.foo", "id", "testAccDockerVolume_adoptExisting"),
+					testCheckLabelMap("docker_volume.foo", "labels", map[string]string{"env": "prod"}),
+				),
 			},
 		},
 	})
 }
 
+func TestVolumeAdoptionMismatches(t *testing.T) {
+	existing := types.Volume{
+		Name:    "foo",
+		Driver:  "local",
+		Labels:  map[string]string{"env": "prod", "team": "ops"},
+		Options: map[string]string{"type": "tmpfs"},
+	}
+
+	mismatches := volumeAdoptionMismatches(existing, volume.VolumeCreateBody{
+		Name:       "foo",
+		Labels:     map[string]string{"env": "prod", "team": "ops"},
+		DriverOpts: map[string]string{"type": "tmpfs"},
+	})
+	if len(mismatches) != 0 {
+		t.Fatalf("expected no mismatches, got %v", mismatches)
+	}
+
+	mismatches = volumeAdoptionMismatches(existing, volume.VolumeCreateBody{
+		Name:   "foo",
+		Driver: "nfs",
+		Labels: map[string]string{"env": "dev", "owner": "me"},
+	})
+	expected := []string{
+		"driver is 'local' but 'nfs' is configured",
+		"label 'env' is 'prod' but 'dev' is configured",
+		"label 'owner' is missing",
+		"label 'team' is not configured",
+		"driver option 'type' is not configured",
+	}
+	if !reflect.DeepEqual(mismatches, expected) {
+		t.Fatalf("expected %v, got %v", expected, mismatches)
+	}
+}
+
+func TestFormatVolumeCreatedAt(t *testing.T) {
+	cases := map[string]string{
+		"2023-07-31T10:15:30+02:00":      "2023-07-31T08:15:30Z",
+		"2023-07-31T08:15:30.123456789Z": "2023-07-31T08:15:30Z",
+		"":                               "",
+		"not-a-date":                     "not-a-date",
+	}
+
+	for createdAt, expected := range cases {
+		if actual := formatVolumeCreatedAt(context.Background(), createdAt); actual != expected {
+			t.Errorf("expected '%s' for '%s', got '%s'", expected, createdAt, actual)
+		}
+	}
+}
+
 func TestAccDockerVolume_full(t *testing.T) {
 	var v types.Volume
 
@@ -82,6 +253,9 @@ func TestAccDockerVolume_full(t *testing.T) {
 				ResourceName:      "docker_volume.foo",
 				ImportState:       true,
 				ImportStateVerify: true,
+				ImportStateVerifyIgnore: []string{
+					"adopt_existing",
+				},
 			},
 		},
 	})
@@ -122,6 +296,9 @@ func TestAccDockerVolume_labels(t *testing.T) {
 				ResourceName:      "docker_volume.foo",
 				ImportState:       true,
 				ImportStateVerify: true,
+				ImportStateVerifyIgnore: []string{
+					"adopt_existing",
+				},
 			},
 		},
 	})
@@ -153,3 +330,273 @@ func checkDockerVolumeCreated(n string, volume *types.Volume) resource.TestCheck
 		return nil
 	}
 }
+
+const testAccDockerVolumeAdoptExistingConfig = `
+resource "docker_volume" "foo" {
+	name           = "testAccDockerVolume_adoptExisting"
+	adopt_existing = true
+	labels {
+		label = "env"
+		value = "%s"
+	}
+}
+`
+
+func TestResourceDockerVolumeCreateWithDefaultDriver(t *testing.T) {
+	volumeName := "0fd8b2a1c5b3e4a4d7a8c0d5f8e0b7a41e3b4c0b9d8f1a2e7c6b5a4d3c2b1a09"
+	createdDrivers := []string{}
+	// a Docker host which only knows the endpoints to create and read a volume
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/volumes/create"):
+			var createOpts volume.VolumeCreateBody
+			if err := json.NewDecoder(r.Body).Decode(&createOpts); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			createdDrivers = append(createdDrivers, createOpts.Driver)
+			fmt.Fprintf(w, `{"Name": %q, "Driver": %q}`, volumeName, createOpts.Driver)
+		case strings.HasSuffix(r.URL.Path, "/volumes/"+volumeName):
+			fmt.Fprintf(w, `{"Name": %q, "Driver": %q}`, volumeName, createdDrivers[len(createdDrivers)-1])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig:       &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+		DefaultVolumeDriver: "rexray/ebs",
+	}
+	for _, driver := range []string{"", "local"} {
+		d := schema.TestResourceDataRaw(t, resourceDockerVolume().Schema, map[string]interface{}{
+			"driver": driver,
+		})
+		if diags := resourceDockerVolumeCreate(context.Background(), d, providerConfig); diags.HasError() {
+			t.Fatalf("Expected the volume to be created, got %#v", diags)
+		}
+	}
+
+	expected := []string{"rexray/ebs", "local"}
+	if !reflect.DeepEqual(createdDrivers, expected) {
+		t.Fatalf("Expected the volumes to be created with the drivers %v, got %v", expected, createdDrivers)
+	}
+}
+
+func TestResourceDockerVolumeCreateExisting(t *testing.T) {
+	created := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/volumes/create"):
+			created = true
+			fmt.Fprint(w, `{"Name": "data", "Driver": "local"}`)
+		case strings.HasSuffix(r.URL.Path, "/volumes/data"):
+			fmt.Fprint(w, `{"Name": "data", "Driver": "local"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+	d := schema.TestResourceDataRaw(t, resourceDockerVolume().Schema, map[string]interface{}{
+		"name": "data",
+	})
+	diags := resourceDockerVolumeCreate(context.Background(), d, providerConfig)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "Set 'adopt_existing'") {
+		t.Fatalf("Expected the existing volume to be rejected, got %#v", diags)
+	}
+	if created || d.Id() != "" {
+		t.Fatalf("Expected the existing volume not to be created again and not to be stored, got created %v and ID %q", created, d.Id())
+	}
+}
+
+func TestAccDockerVolume_namePrefix(t *testing.T) {
+	var v types.Volume
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				resource "docker_volume" "foo" {
+					name_prefix = "tf-test-volume"
+				}
+				`,
+				Check: resource.ComposeTestCheckFunc(
+					checkDockerVolumeCreated("docker_volume.foo", &v),
+					resource.TestMatchResourceAttr("docker_volume.foo", "name", regexp.MustCompile(`^tf-test-volume-.+`)),
+					resource.TestCheckResourceAttrPair("docker_volume.foo", "id", "docker_volume.foo", "name"),
+				),
+			},
+		},
+	})
+}
+
+func TestGenerateVolumeName(t *testing.T) {
+	inspected := []string{}
+	// a Docker host on which only the first generated name is already used
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.Contains(r.URL.Path, "/volumes/"):
+			name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			inspected = append(inspected, name)
+			if len(inspected) == 1 {
+				fmt.Fprintf(w, `{"Name": %q, "Driver": "local"}`, name)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "no such volume"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+	client, err := providerConfig.MakeClientForConfig(context.Background(), providerConfig.DefaultConfig)
+	if err != nil {
+		t.Fatalf("Unable to create the client: %s", err)
+	}
+
+	name, err := generateVolumeName(context.Background(), providerConfig, client, "cache")
+	if err != nil {
+		t.Fatalf("Expected a volume name, got %s", err)
+	}
+	if len(inspected) != 2 || name != inspected[1] || name == inspected[0] {
+		t.Fatalf("Expected the second generated name after the used one, got %q for %v", name, inspected)
+	}
+	if !strings.HasPrefix(name, "cache-") {
+		t.Fatalf("Expected the name to have the prefix 'cache-', got %q", name)
+	}
+}
+
+func TestResourceDockerVolumeReadAfterCreate(t *testing.T) {
+	inspections := 0
+	// a Docker host with a clustered driver which knows the volume only on the third inspection
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/volumes/shared"):
+			if inspections++; inspections < 3 {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"message": "no such volume"}`)
+				return
+			}
+			fmt.Fprint(w, `{"Name": "shared", "Driver": "clustered", "Scope": "global"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceDockerVolume().Schema, map[string]interface{}{"name": "shared"})
+	d.SetId("shared")
+	d.MarkNewResource()
+	if diags := resourceDockerVolumeRead(context.Background(), d, providerConfig); diags.HasError() {
+		t.Fatalf("Expected the created volume to be read, got %#v", diags)
+	}
+	if inspections != 3 || d.Get("driver") != "clustered" {
+		t.Fatalf("Expected the volume after 3 inspections, got %d inspections and %#v", inspections, d.State())
+	}
+
+	// volumes which are not new are not waited for
+	inspections = 0
+	d = schema.TestResourceDataRaw(t, resourceDockerVolume().Schema, map[string]interface{}{"name": "shared"})
+	d.SetId("shared")
+	if diags := resourceDockerVolumeRead(context.Background(), d, providerConfig); !diags.HasError() || inspections != 1 {
+		t.Fatalf("Expected an error after 1 inspection, got %d inspections and %#v", inspections, diags)
+	}
+}
+
+func TestResourceDockerVolumeReadRemovedDefaultLabel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			fmt.Fprint(w, "OK")
+		case strings.HasSuffix(r.URL.Path, "/volumes/data"):
+			fmt.Fprint(w, `{"Name": "data", "Driver": "local", "Labels": {"env": "prod", "team": "platform"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	providerConfig := &ProviderConfig{
+		DefaultConfig: &Config{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://")},
+		DefaultLabels: map[string]string{"team": "platform"},
+	}
+	d := schema.TestResourceDataRaw(t, resourceDockerVolume().Schema, map[string]interface{}{
+		"name":   "data",
+		"labels": []interface{}{map[string]interface{}{"label": "env", "value": "prod"}},
+	})
+	d.SetId("data")
+	expectedLabels := map[string]string{"env": "prod"}
+	if diags := resourceDockerVolumeRead(context.Background(), d, providerConfig); diags.HasError() {
+		t.Fatalf("Expected the volume to be read, got %#v", diags)
+	}
+	if labels := labelSetToMap(d.Get("labels").(*schema.Set)); !reflect.DeepEqual(labels, expectedLabels) {
+		t.Fatalf("Expected the labels %v, got %v", expectedLabels, labels)
+	}
+
+	// the label stays hidden after it is removed from the default labels of the provider
+	providerConfig.DefaultLabels = nil
+	if diags := resourceDockerVolumeRead(context.Background(), d, providerConfig); diags.HasError() {
+		t.Fatalf("Expected the volume to be read, got %#v", diags)
+	}
+	if labels := labelSetToMap(d.Get("labels").(*schema.Set)); !reflect.DeepEqual(labels, expectedLabels) {
+		t.Fatalf("Expected the labels %v after removing the default label, got %v", expectedLabels, labels)
+	}
+	if defaultLabels := d.Get("default_labels").(map[string]interface{}); !reflect.DeepEqual(defaultLabels, map[string]interface{}{"team": "platform"}) {
+		t.Fatalf("Expected the default label to be remembered, got %v", defaultLabels)
+	}
+}
+
+func TestVolumeSizeQuota(t *testing.T) {
+	for _, tc := range []struct {
+		driver   string
+		options  map[string]string
+		expected int64
+	}{
+		{"local", map[string]string{"size": "10G"}, 10 * 1024 * 1024 * 1024},
+		{"", map[string]string{"size": "512m"}, 512 * 1024 * 1024},
+		{"local", map[string]string{"type": "nfs"}, 0},
+		{"rexray/ebs", map[string]string{"size": "10"}, 0},
+	} {
+		quota, err := volumeSizeQuota(tc.driver, tc.options)
+		if err != nil || quota != tc.expected {
+			t.Fatalf("Expected the quota %d for %v, got %d and %v", tc.expected, tc.options, quota, err)
+		}
+	}
+	for _, size := range []string{"ten gigabytes", "0", "-1G"} {
+		if _, err := volumeSizeQuota("local", map[string]string{"size": size}); err == nil {
+			t.Fatalf("Expected an error for the size %q", size)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+// testSweepNamePrefixes are the prefixes of the names of the resources which are
+// created by the acceptance tests. Only resources with these prefixes or one of the
+// testSweepNames are swept.
+var testSweepNamePrefixes = []string{"tftest-", "tftest_", "tf-test-"}
+
+// testSweepNames are the names of the resources which are created by the acceptance
+// tests without a suffix.
+var testSweepNames = []string{"tf-test"}
+
+// TestMain runs the sweepers with 'go test ./internal/provider -v -sweep=local'
+// instead of the tests.
+func TestMain(m *testing.M) {
+	resource.TestMain(m)
+}
+
+func init() {
+	resource.AddTestSweepers("docker_container", &resource.Sweeper{
+		Name: "docker_container",
+		F:    testSweepContainers,
+	})
+	resource.AddTestSweepers("docker_network", &resource.Sweeper{
+		Name:         "docker_network",
+		F:            testSweepNetworks,
+		Dependencies: []string{"docker_container"},
+	})
+	resource.AddTestSweepers("docker_volume", &resource.Sweeper{
+		Name:         "docker_volume",
+		F:            testSweepVolumes,
+		Dependencies: []string{"docker_container"},
+	})
+}
+
+// isSweepableName returns true if the resource with the given name was
+// created by an acceptance test.
+func isSweepableName(name string) bool {
+	name = strings.TrimPrefix(name, "/")
+	for _, sweepName := range testSweepNames {
+		if name == sweepName {
+			return true
+		}
+	}
+	for _, prefix := range testSweepNamePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// testSweepClient returns a client for the Docker host of the environment, like
+// the one the acceptance tests are run against. The region of the sweepers is ignored.
+func testSweepClient() (*client.Client, error) {
+	return client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+}
+
+// sweepErrors returns an error listing the resources which failed to be removed.
+func sweepErrors(resourceType string, errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("failed to remove %d %ss: %s", len(errs), resourceType, strings.Join(errs, "; "))
+}
+
+func testSweepContainers(_ string) error {
+	ctx := context.Background()
+	client, err := testSweepClient()
+	if err != nil {
+		return err
+	}
+
+	containers, err := client.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return fmt.Errorf("unable to list containers: %w", err)
+	}
+	errs := []string{}
+	for _, container := range containers {
+		if len(container.Names) == 0 || !isSweepableName(container.Names[0]) {
+			continue
+		}
+		log.Printf("[INFO] Removing container %s", container.Names[0])
+		if err := client.ContainerRemove(ctx, container.ID, types.ContainerRemoveOptions{RemoveVolumes: true, Force: true}); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", container.Names[0], err))
+		}
+	}
+	return sweepErrors("container", errs)
+}
+
+func testSweepNetworks(_ string) error {
+	ctx := context.Background()
+	client, err := testSweepClient()
+	if err != nil {
+		return err
+	}
+
+	networks, err := client.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list networks: %w", err)
+	}
+	errs := []string{}
+	for _, network := range networks {
+		if !isSweepableName(network.Name) {
+			continue
+		}
+		log.Printf("[INFO] Removing network %s", network.Name)
+		if err := client.NetworkRemove(ctx, network.ID); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", network.Name, err))
+		}
+	}
+	return sweepErrors("network", errs)
+}
+
+func testSweepVolumes(_ string) error {
+	ctx := context.Background()
+	client, err := testSweepClient()
+	if err != nil {
+		return err
+	}
+
+	volumes, err := client.VolumeList(ctx, filters.NewArgs())
+	if err != nil {
+		return fmt.Errorf("unable to list volumes: %w", err)
+	}
+	errs := []string{}
+	for _, volume := range volumes.Volumes {
+		if !isSweepableName(volume.Name) {
+			continue
+		}
+		log.Printf("[INFO] Removing volume %s", volume.Name)
+		if err := client.VolumeRemove(ctx, volume.Name, true); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", volume.Name, err))
+		}
+	}
+	return sweepErrors("volume", errs)
+}
+
+func TestIsSweepableName(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"tf-test":               true,
+		"/tf-test-reader":       true,
+		"tftest-volume":         true,
+		"tftest_volume":         true,
+		"bridge":                false,
+		"my-tf-test":            false,
+		"tftestimonial":         false,
+		"production-db":         false,
+		"/tf-tests-are-running": false,
+		"tf-testing-prod":       false,
+	} {
+		if actual := isSweepableName(name); actual != expected {
+			t.Errorf("Expected %q to be sweepable: %t, got %t", name, expected, actual)
+		}
+	}
+}
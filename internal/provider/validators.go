@@ -3,10 +3,14 @@ package provider
 import (
 	"encoding/base64"
 	"fmt"
+	"net"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/docker/distribution/reference"
+	"github.com/docker/go-units"
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -158,3 +162,433 @@ func validateDockerContainerPath() schema.SchemaValidateDiagFunc {
 		return diags
 	}
 }
+
+// reservedLabelPrefixes are the label namespaces reserved for the use by Docker itself.
+// See https://docs.docker.com/config/labels-custom-metadata/#key-format-recommendations
+var reservedLabelPrefixes = []string{"com.docker.", "io.docker.", "org.dockerproject."}
+
+func validateLabelIsNotReserved() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+		for _, prefix := range reservedLabelPrefixes {
+			if strings.HasPrefix(value, prefix) {
+				diag := diag.Diagnostic{
+					Severity:      diag.Warning,
+					Summary:       fmt.Sprintf("'%v' is in the reserved label namespace '%s*'", value, prefix),
+					Detail:        fmt.Sprintf("Labels in the namespace '%s*' are reserved for the use by Docker. The daemon or other Docker tooling may ignore or overwrite them, which causes a diff on every plan. Use a label in your own namespace instead.", prefix),
+					AttributePath: p,
+				}
+				diags = append(diags, diag)
+			}
+		}
+		return diags
+	}
+}
+
+// validateLabelFilter checks a 'label' filter, which is either a label key or a
+// 'key=value' pair. Empty filters are rejected, as they would be dropped and widen
+// the filter to all labels.
+func validateLabelFilter() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+		if key, _, _ := strings.Cut(value, "="); strings.TrimSpace(key) == "" {
+			diag := diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       fmt.Sprintf("'%v' is not a valid label filter", value),
+				Detail:        fmt.Sprintf("'%v' is not a valid label filter, use a label key like 'env' or a pair like 'env=dev'", value),
+				AttributePath: p,
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+// stopSignalNames are the names of the Linux signals the Docker daemon accepts, without the SIG prefix.
+var stopSignalNames = regexp.MustCompile(`^(ABRT|ALRM|BUS|CHLD|CLD|CONT|FPE|HUP|ILL|INT|IO|IOT|KILL|PIPE|POLL|PROF|PWR|QUIT|SEGV|STKFLT|STOP|SYS|TERM|TRAP|TSTP|TTIN|TTOU|URG|USR1|USR2|VTALRM|WINCH|XCPU|XFSZ|RTMIN(\+([1-9]|1[0-5]))?|RTMAX(-([1-9]|1[0-4]))?)$`)
+
+func validateStopSignal() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+
+		if number, err := strconv.Atoi(value); err == nil {
+			if number < 1 || number > 64 {
+				diag := diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  fmt.Sprintf("'%v' is not a valid signal number", value),
+					Detail:   fmt.Sprintf("'%v' is not a valid signal number, it must be between 1 and 64", value),
+				}
+				diags = append(diags, diag)
+			}
+			return diags
+		}
+
+		// the daemon ignores the case and the SIG prefix
+		if !stopSignalNames.MatchString(strings.TrimPrefix(strings.ToUpper(value), "SIG")) {
+			diag := diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' is not a valid signal", value),
+				Detail:   fmt.Sprintf("'%v' is not a valid signal, use a name like 'SIGTERM' or a number like '15'", value),
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+func validateImagePlatform() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+		if value != "" && !regexp.MustCompile(`^[a-z0-9_]+(/[a-z0-9_]+(/[a-z0-9_]+)?)?$`).MatchString(value) {
+			diag := diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' is not a valid platform", value),
+				Detail:   fmt.Sprintf("'%v' is not a valid platform, it must be in the format 'os[/arch[/variant]]', e.g. 'linux/arm64'", value),
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+// validateExtraHostIP checks the IP address of an entry of /etc/hosts, which is either
+// an IP address or 'host-gateway' for the IP address of the Docker host.
+func validateExtraHostIP() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+		if value != hostGatewayName && net.ParseIP(value) == nil {
+			diag := diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' is not a valid IP address", value),
+				Detail:   fmt.Sprintf("'%v' is not a valid IP address, use an IPv4 or IPv6 address or '%s' for the IP address of the Docker host", value, hostGatewayName),
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+var hostnameLabelRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// validateHostname checks that the value is a hostname or domain name according to
+// RFC 1123, i.e. labels of letters, digits and hyphens which are separated by dots.
+func validateHostname() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+		valid := len(value) <= 253
+		for _, label := range strings.Split(value, ".") {
+			valid = valid && hostnameLabelRegexp.MatchString(label)
+		}
+		if !valid {
+			diag := diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' is not a valid hostname", value),
+				Detail:   fmt.Sprintf("'%v' is not a valid hostname, use labels of up to 63 letters, digits and hyphens separated by dots, which don't start or end with a hyphen", value),
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+// validateIPAddress checks that the value is an IPv4 or IPv6 address, e.g. of a
+// DNS server.
+func validateIPAddress() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+		if net.ParseIP(value) == nil {
+			diag := diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' is not a valid IP address", value),
+				Detail:   fmt.Sprintf("'%v' is not a valid IP address, use an IPv4 or IPv6 address", value),
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+// validateSecurityOpt checks a security option of a container, which is either
+// 'no-new-privileges' or a 'key=value' pair of one of the keys the daemon supports.
+func validateSecurityOpt() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+
+		valid := value == "no-new-privileges"
+		// the daemon still accepts ':' as separator, e.g. 'label:disable'
+		if key, option, ok := cutSecurityOpt(value); ok && option != "" {
+			switch key {
+			case "label", "apparmor", "seccomp":
+				valid = true
+			case "no-new-privileges":
+				valid = option == "true" || option == "false"
+			case "systempaths":
+				valid = option == "unconfined"
+			}
+		}
+		if !valid {
+			diag := diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' is not a valid security option", value),
+				Detail:   fmt.Sprintf("'%v' is not a valid security option, use 'no-new-privileges' or one of 'label=<option>', 'apparmor=<profile>', 'seccomp=<profile>', 'no-new-privileges=<true|false>' or 'systempaths=unconfined'", value),
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+// cutSecurityOpt splits a security option into its key and value at the first '=' or ':'.
+func cutSecurityOpt(value string) (string, string, bool) {
+	i := strings.IndexAny(value, "=:")
+	if i < 0 {
+		return value, "", false
+	}
+	return value[:i], value[i+1:], true
+}
+
+// validateMBSize checks a size which is a number of MBs or a size with unit,
+// e.g. '256m'.
+func validateMBSize() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+		if _, err := parseMBSize(value); err != nil {
+			diag := diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' is not a valid size", value),
+				Detail:   fmt.Sprintf("'%v' is not a valid size, use a number of MBs or a size with unit like '256m' or '1g': %s", value, err),
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+// validateMemorySwap checks the memory_swap, which is a size like for validateMBSize
+// or -1 for unlimited swap.
+func validateMemorySwap() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+		if _, err := parseMemorySwap(value); err != nil {
+			diag := diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' is not a valid size", value),
+				Detail:   fmt.Sprintf("'%v' is not a valid size, use a number of MBs, a size with unit like '256m' or '1g' or -1 for unlimited swap: %s", value, err),
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+// validateBytesSize checks a size in bytes, which is a number or a size with
+// unit, e.g. '10GB'.
+func validateBytesSize() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+		if _, err := units.RAMInBytes(value); err != nil {
+			diag := diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' is not a valid size", value),
+				Detail:   fmt.Sprintf("'%v' is not a valid size, use a number of bytes or a size with unit like '512MB' or '10GB': %s", value, err),
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+// validateCpus checks the number of CPUs of a container, e.g. '1.5'.
+func validateCpus() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+		if _, err := parseCpus(value); err != nil {
+			diag := diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' is not a valid number of CPUs", value),
+				Detail:   fmt.Sprintf("'%v' is not a valid number of CPUs, use a decimal number like '1.5': %s", value, err),
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+// validateDevicePermissions checks the cgroup permissions of a device, which are
+// any combination of 'r' (read), 'w' (write) and 'm' (mknod).
+func validateDevicePermissions() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+
+		valid := value != ""
+		seen := map[rune]bool{}
+		for _, c := range value {
+			if !strings.ContainsRune("rwm", c) || seen[c] {
+				valid = false
+				break
+			}
+			seen[c] = true
+		}
+
+		if !valid {
+			diag := diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' are not valid device permissions", value),
+				Detail:   fmt.Sprintf("'%v' are not valid device permissions, they must be a combination of 'r', 'w' and 'm', e.g. 'rwm'", value),
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+func validateUlimitName() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+		// the daemon parses the ulimits the same way and rejects unknown names
+		if _, err := units.ParseUlimit(value + "=0:0"); err != nil {
+			diag := diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' is not a valid ulimit", value),
+				Detail:   fmt.Sprintf("'%v' is not a valid ulimit, use a name like 'nofile' or 'nproc'", value),
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+// namespacedSysctls are the sysctls which are namespaced by the kernel and therefore
+// accepted by the daemon, besides the ones with the prefixes in namespacedSysctlPrefixes.
+// See https://docs.docker.com/engine/reference/commandline/run/#sysctl
+var (
+	namespacedSysctls = map[string]bool{
+		"kernel.msgmax":          true,
+		"kernel.msgmnb":          true,
+		"kernel.msgmni":          true,
+		"kernel.sem":             true,
+		"kernel.shmall":          true,
+		"kernel.shmmax":          true,
+		"kernel.shmmni":          true,
+		"kernel.shm_rmid_forced": true,
+	}
+	namespacedSysctlPrefixes = []string{"fs.mqueue.", "net."}
+)
+
+func validateSysctls() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		var diags diag.Diagnostics
+		for key := range v.(map[string]interface{}) {
+			if isNamespacedSysctl(key) {
+				continue
+			}
+			diag := diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' is not a namespaced sysctl", key),
+				Detail:   fmt.Sprintf("'%v' is not a namespaced sysctl and cannot be set in a container, only the IPC sysctls 'kernel.msg*', 'kernel.sem', 'kernel.shm*' and the sysctls with the prefixes 'fs.mqueue.' and 'net.' are supported", key),
+			}
+			diags = append(diags, diag)
+		}
+		return diags
+	}
+}
+
+func isNamespacedSysctl(key string) bool {
+	if namespacedSysctls[key] {
+		return true
+	}
+	for _, prefix := range namespacedSysctlPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinLogDrivers are the logging drivers shipped with the Docker daemon.
+// See https://docs.docker.com/config/containers/logging/configure/#supported-logging-drivers
+var builtinLogDrivers = map[string]bool{
+	"none":       true,
+	"local":      true,
+	"json-file":  true,
+	"syslog":     true,
+	"journald":   true,
+	"gelf":       true,
+	"fluentd":    true,
+	"awslogs":    true,
+	"splunk":     true,
+	"etwlogs":    true,
+	"gcplogs":    true,
+	"logentries": true,
+}
+
+// validateLogDriver errors on malformed driver names and warns on drivers which are
+// not built-in, because they must be installed as plugin on the Docker host.
+func validateLogDriver() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		var diags diag.Diagnostics
+
+		switch {
+		case value == "":
+		case !regexp.MustCompile(`^[a-z0-9][a-z0-9_.\-]*(/[a-z0-9][a-z0-9_.\-]*)*(:[a-zA-Z0-9_][a-zA-Z0-9_.\-]*)?$`).MatchString(value):
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("'%v' is not a valid logging driver", value),
+				Detail:   fmt.Sprintf("'%v' is not a valid logging driver, use a built-in driver like 'json-file' or the name of a logging plugin", value),
+			})
+		case value == "none":
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Warning,
+				Summary:       "The logging driver 'none' disables the logs of the container",
+				Detail:        "With the logging driver 'none' the output of the container is discarded, neither 'docker logs' nor the 'logs' attribute will return anything.",
+				AttributePath: p,
+			})
+		case !builtinLogDrivers[value]:
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Warning,
+				Summary:       fmt.Sprintf("'%v' is not a built-in logging driver", value),
+				Detail:        fmt.Sprintf("'%v' is not a built-in logging driver, make sure it is installed as plugin on the Docker host.", value),
+				AttributePath: p,
+			})
+		}
+		return diags
+	}
+}
+
+// validateImageNameDigest warns about image names with both a tag and a digest, as the
+// daemon pulls them by the digest and ignores the tag.
+func validateImageNameDigest() schema.SchemaValidateDiagFunc {
+	return func(v interface{}, p cty.Path) diag.Diagnostics {
+		value := v.(string)
+		ref, err := reference.ParseNormalizedNamed(value)
+		if err != nil {
+			return nil
+		}
+		tagged, isTagged := ref.(reference.Tagged)
+		digested, isDigested := ref.(reference.Digested)
+		if !isTagged || !isDigested {
+			return nil
+		}
+		return diag.Diagnostics{{
+			Severity:      diag.Warning,
+			Summary:       fmt.Sprintf("The tag '%s' of the image '%s' is ignored", tagged.Tag(), value),
+			Detail:        fmt.Sprintf("The image is pulled by its digest %s, the tag is neither checked against the digest nor added to the local image.", digested.Digest()),
+			AttributePath: p,
+		}}
+	}
+}
@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/go-cty/cty"
@@ -119,3 +120,258 @@ func TestValidateStringShouldBeAValidDockerContainerPath(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateLabelIsNotReserved(t *testing.T) {
+	cases := []struct {
+		Value     string
+		WarnCount int
+	}{
+		{Value: "com.example.team", WarnCount: 0},
+		{Value: "com.dockerhub.team", WarnCount: 0},
+		{Value: "docker.com.team", WarnCount: 0},
+		{Value: "com.docker.compose.project", WarnCount: 1},
+		{Value: "io.docker.foo", WarnCount: 1},
+		{Value: "org.dockerproject.foo", WarnCount: 1},
+	}
+
+	for _, tc := range cases {
+		diags := validateLabelIsNotReserved()(tc.Value, *new(cty.Path))
+
+		if diags.HasError() {
+			t.Fatalf("Expected label '%s' to only trigger warnings", tc.Value)
+		}
+		if len(diags) != tc.WarnCount {
+			t.Fatalf("Expected %d validation warnings for label '%s', got %d", tc.WarnCount, tc.Value, len(diags))
+		}
+	}
+}
+
+func TestValidateStopSignal(t *testing.T) {
+	for _, v := range []string{"SIGTERM", "TERM", "sigint", "SIGUSR1", "SIGRTMIN+3", "SIGRTMAX-1", "9"} {
+		if diags := validateStopSignal()(v, *new(cty.Path)); diags.HasError() {
+			t.Fatalf("%q should be a valid signal", v)
+		}
+	}
+	for _, v := range []string{"", "SIGFOO", "SIG", "TERMINATE", "SIGRTMIN+16", "0", "65", "-1"} {
+		if diags := validateStopSignal()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid signal", v)
+		}
+	}
+}
+
+func TestValidateImagePlatform(t *testing.T) {
+	for _, v := range []string{"", "linux", "linux/amd64", "linux/arm64/v8", "windows/amd64"} {
+		if diags := validateImagePlatform()(v, *new(cty.Path)); diags.HasError() {
+			t.Fatalf("%q should be a valid platform", v)
+		}
+	}
+	for _, v := range []string{"linux/", "/amd64", "linux/arm/v7/extra", "Linux/AMD64", "linux amd64"} {
+		if diags := validateImagePlatform()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid platform", v)
+		}
+	}
+}
+
+func TestValidateExtraHostIP(t *testing.T) {
+	for _, v := range []string{"10.0.0.1", "::1", "fe80::1", "host-gateway"} {
+		if diags := validateExtraHostIP()(v, *new(cty.Path)); diags.HasError() {
+			t.Fatalf("%q should be a valid IP address", v)
+		}
+	}
+	for _, v := range []string{"", "10.0.0", "localhost", "10.0.0.1:80", "host_gateway"} {
+		if diags := validateExtraHostIP()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid IP address", v)
+		}
+	}
+}
+
+func TestValidateSecurityOpt(t *testing.T) {
+	for _, v := range []string{"no-new-privileges", "no-new-privileges=true", "no-new-privileges:false", "label=disable", "label:user:USER", "apparmor=docker-default", "seccomp=unconfined", "seccomp=/etc/docker/seccomp.json", "systempaths=unconfined"} {
+		if diags := validateSecurityOpt()(v, *new(cty.Path)); diags.HasError() {
+			t.Fatalf("%q should be a valid security option", v)
+		}
+	}
+	for _, v := range []string{"", "no-new-privileges=yes", "label=", "seccomp", "privileged=true", "systempaths=confined", "apparmor docker-default"} {
+		if diags := validateSecurityOpt()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid security option", v)
+		}
+	}
+}
+
+func TestValidateMBSize(t *testing.T) {
+	for _, v := range []string{"0", "128", "256m", "1g", "1GB", "512k"} {
+		if diags := validateMBSize()(v, *new(cty.Path)); diags.HasError() {
+			t.Fatalf("%q should be a valid size", v)
+		}
+	}
+	for _, v := range []string{"", "-1", "256x", "m", "1.5.g"} {
+		if diags := validateMBSize()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid size", v)
+		}
+	}
+}
+
+func TestValidateMemorySwap(t *testing.T) {
+	for _, v := range []string{"-1", "0", "2048", "2g"} {
+		if diags := validateMemorySwap()(v, *new(cty.Path)); diags.HasError() {
+			t.Fatalf("%q should be a valid memory swap", v)
+		}
+	}
+	for _, v := range []string{"", "-2", "2x"} {
+		if diags := validateMemorySwap()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid memory swap", v)
+		}
+	}
+}
+
+func TestValidateLabelFilter(t *testing.T) {
+	for _, v := range []string{"env", "env=dev", "env="} {
+		if diags := validateLabelFilter()(v, *new(cty.Path)); diags.HasError() {
+			t.Fatalf("%q should be a valid label filter", v)
+		}
+	}
+	for _, v := range []string{"", " ", "=dev", " =dev"} {
+		if diags := validateLabelFilter()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid label filter", v)
+		}
+	}
+}
+
+func TestValidateBytesSize(t *testing.T) {
+	for _, v := range []string{"0", "1024", "512MB", "10GB", "1g", "1.5GiB"} {
+		if diags := validateBytesSize()(v, *new(cty.Path)); diags.HasError() {
+			t.Fatalf("%q should be a valid size", v)
+		}
+	}
+	for _, v := range []string{"", "-1", "10XB", "GB"} {
+		if diags := validateBytesSize()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid size", v)
+		}
+	}
+}
+
+func TestValidateCpus(t *testing.T) {
+	for _, v := range []string{"1", "1.5", "0.001", "16"} {
+		if diags := validateCpus()(v, *new(cty.Path)); diags.HasError() {
+			t.Fatalf("%q should be a valid number of CPUs", v)
+		}
+	}
+	for _, v := range []string{"-1", "one", "1.5.2"} {
+		if diags := validateCpus()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid number of CPUs", v)
+		}
+	}
+}
+
+func TestValidateHostname(t *testing.T) {
+	for _, v := range []string{"web", "web-1", "db.example.com", "1host", strings.Repeat("a", 63)} {
+		if diags := validateHostname()(v, *new(cty.Path)); diags.HasError() {
+			t.Fatalf("%q should be a valid hostname", v)
+		}
+	}
+	for _, v := range []string{"", "-web", "web-", "web_1", "web..example", "example.com.", strings.Repeat("a", 64)} {
+		if diags := validateHostname()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid hostname", v)
+		}
+	}
+}
+
+func TestValidateIPAddress(t *testing.T) {
+	for _, v := range []string{"8.8.8.8", "10.0.0.1", "2001:4860:4860::8888", "::1"} {
+		if diags := validateIPAddress()(v, *new(cty.Path)); diags.HasError() {
+			t.Fatalf("%q should be a valid IP address", v)
+		}
+	}
+	for _, v := range []string{"", "dns.google", "8.8.8", "10.0.0.1:53"} {
+		if diags := validateIPAddress()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid IP address", v)
+		}
+	}
+}
+
+func TestValidateDevicePermissions(t *testing.T) {
+	for _, v := range []string{"r", "rw", "rwm", "mr", "wmr"} {
+		if diags := validateDevicePermissions()(v, *new(cty.Path)); diags.HasError() {
+			t.Fatalf("%q should be valid device permissions", v)
+		}
+	}
+	for _, v := range []string{"", "x", "rwx", "rr", "RWM", "rw m"} {
+		if diags := validateDevicePermissions()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be invalid device permissions", v)
+		}
+	}
+}
+
+func TestValidateUlimitName(t *testing.T) {
+	for _, v := range []string{"nofile", "nproc", "memlock", "core", "stack"} {
+		if diags := validateUlimitName()(v, *new(cty.Path)); diags.HasError() {
+			t.Fatalf("%q should be a valid ulimit", v)
+		}
+	}
+	for _, v := range []string{"", "files", "NOFILE", "nofile=1"} {
+		if diags := validateUlimitName()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid ulimit", v)
+		}
+	}
+}
+
+func TestValidateSysctls(t *testing.T) {
+	valid := map[string]interface{}{
+		"net.core.somaxconn":     "1024",
+		"net.ipv4.ip_forward":    "1",
+		"kernel.shmmax":          "68719476736",
+		"kernel.shm_rmid_forced": "1",
+		"fs.mqueue.msg_max":      "100",
+	}
+	if diags := validateSysctls()(valid, *new(cty.Path)); diags.HasError() {
+		t.Fatalf("%v should be valid sysctls", valid)
+	}
+	for _, v := range []string{"vm.swappiness", "kernel.pid_max", "fs.file-max", "network.foo"} {
+		if diags := validateSysctls()(map[string]interface{}{v: "1"}, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid sysctl", v)
+		}
+	}
+}
+
+func TestValidateLogDriver(t *testing.T) {
+	for _, v := range []string{"", "json-file", "local", "fluentd", "gelf", "awslogs"} {
+		if diags := validateLogDriver()(v, *new(cty.Path)); len(diags) > 0 {
+			t.Fatalf("%q should be a valid logging driver without warnings", v)
+		}
+	}
+	for _, v := range []string{"none", "grafana/loki-docker-driver:latest", "rchicoli/docker-log-elasticsearch"} {
+		if diags := validateLogDriver()(v, *new(cty.Path)); diags.HasError() || len(diags) != 1 {
+			t.Fatalf("%q should be a valid logging driver with a warning", v)
+		}
+	}
+	for _, v := range []string{"json file", "JSON-FILE", "-local", "loki:"} {
+		if diags := validateLogDriver()(v, *new(cty.Path)); !diags.HasError() {
+			t.Fatalf("%q should be an invalid logging driver", v)
+		}
+	}
+}
+
+func TestValidateImageNameDigest(t *testing.T) {
+	digest := "sha256:0d2ce2b8a6e4a3b0b2a5c1c8d0e9f8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1"
+	cases := []struct {
+		Value     string
+		WarnCount int
+	}{
+		{Value: "alpine:3.18", WarnCount: 0},
+		{Value: "alpine@" + digest, WarnCount: 0},
+		{Value: "alpine:3.18@" + digest, WarnCount: 1},
+		{Value: "registry.com:5000/app:1.0@" + digest, WarnCount: 1},
+		{Value: "Invalid/Name:1.0", WarnCount: 0},
+	}
+
+	for _, tc := range cases {
+		diags := validateImageNameDigest()(tc.Value, *new(cty.Path))
+
+		if diags.HasError() {
+			t.Fatalf("Expected image '%s' to only trigger warnings", tc.Value)
+		}
+		if len(diags) != tc.WarnCount {
+			t.Fatalf("Expected %d validation warnings for image '%s', got %d", tc.WarnCount, tc.Value, len(diags))
+		}
+	}
+}
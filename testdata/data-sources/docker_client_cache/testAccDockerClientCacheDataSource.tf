@@ -0,0 +1,7 @@
+data "docker_network" "bridge" {
+  name = "bridge"
+}
+
+data "docker_client_cache" "test" {
+  depends_on = [data.docker_network.bridge]
+}
\ No newline at end of file
@@ -0,0 +1,17 @@
+resource "docker_image" "foo" {
+  name         = "nginx:latest"
+  keep_locally = true
+}
+
+resource "docker_container" "foo" {
+  name  = "tf-test-container-data-source"
+  image = docker_image.foo.image_id
+
+  ports {
+    internal = 80
+  }
+}
+
+data "docker_container" "foo" {
+  name = docker_container.foo.name
+}
\ No newline at end of file
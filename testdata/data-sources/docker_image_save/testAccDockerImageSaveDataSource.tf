@@ -0,0 +1,10 @@
+data "docker_image_save" "foo" {
+  name = "busybox:1.34.0"
+  path = "%s"
+}
+
+resource "docker_image" "foo" {
+  name         = "busybox:1.34.0"
+  source_tar   = data.docker_image_save.foo.path
+  keep_locally = true
+}
\ No newline at end of file
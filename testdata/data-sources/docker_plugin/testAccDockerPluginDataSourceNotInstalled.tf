@@ -0,0 +1,3 @@
+data "docker_plugin" "test" {
+  alias = "tiborvass/not-installed-plugin:latest"
+}
\ No newline at end of file
@@ -0,0 +1,3 @@
+data "docker_registry_image_manifest" "foo" {
+  name = "alpine:latest"
+}
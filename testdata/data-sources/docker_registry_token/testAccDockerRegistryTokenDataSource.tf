@@ -0,0 +1,11 @@
+provider "docker" {
+  alias = "private"
+  registry_auth {
+    address = "%s"
+  }
+}
+data "docker_registry_token" "foobar" {
+  provider             = "docker.private"
+  address              = "%s"
+  insecure_skip_verify = true
+}
\ No newline at end of file
@@ -0,0 +1,21 @@
+resource "docker_volume" "foo" {
+  name = "tftest-resources-by-label"
+  labels {
+    label = "com.example.tf-test"
+    value = "resources-by-label"
+  }
+}
+
+resource "docker_network" "foo" {
+  name = "tftest-resources-by-label"
+  labels {
+    label = "com.example.tf-test"
+    value = "resources-by-label"
+  }
+}
+
+data "docker_resources_by_label" "test" {
+  label_filters = ["com.example.tf-test=resources-by-label"]
+
+  depends_on = [docker_volume.foo, docker_network.foo]
+}
\ No newline at end of file
@@ -0,0 +1,11 @@
+resource "docker_volume" "foo" {
+  name = "tftest-volume-exists"
+}
+
+data "docker_volume_exists" "existing" {
+  name = docker_volume.foo.name
+}
+
+data "docker_volume_exists" "missing" {
+  name = "tftest-volume-missing"
+}
\ No newline at end of file
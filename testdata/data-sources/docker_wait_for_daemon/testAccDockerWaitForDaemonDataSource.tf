@@ -0,0 +1,4 @@
+data "docker_wait_for_daemon" "test" {
+  timeout  = "30s"
+  interval = "1s"
+}
\ No newline at end of file
@@ -0,0 +1,6 @@
+data "docker_wait_for_daemon" "test" {
+  host            = "tcp://127.0.0.1:1"
+  timeout         = "2s"
+  interval        = "500ms"
+  fail_on_timeout = %t
+}
\ No newline at end of file
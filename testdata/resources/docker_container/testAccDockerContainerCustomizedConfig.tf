@@ -80,6 +80,7 @@ resource "docker_container" "foo" {
   }
 
   pid_mode    = "host"
+  uts_mode    = "host"
   userns_mode = "testuser:231072:65536"
   ipc_mode    = "private"
   working_dir = "/tmp"
@@ -0,0 +1,33 @@
+resource "docker_image" "foo" {
+  name = "nginx:latest"
+}
+
+resource "docker_volume" "shared" {
+  name = "testAccDockerContainerSharedVolume_volume"
+}
+
+resource "docker_container" "writer" {
+  name  = "tf-test-writer"
+  image = docker_image.foo.image_id
+
+  mounts {
+    target = "/mount/shared"
+    source = docker_volume.shared.name
+    type   = "volume"
+  }
+}
+
+resource "docker_container" "reader" {
+  name  = "tf-test-reader"
+  image = docker_image.foo.image_id
+
+  mounts {
+    target    = "/mount/shared"
+    source    = docker_volume.shared.name
+    type      = "volume"
+    read_only = true
+    volume_options {
+      no_copy = true
+    }
+  }
+}
\ No newline at end of file
@@ -0,0 +1,9 @@
+resource "docker_image" "foo" {
+  name = "nginx:latest"
+}
+
+resource "docker_container" "foo" {
+  name     = "tf-test"
+  image    = docker_image.foo.image_id
+  shm_size = "256m"
+}
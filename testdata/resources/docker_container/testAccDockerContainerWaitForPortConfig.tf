@@ -0,0 +1,19 @@
+resource "docker_image" "foo" {
+  name         = "nginx:latest"
+  keep_locally = true
+}
+
+resource "docker_container" "foo" {
+  name  = "tf-test"
+  image = docker_image.foo.image_id
+
+  ports {
+    internal = 80
+  }
+
+  wait_for_port {
+    port     = 80
+    protocol = "http"
+    timeout  = 30
+  }
+}
\ No newline at end of file
@@ -0,0 +1,14 @@
+resource "docker_network" "foo" {
+  name     = "tf-test-macvlan"
+  driver   = "macvlan"
+  internal = true
+
+  options = {
+    macvlan_mode = "bridge"
+  }
+
+  ipam_config {
+    subnet  = "172.30.10.0/24"
+    gateway = "172.30.10.1"
+  }
+}
\ No newline at end of file
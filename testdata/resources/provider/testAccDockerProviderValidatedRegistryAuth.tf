@@ -0,0 +1,14 @@
+provider "docker" {
+    alias         = "private"
+    validate_auth = true
+    registry_auth {
+      address  = "%s"
+      username = "testuser"
+      password = "testpwd"
+    }
+}
+data "docker_registry_image" "foobar" {
+    provider             = "docker.private"
+    name                 = "127.0.0.1:15000/tftest-service:v1"
+    insecure_skip_verify = true
+}
\ No newline at end of file